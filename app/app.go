@@ -5,11 +5,13 @@ import (
 	"claude-squad/keys"
 	"claude-squad/log"
 	"claude-squad/session"
+	"claude-squad/session/git"
 	"claude-squad/ui"
 	"claude-squad/ui/overlay"
 	"context"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/charmbracelet/bubbles/spinner"
@@ -42,6 +44,14 @@ const (
 	stateHelp
 	// stateConfirm is the state when a confirmation modal is displayed.
 	stateConfirm
+	// stateComment is the state when the user is writing a review comment on a diff line.
+	stateComment
+	// stateWiden is the state when the user is entering directories to add to a sparse checkout.
+	stateWiden
+	// stateRename is the state when the user is entering a new title for an instance.
+	stateRename
+	// stateGate is the state when the pre-push checks of an instance are running or failed.
+	stateGate
 )
 
 type home struct {
@@ -69,10 +79,15 @@ type home struct {
 
 	// promptAfterName tracks if we should enter prompt mode after naming
 	promptAfterName bool
+	// pendingComment is the review comment being written in stateComment.
+	pendingComment *session.ReviewComment
 
 	// keySent is used to manage underlining menu items
 	keySent bool
 
+	// diffs computes instance diffs in the background
+	diffs *diffScheduler
+
 	// -- UI Components --
 
 	// list displays the list of instances
@@ -91,6 +106,15 @@ type home struct {
 	textOverlay *overlay.TextOverlay
 	// confirmationOverlay displays confirmation modals
 	confirmationOverlay *overlay.ConfirmationOverlay
+	// confirmedMsg is the message returned by the last confirmed action. It is fed back into Update
+	// once the confirmation overlay closes so errors are shown.
+	confirmedMsg tea.Msg
+	// gate is the run of pre-push checks shown in stateGate.
+	gate *gateRun
+	// commitsLoading is set while the commits of the selected instance are loaded, see loadCommits.
+	commitsLoading bool
+	// width and height are the size of the terminal.
+	width, height int
 }
 
 func newHome(ctx context.Context, program string, autoYes bool) *home {
@@ -111,7 +135,7 @@ func newHome(ctx context.Context, program string, autoYes bool) *home {
 		ctx:          ctx,
 		spinner:      spinner.New(spinner.WithSpinner(spinner.MiniDot)),
 		menu:         ui.NewMenu(),
-		tabbedWindow: ui.NewTabbedWindow(ui.NewPreviewPane(), ui.NewDiffPane()),
+		tabbedWindow: ui.NewTabbedWindow(ui.NewPreviewPane(), ui.NewDiffPane(), ui.NewCommitsPane()),
 		errBox:       ui.NewErrBox(),
 		storage:      storage,
 		appConfig:    appConfig,
@@ -119,6 +143,7 @@ func newHome(ctx context.Context, program string, autoYes bool) *home {
 		autoYes:      autoYes,
 		state:        stateDefault,
 		appState:     appState,
+		diffs:        newDiffScheduler(),
 	}
 	h.list = ui.NewList(&h.spinner, autoYes)
 
@@ -162,6 +187,10 @@ func (m *home) updateHandleWindowSizeEvent(msg tea.WindowSizeMsg) {
 	if m.textOverlay != nil {
 		m.textOverlay.SetWidth(int(float32(msg.Width) * 0.6))
 	}
+	m.width, m.height = msg.Width, msg.Height
+	if m.gate != nil {
+		m.gate.overlay.SetSize(int(float32(msg.Width)*0.8), int(float32(msg.Height)*0.8))
+	}
 
 	previewWidth, previewHeight := m.tabbedWindow.GetPreviewSize()
 	if err := m.list.SetSessionPreviewSize(previewWidth, previewHeight); err != nil {
@@ -171,9 +200,15 @@ func (m *home) updateHandleWindowSizeEvent(msg tea.WindowSizeMsg) {
 }
 
 func (m *home) Init() tea.Cmd {
+	// Open actions bound to keys that are taken would silently do nothing.
+	var configErr tea.Cmd
+	if err := m.appConfig.ValidateOpenActions(); err != nil {
+		configErr = m.handleError(err)
+	}
 	// Upon starting, we want to start the spinner. Whenever we get a spinner.TickMsg, we
 	// update the spinner, which sends a new spinner.TickMsg. I think this lasts forever lol.
 	return tea.Batch(
+		configErr,
 		m.spinner.Tick,
 		func() tea.Msg {
 			time.Sleep(100 * time.Millisecond)
@@ -200,8 +235,15 @@ func (m *home) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.menu.ClearKeydown()
 		return m, nil
 	case tickUpdateMetadataMessage:
+		cmds := []tea.Cmd{tickUpdateMetadataCmd}
 		for _, instance := range m.list.GetInstances() {
-			if !instance.Started() || instance.Paused() {
+			if !instance.Started() {
+				continue
+			}
+			if instance.Paused() || instance.Frozen() {
+				// The agent isn't working, but its branch can still change, e.g. by committing to it in
+				// the main checkout. Reading the pane of a frozen agent would mark it running again.
+				cmds = append(cmds, m.diffs.schedule(instance))
 				continue
 			}
 			updated, prompt := instance.HasUpdated()
@@ -214,17 +256,26 @@ func (m *home) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					instance.SetStatus(session.Ready)
 				}
 			}
-			if err := instance.UpdateDiffStats(); err != nil {
-				log.WarningLog.Printf("could not update diff stats: %v", err)
-			}
+			cmds = append(cmds, m.diffs.schedule(instance))
+		}
+		// Killed instances drop out of the overlaps here.
+		m.list.SetOverlaps(session.FindOverlaps(m.list.GetInstances()))
+		// Keep the commits tab up to date as the agent commits.
+		cmds = append(cmds, m.loadCommits(true))
+		return m, tea.Batch(cmds...)
+	case diffUpdatedMsg:
+		m.diffs.done(msg.instance)
+		if err := msg.instance.ApplyDiff(msg.update); err != nil {
+			log.WarningLog.Printf("could not update diff stats: %v", err)
 		}
-		return m, tickUpdateMetadataCmd
+		m.list.SetOverlaps(session.FindOverlaps(m.list.GetInstances()))
+		return m, nil
 	case tea.MouseMsg:
 		// Handle mouse wheel events for scrolling the diff/preview pane
 		if msg.Action == tea.MouseActionPress {
 			if msg.Button == tea.MouseButtonWheelDown || msg.Button == tea.MouseButtonWheelUp {
 				selected := m.list.GetSelectedInstance()
-				if selected == nil || selected.Status == session.Paused {
+				if selected == nil {
 					return m, nil
 				}
 
@@ -248,6 +299,17 @@ func (m *home) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case instanceChangedMsg:
 		// Handle instance changed after confirmation action
 		return m, m.instanceChanged()
+	case startGateMsg:
+		m.gate = startGate(m.ctx, msg)
+		m.gate.overlay.SetSize(int(float32(m.width)*0.8), int(float32(m.height)*0.8))
+		m.state = stateGate
+		return m, m.gate.wait()
+	case gateOutputMsg, gateDoneMsg:
+		return m, m.handleGateMsg(msg)
+	case commitsLoadedMsg:
+		return m, m.handleCommitsLoaded(msg)
+	case commitsRefreshMsg:
+		return m, tea.Batch(m.instanceChanged(), m.loadCommits(true))
 	case spinner.TickMsg:
 		var cmd tea.Cmd
 		m.spinner, cmd = m.spinner.Update(msg)
@@ -270,7 +332,8 @@ func (m *home) handleMenuHighlighting(msg tea.KeyMsg) (cmd tea.Cmd, returnEarly
 		m.keySent = false
 		return nil, false
 	}
-	if m.state == statePrompt || m.state == stateHelp || m.state == stateConfirm {
+	if m.state == statePrompt || m.state == stateHelp || m.state == stateConfirm || m.state == stateComment ||
+		m.state == stateWiden || m.state == stateRename || m.state == stateGate {
 		return nil, false
 	}
 	// If it's in the global keymap, we should try to highlight it.
@@ -377,6 +440,11 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 			if err := instance.SetTitle(instance.Title + " "); err != nil {
 				return m, m.handleError(err)
 			}
+		case tea.KeyTab:
+			// Cycle through starting clean, copying and moving the main checkout's uncommitted changes.
+			if git.IsGitRepo(instance.Path) {
+				instance.SetCarryChanges(instance.CarryChanges().Next())
+			}
 		case tea.KeyEsc:
 			m.list.Kill()
 			m.state = stateDefault
@@ -424,6 +492,64 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 		}
 
 		return m, nil
+	} else if m.state == stateComment {
+		shouldClose := m.textInputOverlay.HandleKeyPress(msg)
+		if shouldClose {
+			selected := m.list.GetSelectedInstance()
+			if selected != nil && m.pendingComment != nil && m.textInputOverlay.IsSubmitted() {
+				comment := *m.pendingComment
+				comment.Body = strings.TrimSpace(m.textInputOverlay.GetValue())
+				selected.SetReviewComment(comment)
+			}
+
+			m.textInputOverlay = nil
+			m.pendingComment = nil
+			m.state = stateDefault
+			m.menu.SetState(ui.StateDefault)
+			return m, tea.Batch(tea.WindowSize(), m.instanceChanged())
+		}
+
+		return m, nil
+	} else if m.state == stateWiden {
+		shouldClose := m.textInputOverlay.HandleKeyPress(msg)
+		if shouldClose {
+			var cmd tea.Cmd
+			selected := m.list.GetSelectedInstance()
+			if selected != nil && m.textInputOverlay.IsSubmitted() {
+				if err := selected.WidenSparseCheckout(strings.Fields(m.textInputOverlay.GetValue())); err != nil {
+					cmd = m.handleError(err)
+				} else {
+					cmd = m.diffs.schedule(selected)
+				}
+			}
+
+			m.textInputOverlay = nil
+			m.state = stateDefault
+			m.menu.SetState(ui.StateDefault)
+			return m, tea.Batch(tea.WindowSize(), cmd)
+		}
+
+		return m, nil
+	} else if m.state == stateRename {
+		shouldClose := m.textInputOverlay.HandleKeyPress(msg)
+		if shouldClose {
+			var cmd tea.Cmd
+			selected := m.list.GetSelectedInstance()
+			title := strings.TrimSpace(m.textInputOverlay.GetValue())
+			m.textInputOverlay = nil
+			m.state = stateDefault
+			m.menu.SetState(ui.StateDefault)
+			if selected != nil && title != "" && title != selected.Title {
+				cmd = m.renameInstance(selected, title)
+			}
+			return m, tea.Batch(tea.WindowSize(), m.instanceChanged(), cmd)
+		}
+
+		return m, nil
+	}
+
+	if m.state == stateGate {
+		return m.handleGateKeyPress(msg)
 	}
 
 	// Handle confirmation state
@@ -432,6 +558,10 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 		if shouldClose {
 			m.state = stateDefault
 			m.confirmationOverlay = nil
+			if confirmedMsg := m.confirmedMsg; confirmedMsg != nil {
+				m.confirmedMsg = nil
+				return m, func() tea.Msg { return confirmedMsg }
+			}
 			return m, nil
 		}
 		return m, nil
@@ -460,7 +590,8 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 
 	name, ok := keys.GlobalKeyStringsMap[msg.String()]
 	if !ok {
-		return m, nil
+		// Keys that aren't taken can be bound to open actions in the config.
+		return m, m.handleOpenKey(msg.String())
 	}
 
 	switch name {
@@ -519,10 +650,32 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 	case keys.KeyShiftDown:
 		m.tabbedWindow.ScrollDown()
 		return m, m.instanceChanged()
+	case keys.KeyDiffNextFile, keys.KeyDiffPrevFile, keys.KeyDiffNextHunk, keys.KeyDiffPrevHunk,
+		keys.KeyDiffToggleFile, keys.KeyDiffFilter, keys.KeyDiffSideBySide, keys.KeyDiffWordDiff,
+		keys.KeyDiffNextLine, keys.KeyDiffPrevLine:
+		return m, m.handleDiffNavigation(name)
+	case keys.KeyDiffComment, keys.KeyDiffSubmitReview:
+		return m, m.handleDiffReview(name)
+	case keys.KeyDiffBaseline:
+		if !m.tabbedWindow.IsInDiffTab() {
+			return m, nil
+		}
+		selected := m.list.GetSelectedInstance()
+		if selected == nil {
+			return m, nil
+		}
+		if _, err := selected.CycleDiffBaseline(); err != nil {
+			return m, m.handleError(err)
+		}
+		return m, m.diffs.schedule(selected)
+	case keys.KeyDiffAcceptHunk, keys.KeyDiffDiscardHunk, keys.KeyDiffRevertFile:
+		return m, m.handleDiffHunkAction(name)
+	case keys.KeyCommitView, keys.KeyCommitCherryPick, keys.KeyCommitRevert:
+		return m, m.handleCommitAction(name)
 	case keys.KeyTab:
 		m.tabbedWindow.Toggle()
 		m.menu.SetInDiffTab(m.tabbedWindow.IsInDiffTab())
-		return m, m.instanceChanged()
+		return m, tea.Batch(m.instanceChanged(), m.loadCommits(true))
 	case keys.KeyKill:
 		selected := m.list.GetSelectedInstance()
 		if selected == nil {
@@ -531,23 +684,33 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 
 		// Create the kill action as a tea.Cmd
 		killAction := func() tea.Msg {
-			// Get worktree and check if branch is checked out
-			worktree, err := selected.GetGitWorktree()
-			if err != nil {
-				return err
-			}
+			// Get worktree and check if branch is checked out. Snapshots have no branch.
+			if !selected.IsSnapshot() {
+				worktree, err := selected.GetGitWorktree()
+				if err != nil {
+					return err
+				}
 
-			checkedOut, err := worktree.IsBranchCheckedOut()
-			if err != nil {
-				return err
-			}
+				// Switch the repository back if the branch was checked out with the checkout key,
+				// before anything is deleted.
+				if selected.CheckedOut() {
+					if err := selected.ReturnCheckout(); err != nil {
+						return err
+					}
+				}
+
+				checkedOut, err := worktree.IsBranchCheckedOut()
+				if err != nil {
+					return err
+				}
 
-			if checkedOut {
-				return fmt.Errorf("instance %s is currently checked out", selected.Title)
+				if checkedOut {
+					return fmt.Errorf("instance %s is currently checked out", selected.Title)
+				}
 			}
 
 			// Delete from storage first
-			if err := m.storage.DeleteInstance(selected.Title); err != nil {
+			if err := m.storage.DeleteInstance(selected.ID); err != nil {
 				return err
 			}
 
@@ -558,6 +721,9 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 
 		// Show confirmation modal
 		message := fmt.Sprintf("[!] Kill session '%s'?", selected.Title)
+		if selected.CheckedOut() {
+			message = fmt.Sprintf("[!] Kill session '%s'? The repository switches back from its branch first.", selected.Title)
+		}
 		return m, m.confirmAction(message, killAction)
 	case keys.KeySubmit:
 		selected := m.list.GetSelectedInstance()
@@ -565,6 +731,26 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 			return m, nil
 		}
 
+		if selected.IsSnapshot() {
+			applyAction := func() tea.Msg {
+				if _, err := selected.ApplyToSource(); err != nil {
+					return err
+				}
+				if err := selected.UpdateDiffStats(); err != nil {
+					return err
+				}
+				return instanceChangedMsg{}
+			}
+			message := fmt.Sprintf("[!] Apply changes from session '%s' to %s?", selected.Title, selected.Path)
+			return m, m.confirmAction(message, gated(selected, applyAction))
+		}
+
+		// Accepted hunks that were committed or changed since can't be pushed anymore.
+		if err := selected.PruneAcceptedHunks(); err != nil {
+			return m, m.handleError(err)
+		}
+		acceptedCount := len(selected.AcceptedHunks())
+
 		// Create the push action as a tea.Cmd
 		pushAction := func() tea.Msg {
 			// Default commit message with timestamp
@@ -573,6 +759,18 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 			if err != nil {
 				return err
 			}
+			// If hunks were accepted in the diff tab, only those get committed.
+			if acceptedCount > 0 {
+				err := selected.PushAcceptedHunks(commitMsg, true)
+				// The committed hunks are no longer accepted, even if the push itself failed.
+				if saveErr := m.storage.SaveInstances(m.list.GetInstances()); saveErr != nil && err == nil {
+					err = saveErr
+				}
+				if err != nil {
+					return err
+				}
+				return instanceChangedMsg{}
+			}
 			if err = worktree.PushChanges(commitMsg, true); err != nil {
 				return err
 			}
@@ -581,16 +779,36 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 
 		// Show confirmation modal
 		message := fmt.Sprintf("[!] Push changes from session '%s'?", selected.Title)
-		return m, m.confirmAction(message, pushAction)
+		if acceptedCount > 0 {
+			message = fmt.Sprintf("[!] Push the %d accepted hunks from session '%s'?", acceptedCount, selected.Title)
+		}
+		return m, m.confirmAction(message, gated(selected, pushAction))
 	case keys.KeyCheckout:
 		selected := m.list.GetSelectedInstance()
 		if selected == nil {
 			return m, nil
 		}
+		if selected.IsSnapshot() {
+			// Snapshots have no branch to check out, just pause.
+			if err := selected.Pause(); err != nil {
+				return m, m.handleError(err)
+			}
+			return m, m.instanceChanged()
+		}
 
-		// Show help screen before pausing
+		if selected.CheckedOut() {
+			if err := selected.ReturnCheckout(); err != nil {
+				return m, m.handleError(err)
+			}
+			return m, m.instanceChanged()
+		}
+		if other := m.checkedOutInRepo(selected); other != nil {
+			return m, m.handleError(fmt.Errorf("'%s' is checked out in this repository, switch back from it first", other.Title))
+		}
+
+		// Show help screen before checking out
 		m.showHelpScreen(helpTypeInstanceCheckout{}, func() {
-			if err := selected.Pause(); err != nil {
+			if err := selected.CheckOut(); err != nil {
 				m.handleError(err)
 			}
 			m.instanceChanged()
@@ -605,6 +823,59 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 			return m, m.handleError(err)
 		}
 		return m, tea.WindowSize()
+	case keys.KeyWiden:
+		selected := m.list.GetSelectedInstance()
+		if selected == nil || !selected.Started() {
+			return m, nil
+		}
+		worktree, err := selected.GetGitWorktree()
+		if err != nil {
+			return m, m.handleError(err)
+		}
+		if !worktree.IsSparse() {
+			return m, m.handleError(fmt.Errorf("'%s' already has the full tree checked out", selected.Title))
+		}
+		m.state = stateWiden
+		m.menu.SetState(ui.StatePrompt)
+		m.textInputOverlay = overlay.NewTextInputOverlay(
+			"Directories to add to "+strings.Join(worktree.GetSparsePaths(), ", ")+" (space separated)", "")
+		return m, tea.WindowSize()
+	case keys.KeyRename:
+		selected := m.list.GetSelectedInstance()
+		if selected == nil || !selected.Started() {
+			return m, nil
+		}
+		m.state = stateRename
+		m.menu.SetState(ui.StatePrompt)
+		m.textInputOverlay = overlay.NewTextInputOverlay("New title for '"+selected.Title+"'", selected.Title)
+		return m, tea.WindowSize()
+	case keys.KeyOverlaps:
+		return m.showOverlaps()
+	case keys.KeyWindow:
+		if m.tabbedWindow.IsInDiffTab() || m.tabbedWindow.IsInCommitsTab() {
+			return m, nil
+		}
+		selected := m.list.GetSelectedInstance()
+		if selected == nil || !selected.Started() || selected.Paused() {
+			return m, nil
+		}
+		if err := selected.NextWindow(); err != nil {
+			return m, m.handleError(err)
+		}
+		return m, m.instanceChanged()
+	case keys.KeyFreeze:
+		selected := m.list.GetSelectedInstance()
+		if selected == nil || !selected.Started() {
+			return m, nil
+		}
+		if selected.Frozen() {
+			if err := selected.Thaw(); err != nil {
+				return m, m.handleError(err)
+			}
+		} else if err := selected.Freeze(); err != nil {
+			return m, m.handleError(err)
+		}
+		return m, m.instanceChanged()
 	case keys.KeyEnter:
 		if m.list.NumInstances() == 0 {
 			return m, nil
@@ -629,6 +900,230 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 	}
 }
 
+// handleDiffNavigation moves around the diff tab. The keys are ignored outside the diff tab.
+func (m *home) handleDiffNavigation(name keys.KeyName) tea.Cmd {
+	if m.tabbedWindow.IsInCommitsTab() {
+		// The line cursor keys move through the commits.
+		switch name {
+		case keys.KeyDiffNextLine:
+			m.tabbedWindow.CommitsNext()
+		case keys.KeyDiffPrevLine:
+			m.tabbedWindow.CommitsPrev()
+		}
+		return nil
+	}
+	if !m.tabbedWindow.IsInDiffTab() {
+		return nil
+	}
+	switch name {
+	case keys.KeyDiffNextFile:
+		m.tabbedWindow.DiffNextFile()
+	case keys.KeyDiffPrevFile:
+		m.tabbedWindow.DiffPrevFile()
+	case keys.KeyDiffNextHunk:
+		m.tabbedWindow.DiffNextHunk()
+	case keys.KeyDiffPrevHunk:
+		m.tabbedWindow.DiffPrevHunk()
+	case keys.KeyDiffToggleFile:
+		m.tabbedWindow.DiffToggleFile()
+	case keys.KeyDiffFilter:
+		m.tabbedWindow.DiffToggleFilter()
+	case keys.KeyDiffSideBySide:
+		m.tabbedWindow.DiffToggleSideBySide()
+	case keys.KeyDiffWordDiff:
+		m.tabbedWindow.DiffToggleWordDiff()
+	case keys.KeyDiffNextLine:
+		m.tabbedWindow.DiffNextLine()
+	case keys.KeyDiffPrevLine:
+		m.tabbedWindow.DiffPrevLine()
+	}
+	return nil
+}
+
+// handleDiffReview opens the comment editor for the line under the cursor, or sends the pending
+// review comments to the agent.
+func (m *home) handleDiffReview(name keys.KeyName) tea.Cmd {
+	if !m.tabbedWindow.IsInDiffTab() {
+		return nil
+	}
+	selected := m.list.GetSelectedInstance()
+	if selected == nil || !selected.Started() || selected.Paused() {
+		return nil
+	}
+
+	if name == keys.KeyDiffSubmitReview {
+		count := len(selected.ReviewComments())
+		if count == 0 {
+			return m.handleError(fmt.Errorf("no review comments to send, add one with C"))
+		}
+		message := fmt.Sprintf("[!] Send %d review comments to '%s'?", count, selected.Title)
+		return m.confirmAction(message, func() tea.Msg {
+			if err := selected.SubmitReview(); err != nil {
+				return err
+			}
+			return instanceChangedMsg{}
+		})
+	}
+
+	file, hunk, line := m.tabbedWindow.SelectedDiffLine()
+	if file == nil || line < 0 {
+		return m.handleError(fmt.Errorf("select a line with J or K first"))
+	}
+	comment, err := session.NewReviewComment(file, hunk, line)
+	if err != nil {
+		return m.handleError(err)
+	}
+	if existing, ok := selected.ReviewComment(comment); ok {
+		comment.Body = existing.Body
+	}
+
+	m.pendingComment = &comment
+	m.state = stateComment
+	m.menu.SetState(ui.StatePrompt)
+	m.textInputOverlay = overlay.NewTextInputOverlay("Comment on "+comment.Location(), comment.Body)
+	return tea.WindowSize()
+}
+
+// handleDiffHunkAction accepts, discards or reverts the hunk or file selected in the diff tab.
+// Destructive actions ask for confirmation and warn if the file changed since it was rendered.
+func (m *home) handleDiffHunkAction(name keys.KeyName) tea.Cmd {
+	if !m.tabbedWindow.IsInDiffTab() {
+		return nil
+	}
+	selected := m.list.GetSelectedInstance()
+	if selected == nil || !selected.Started() || selected.Paused() {
+		return nil
+	}
+	selectedFile, hunk := m.tabbedWindow.SelectedDiffHunk()
+	if selectedFile == nil {
+		return nil
+	}
+	// Copy the file so a diff refresh while the confirmation is open doesn't change what we act on.
+	file := *selectedFile
+	if name != keys.KeyDiffRevertFile && hunk < 0 {
+		return m.handleError(fmt.Errorf("select a hunk with { or } first"))
+	}
+
+	if selected.IsSnapshot() {
+		if name != keys.KeyDiffRevertFile {
+			return m.handleError(fmt.Errorf("hunks can only be accepted or discarded in git repositories"))
+		}
+		message := fmt.Sprintf("[!] Revert '%s' to the snapshot?", file.Path())
+		return m.confirmAction(message, func() tea.Msg {
+			if err := selected.RevertSnapshotFile(file.Path()); err != nil {
+				return err
+			}
+			if err := selected.UpdateDiffStats(); err != nil {
+				return err
+			}
+			return instanceChangedMsg{}
+		})
+	}
+
+	worktree, err := selected.GetGitWorktree()
+	if err != nil {
+		return m.handleError(err)
+	}
+
+	if name == keys.KeyDiffAcceptHunk {
+		key := git.HunkKey(file.Path(), file.Hunks[hunk])
+		var uncommitted []string
+		if !selected.IsHunkAccepted(key) {
+			// Pushing commits from the uncommitted changes, which may differ from the diff shown.
+			if uncommitted, err = worktree.UncommittedHunkKeys(file.Path(), file.Hunks[hunk]); err != nil {
+				return m.handleError(err)
+			}
+			if len(uncommitted) == 0 {
+				return m.handleError(fmt.Errorf("hunk %s in '%s' is already committed", file.Hunks[hunk].Header, file.Path()))
+			}
+		}
+		selected.ToggleHunkAccepted(key, uncommitted)
+		return m.instanceChanged()
+	}
+
+	var message string
+	var apply func() error
+	if name == keys.KeyDiffDiscardHunk {
+		message = fmt.Sprintf("[!] Discard hunk %s in '%s'?", file.Hunks[hunk].Header, file.Path())
+		apply = func() error { return worktree.DiscardHunk(&file, hunk) }
+	} else {
+		baseline, err := worktree.DescribeDiffBaseline()
+		if err != nil {
+			return m.handleError(err)
+		}
+		message = fmt.Sprintf("[!] Revert '%s' to the diff baseline (%s)?", file.Path(), baseline)
+		apply = func() error { return worktree.RevertFile(&file) }
+	}
+
+	changed, err := worktree.HasFileChanged(&file)
+	if err != nil {
+		return m.handleError(err)
+	}
+	if changed {
+		message += "\n\nWarning: the file changed since the diff was rendered. Review it before confirming."
+	}
+
+	action := func() tea.Msg {
+		if err := apply(); err != nil {
+			return err
+		}
+		if err := selected.UpdateDiffStats(); err != nil {
+			return err
+		}
+		return instanceChangedMsg{}
+	}
+	return m.confirmAction(message, action)
+}
+
+// renameInstance changes the title of an instance and offers to rename its branch to match.
+func (m *home) renameInstance(instance *session.Instance, title string) tea.Cmd {
+	if len(title) > 32 {
+		return m.handleError(fmt.Errorf("title cannot be longer than 32 characters"))
+	}
+	if err := instance.SetTitle(title); err != nil {
+		return m.handleError(err)
+	}
+	if err := m.storage.SaveInstances(m.list.GetInstances()); err != nil {
+		return m.handleError(err)
+	}
+
+	branch, err := instance.BranchNameFor(title)
+	if err != nil {
+		return m.handleError(fmt.Errorf("no new name for the branch: %w", err))
+	}
+	if branch == "" || branch == instance.Branch {
+		return nil
+	}
+	message := fmt.Sprintf("[!] Also rename branch '%s' to '%s'?", instance.Branch, branch)
+	return m.confirmAction(message, func() tea.Msg {
+		if err := instance.RenameBranch(branch); err != nil {
+			return err
+		}
+		if err := m.storage.SaveInstances(m.list.GetInstances()); err != nil {
+			return err
+		}
+		return instanceChangedMsg{}
+	})
+}
+
+// checkedOutInRepo returns another instance of the same repository whose branch is checked out in
+// the main repository, or nil if there is none.
+func (m *home) checkedOutInRepo(instance *session.Instance) *session.Instance {
+	worktree, err := instance.GetGitWorktree()
+	if err != nil {
+		return nil
+	}
+	for _, other := range m.list.GetInstances() {
+		if other == instance || !other.CheckedOut() {
+			continue
+		}
+		if otherWorktree, err := other.GetGitWorktree(); err == nil && otherWorktree.GetRepoPath() == worktree.GetRepoPath() {
+			return other
+		}
+	}
+	return nil
+}
+
 // instanceChanged updates the preview pane, menu, and diff pane based on the selected instance. It returns an error
 // Cmd if there was any error.
 func (m *home) instanceChanged() tea.Cmd {
@@ -644,7 +1139,7 @@ func (m *home) instanceChanged() tea.Cmd {
 	if err := m.tabbedWindow.UpdatePreview(selected); err != nil {
 		return m.handleError(err)
 	}
-	return nil
+	return m.loadCommits(false)
 }
 
 type keyupMsg struct{}
@@ -708,7 +1203,7 @@ func (m *home) confirmAction(message string, action tea.Cmd) tea.Cmd {
 		m.state = stateDefault
 		// Execute the action if it exists
 		if action != nil {
-			_ = action()
+			m.confirmedMsg = action()
 		}
 	}
 
@@ -731,7 +1226,7 @@ func (m *home) View() string {
 		m.errBox.String(),
 	)
 
-	if m.state == statePrompt {
+	if m.state == statePrompt || m.state == stateComment || m.state == stateWiden || m.state == stateRename {
 		if m.textInputOverlay == nil {
 			log.ErrorLog.Printf("text input overlay is nil")
 		}
@@ -746,6 +1241,8 @@ func (m *home) View() string {
 			log.ErrorLog.Printf("confirmation overlay is nil")
 		}
 		return overlay.PlaceOverlay(0, 0, m.confirmationOverlay.Render(), mainView, true, true)
+	} else if m.state == stateGate && m.gate != nil {
+		return overlay.PlaceOverlay(0, 0, m.gate.overlay.Render(), mainView, true, true)
 	}
 
 	return mainView
@@ -0,0 +1,112 @@
+package app
+
+import (
+	"claude-squad/keys"
+	"claude-squad/session/git"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// commitsLoadedMsg carries the commits of an instance's branch, loaded in the background.
+type commitsLoadedMsg struct {
+	instanceID string
+	commits    []git.Commit
+	err        error
+}
+
+// loadCommits returns a command that loads the commits of the selected instance for the commits tab,
+// or nil if the tab isn't shown or a load is already running. Unless force is set, the commits are
+// only loaded when another instance was selected.
+func (m *home) loadCommits(force bool) tea.Cmd {
+	if !m.tabbedWindow.IsInCommitsTab() || m.commitsLoading {
+		return nil
+	}
+	selected := m.list.GetSelectedInstance()
+	if selected == nil || !selected.Started() {
+		m.tabbedWindow.ClearCommits("No commits")
+		return nil
+	}
+	if !force && selected.ID == m.tabbedWindow.CommitsInstanceID() {
+		return nil
+	}
+	worktree, err := selected.GetGitWorktree()
+	if err != nil {
+		m.tabbedWindow.ClearCommits("Snapshots have no commits")
+		return nil
+	}
+
+	m.commitsLoading = true
+	id := selected.ID
+	return func() tea.Msg {
+		commits, err := worktree.Commits()
+		return commitsLoadedMsg{instanceID: id, commits: commits, err: err}
+	}
+}
+
+// handleCommitsLoaded shows loaded commits if their instance is still selected.
+func (m *home) handleCommitsLoaded(msg commitsLoadedMsg) tea.Cmd {
+	m.commitsLoading = false
+	selected := m.list.GetSelectedInstance()
+	if selected == nil || selected.ID != msg.instanceID {
+		// The selection changed while loading.
+		return m.loadCommits(false)
+	}
+	m.tabbedWindow.SetCommits(msg.instanceID, msg.commits, msg.err)
+	return nil
+}
+
+// handleCommitAction handles the keys of the commits tab.
+func (m *home) handleCommitAction(name keys.KeyName) tea.Cmd {
+	if !m.tabbedWindow.IsInCommitsTab() {
+		return nil
+	}
+	selected := m.list.GetSelectedInstance()
+	commit := m.tabbedWindow.SelectedCommit()
+	if selected == nil || commit == nil {
+		return nil
+	}
+	worktree, err := selected.GetGitWorktree()
+	if err != nil {
+		return m.handleError(err)
+	}
+	sha := commit.SHA
+
+	switch name {
+	case keys.KeyCommitView:
+		if m.tabbedWindow.IsShowingCommitDiff() {
+			m.tabbedWindow.HideCommitDiff()
+			return nil
+		}
+		content, err := worktree.CommitDiff(sha)
+		if err != nil {
+			return m.handleError(err)
+		}
+		m.tabbedWindow.ShowCommitDiff(*commit, content)
+	case keys.KeyCommitCherryPick:
+		cherryPickAction := func() tea.Msg {
+			if err := worktree.CherryPick(sha); err != nil {
+				return err
+			}
+			return instanceChangedMsg{}
+		}
+		message := fmt.Sprintf("[!] Cherry-pick %s onto the branch of %s?", commit.ShortSHA(), worktree.GetRepoName())
+		return m.confirmAction(message, cherryPickAction)
+	case keys.KeyCommitRevert:
+		if selected.Paused() {
+			return m.handleError(fmt.Errorf("resume session '%s' to revert its commits", selected.Title))
+		}
+		revertAction := func() tea.Msg {
+			if err := worktree.Revert(sha); err != nil {
+				return err
+			}
+			return commitsRefreshMsg{}
+		}
+		message := fmt.Sprintf("[!] Revert %s \"%s\" in session '%s'?", commit.ShortSHA(), commit.Subject, selected.Title)
+		return m.confirmAction(message, revertAction)
+	}
+	return nil
+}
+
+// commitsRefreshMsg reloads the commits tab, e.g. after a commit was added.
+type commitsRefreshMsg struct{}
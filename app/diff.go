@@ -0,0 +1,52 @@
+package app
+
+import (
+	"claude-squad/session"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// diffWorkers caps the number of diffs computed at the same time.
+const diffWorkers = 4
+
+// diffUpdatedMsg carries the result of a background diff computation.
+type diffUpdatedMsg struct {
+	instance *session.Instance
+	update   session.DiffUpdate
+}
+
+// diffScheduler computes instance diffs on a bounded pool of goroutines so git never runs on the
+// Bubble Tea goroutine. Results come back as diffUpdatedMsg.
+type diffScheduler struct {
+	slots chan struct{}
+	// inFlight holds the instances with a diff being computed. It is only accessed from Update.
+	inFlight map[*session.Instance]bool
+}
+
+func newDiffScheduler() *diffScheduler {
+	return &diffScheduler{
+		slots:    make(chan struct{}, diffWorkers),
+		inFlight: make(map[*session.Instance]bool),
+	}
+}
+
+// schedule returns a command that recomputes the instance's diff if its worktree changed, or nil if
+// a computation for the instance is already running.
+func (s *diffScheduler) schedule(instance *session.Instance) tea.Cmd {
+	if s.inFlight[instance] {
+		return nil
+	}
+	s.inFlight[instance] = true
+	// Capture what the diff depends on here, instance fields must not be read off the UI goroutine.
+	compute := instance.PrepareDiff(instance.DiffFingerprint())
+	return func() tea.Msg {
+		s.slots <- struct{}{}
+		defer func() { <-s.slots }()
+		return diffUpdatedMsg{instance: instance, update: compute()}
+	}
+}
+
+// done marks the instance's diff computation as finished.
+func (s *diffScheduler) done(instance *session.Instance) {
+	delete(s.inFlight, instance)
+}
@@ -0,0 +1,163 @@
+package app
+
+import (
+	"claude-squad/session"
+	"claude-squad/ui/overlay"
+	"context"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxGateBatch caps how many lines of output are delivered to Update at once.
+const maxGateBatch = 100
+
+// startGateMsg asks Update to run the pre-push checks and then action, which does the actual push.
+type startGateMsg struct {
+	instance *session.Instance
+	gate     *session.PrePushGate
+	action   tea.Cmd
+}
+
+// gateOutputMsg carries new output of the running checks.
+type gateOutputMsg struct {
+	lines []string
+}
+
+// gateDoneMsg is sent once the checks finished. err is nil if all of them passed.
+type gateDoneMsg struct {
+	err error
+}
+
+// gateRun is a run of the pre-push checks shown in the output overlay.
+type gateRun struct {
+	instance *session.Instance
+	gate     *session.PrePushGate
+	action   tea.Cmd
+	cancel   context.CancelFunc
+	lines    chan string
+	done     chan error
+	overlay  *overlay.OutputOverlay
+	// err is set once the checks failed.
+	err      error
+	finished bool
+}
+
+// gated returns action, or a command that runs the instance's pre-push checks before action if
+// there are any.
+func gated(instance *session.Instance, action tea.Cmd) tea.Cmd {
+	gate := instance.PrePushGate()
+	if gate == nil {
+		return action
+	}
+	return func() tea.Msg {
+		return startGateMsg{instance: instance, gate: gate, action: action}
+	}
+}
+
+// startGate runs the checks in the background and returns the command that waits for their output.
+func startGate(ctx context.Context, msg startGateMsg) *gateRun {
+	ctx, cancel := context.WithCancel(ctx)
+	run := &gateRun{
+		instance: msg.instance,
+		gate:     msg.gate,
+		action:   msg.action,
+		cancel:   cancel,
+		lines:    make(chan string, maxGateBatch),
+		done:     make(chan error, 1),
+		overlay:  overlay.NewOutputOverlay(fmt.Sprintf("Pre-push checks for '%s'", msg.instance.Title)),
+	}
+	run.overlay.SetFooter("running… esc to cancel", false)
+	go func() {
+		err := run.gate.Run(ctx, func(line string) { run.lines <- line })
+		close(run.lines)
+		run.done <- err
+	}()
+	return run
+}
+
+// wait returns a command that delivers the next batch of output, or the result once the checks are
+// done.
+func (r *gateRun) wait() tea.Cmd {
+	return func() tea.Msg {
+		line, ok := <-r.lines
+		if !ok {
+			return gateDoneMsg{err: <-r.done}
+		}
+		lines := []string{line}
+		for len(lines) < maxGateBatch {
+			select {
+			case line, ok := <-r.lines:
+				if !ok {
+					return gateOutputMsg{lines: lines}
+				}
+				lines = append(lines, line)
+			default:
+				return gateOutputMsg{lines: lines}
+			}
+		}
+		return gateOutputMsg{lines: lines}
+	}
+}
+
+// handleGateMsg handles the messages of a running gate.
+func (m *home) handleGateMsg(msg tea.Msg) tea.Cmd {
+	if m.gate == nil {
+		return nil
+	}
+	switch msg := msg.(type) {
+	case gateOutputMsg:
+		m.gate.overlay.AppendLines(msg.lines...)
+		return m.gate.wait()
+	case gateDoneMsg:
+		m.gate.finished = true
+		if msg.err == nil {
+			// All checks passed, go ahead with the push.
+			action := m.gate.action
+			m.closeGate()
+			return action
+		}
+		m.gate.err = msg.err
+		m.gate.overlay.AppendLines("", msg.err.Error())
+		m.gate.overlay.SetFooter("f push anyway • s send output to agent • esc close", true)
+	}
+	return nil
+}
+
+// handleGateKeyPress handles key presses while the output of the checks is shown.
+func (m *home) handleGateKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	run := m.gate
+	if !run.finished {
+		if msg.Type == tea.KeyEsc || msg.String() == "ctrl+c" {
+			// The run finishes with an error, which keeps the overlay open to show what happened.
+			run.cancel()
+		}
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "f":
+		action := run.action
+		m.closeGate()
+		return m, action
+	case "s":
+		prompt := run.gate.FailurePrompt()
+		instance := run.instance
+		m.closeGate()
+		if err := instance.SendPrompt(prompt); err != nil {
+			return m, m.handleError(err)
+		}
+		return m, m.instanceChanged()
+	case "esc", "q":
+		m.closeGate()
+	}
+	return m, nil
+}
+
+func (m *home) closeGate() {
+	if m.gate != nil {
+		m.gate.cancel()
+	}
+	m.gate = nil
+	m.state = stateDefault
+}
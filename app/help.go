@@ -42,19 +42,50 @@ func (h helpTypeGeneral) toContent() string {
 		headerStyle.Render("Managing:"),
 		keyStyle.Render("n")+descStyle.Render("         - Create a new session"),
 		keyStyle.Render("N")+descStyle.Render("         - Create a new session with a prompt"),
+		keyStyle.Render("tab")+descStyle.Render("       - While naming a session: copy or move your uncommitted changes into it"),
 		keyStyle.Render("D")+descStyle.Render("         - Kill (delete) the selected session"),
+		keyStyle.Render("e")+descStyle.Render("         - Rename the selected session (optionally its branch too)"),
+		keyStyle.Render("O")+descStyle.Render("         - Show files changed by more than one session (⚠ in the list)"),
 		keyStyle.Render("↑/j, ↓/k")+descStyle.Render("  - Navigate between sessions"),
 		keyStyle.Render("↵/o")+descStyle.Render("       - Attach to the selected session"),
 		keyStyle.Render("ctrl-q")+descStyle.Render("    - Detach from session"),
 		"",
 		headerStyle.Render("Handoff:"),
-		keyStyle.Render("p")+descStyle.Render("         - Commit and push branch to github"),
-		keyStyle.Render("c")+descStyle.Render("         - Checkout: commit changes and pause session"),
+		keyStyle.Render("p")+descStyle.Render("         - Commit and push branch to github (apply changes outside of git)"),
+		descStyle.Render("              Configured pre-push checks run first; on failure press f to push anyway"),
+		descStyle.Render("              or s to send the output to the agent"),
+		keyStyle.Render("c")+descStyle.Render("         - Checkout: pause session and check out its branch in your repository"),
+		descStyle.Render("              Press again to switch your repository back and restore your stash"),
 		keyStyle.Render("r")+descStyle.Render("         - Resume a paused session"),
+		keyStyle.Render("F")+descStyle.Render("         - Freeze the agent's processes in place, or thaw them"),
+		keyStyle.Render("W")+descStyle.Render("         - Add directories to a sparse checkout"),
 		"",
 		headerStyle.Render("Other:"),
-		keyStyle.Render("tab")+descStyle.Render("       - Switch between preview and diff tabs"),
+		keyStyle.Render("tab")+descStyle.Render("       - Switch between the preview, diff and commits tabs"),
+		keyStyle.Render("t")+descStyle.Render("         - Switch the preview and attach between the agent and its extra windows"),
+		keyStyle.Render("E")+descStyle.Render("         - Open the worktree in $EDITOR (configurable with open_actions)"),
 		keyStyle.Render("shift-↓/↑")+descStyle.Render(" - Scroll in diff view"),
+		"",
+		headerStyle.Render("Diff view:"),
+		keyStyle.Render("[/]")+descStyle.Render("       - Jump to the previous/next file"),
+		keyStyle.Render("{/}")+descStyle.Render("       - Jump to the previous/next hunk"),
+		keyStyle.Render("z")+descStyle.Render("         - Collapse or expand the selected file"),
+		keyStyle.Render("f")+descStyle.Render("         - Hide or show lockfiles and generated files"),
+		keyStyle.Render("s")+descStyle.Render("         - Toggle side-by-side view (wide windows)"),
+		keyStyle.Render("w")+descStyle.Render("         - Toggle word-level highlighting"),
+		keyStyle.Render("a")+descStyle.Render("         - Accept the selected hunk (push commits only accepted hunks)"),
+		keyStyle.Render("x")+descStyle.Render("         - Discard the selected hunk from the worktree"),
+		keyStyle.Render("X")+descStyle.Render("         - Revert the selected file to the diff baseline (see b)"),
+		keyStyle.Render("J/K")+descStyle.Render("       - Move the line cursor down/up"),
+		keyStyle.Render("C")+descStyle.Render("         - Comment on the line under the cursor"),
+		keyStyle.Render("R")+descStyle.Render("         - Send the review comments to the agent"),
+		keyStyle.Render("b")+descStyle.Render("         - Switch the diff baseline (base commit, base branch, last push, last commit, uncommitted)"),
+		"",
+		headerStyle.Render("Commits view:"),
+		keyStyle.Render("J/K")+descStyle.Render("       - Select the next/previous commit"),
+		keyStyle.Render("v")+descStyle.Render("         - Show or hide the diff of the selected commit"),
+		keyStyle.Render("P")+descStyle.Render("         - Cherry-pick the selected commit onto the main checkout's branch"),
+		keyStyle.Render("V")+descStyle.Render("         - Revert the selected commit in the session"),
 		keyStyle.Render("q")+descStyle.Render("         - Quit the application"),
 	)
 	return content
@@ -95,13 +126,13 @@ func (h helpTypeInstanceCheckout) toContent() string {
 	content := lipgloss.JoinVertical(lipgloss.Left,
 		titleStyle.Render("Checkout Instance"),
 		"",
-		"Changes will be committed locally. The branch name has been copied to your clipboard for you to checkout.",
+		"The session is paused and its branch is checked out in your repository, along with its uncommitted changes. Your own uncommitted changes are stashed.",
 		"",
-		"Feel free to make changes to the branch and commit them. When resuming, the session will continue from where you left off.",
+		"Feel free to make changes to the branch and commit them. Switching back restores your previous branch and stash, and uncommitted changes on the branch are handed back to the session.",
 		"",
 		headerStyle.Render("Commands:"),
-		keyStyle.Render("c")+descStyle.Render(" - Checkout: commit changes locally and pause session"),
-		keyStyle.Render("r")+descStyle.Render(" - Resume a paused session"),
+		keyStyle.Render("c")+descStyle.Render(" - Switch your repository back to where it was"),
+		keyStyle.Render("r")+descStyle.Render(" - Switch back and resume the session"),
 	)
 	return content
 }
@@ -0,0 +1,49 @@
+package app
+
+import (
+	"claude-squad/config"
+	"claude-squad/session"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// handleOpenKey runs the open action bound to key on the selected instance. It returns nil if no
+// action is bound to the key.
+func (m *home) handleOpenKey(key string) tea.Cmd {
+	for _, action := range m.appConfig.GetOpenActions() {
+		if action.Key != "" && action.Key == key {
+			return m.openSelected(action)
+		}
+	}
+	return nil
+}
+
+// openSelected opens the worktree of the selected instance with action. Editors take over the
+// terminal until they exit, everything else runs next to the TUI.
+func (m *home) openSelected(action config.OpenAction) tea.Cmd {
+	selected := m.list.GetSelectedInstance()
+	if selected == nil {
+		return nil
+	}
+	target, err := selected.OpenTarget()
+	if err != nil {
+		return m.handleError(err)
+	}
+	cmd, foreground, err := session.OpenCmd(action, target)
+	if err != nil {
+		return m.handleError(err)
+	}
+	if foreground {
+		return tea.ExecProcess(cmd, func(err error) tea.Msg {
+			if err != nil {
+				return fmt.Errorf("open action %q failed: %w", action.Name, err)
+			}
+			return instanceChangedMsg{}
+		})
+	}
+	if err := session.StartOpenCmd(cmd); err != nil {
+		return m.handleError(err)
+	}
+	return nil
+}
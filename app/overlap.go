@@ -0,0 +1,64 @@
+package app
+
+import (
+	"claude-squad/session"
+	"claude-squad/ui/overlay"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	overlapFileStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#e0a526"))
+	overlapLinesStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#de613e"))
+)
+
+// showOverlaps shows which files are changed by more than one instance, so agents can be redirected
+// before their changes collide.
+func (m *home) showOverlaps() (tea.Model, tea.Cmd) {
+	m.textOverlay = overlay.NewTextOverlay(overlapsContent(session.FindOverlaps(m.list.GetInstances()),
+		m.list.GetSelectedInstance()))
+	m.state = stateHelp
+	return m, nil
+}
+
+// overlapsContent lists the overlapping files of each repository along with the instances changing
+// them. Files the selected instance changes are marked.
+func overlapsContent(overlaps []session.FileOverlap, selected *session.Instance) string {
+	lines := []string{titleStyle.Render("Overlapping changes"), ""}
+	if len(overlaps) == 0 {
+		lines = append(lines, descStyle.Render("No file is changed by more than one session."))
+		return lipgloss.JoinVertical(lipgloss.Left, lines...)
+	}
+
+	repo := ""
+	for _, overlap := range overlaps {
+		if overlap.RepoPath != repo {
+			if repo != "" {
+				lines = append(lines, "")
+			}
+			repo = overlap.RepoPath
+			lines = append(lines, headerStyle.Render(filepath.Base(repo)+":"))
+		}
+
+		var titles []string
+		marker := "  "
+		for _, instance := range overlap.Instances {
+			titles = append(titles, instance.Title)
+			if instance == selected {
+				marker = "▸ "
+			}
+		}
+		style := overlapFileStyle
+		detail := ""
+		if overlap.SameLines {
+			style = overlapLinesStyle
+			detail = " (same lines)"
+		}
+		lines = append(lines, marker+style.Render(overlap.Path)+descStyle.Render(" - "+strings.Join(titles, ", ")+detail))
+	}
+	lines = append(lines, "", descStyle.Render("▸ marks files changed by the selected session."))
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
@@ -36,6 +36,29 @@ type Config struct {
 	DaemonPollInterval int `json:"daemon_poll_interval"`
 	// BranchPrefix is the prefix used for git branches created by the application.
 	BranchPrefix string `json:"branch_prefix"`
+	// WorktreePath is the global worktree path template, see RepoConfig.WorktreePath.
+	WorktreePath string `json:"worktree_path,omitempty"`
+	// Isolation is the global isolation of new instances, see RepoConfig.Isolation.
+	Isolation string `json:"isolation,omitempty"`
+	// BranchName is the global branch name template, see RepoConfig.BranchName.
+	BranchName string `json:"branch_name,omitempty"`
+	// Commit holds the global commit attribution settings, see RepoConfig.Commit.
+	Commit CommitConfig `json:"commit,omitempty"`
+	// PrePush lists the global pre-push checks, see RepoConfig.PrePush.
+	PrePush []string `json:"pre_push,omitempty"`
+	// PauseMode is the global handling of uncommitted changes on pause, see RepoConfig.PauseMode.
+	PauseMode string `json:"pause_mode,omitempty"`
+	// Windows lists the global extra tmux windows, see RepoConfig.Windows.
+	Windows []WindowConfig `json:"windows,omitempty"`
+	// Snapshot limits the snapshots instances work on outside of git repositories, see
+	// SnapshotConfig.
+	Snapshot SnapshotConfig `json:"snapshot,omitempty"`
+	// OpenActions lists the ways to open an instance's worktree outside of claude-squad, see
+	// OpenAction. Empty uses an editor action bound to E and a tmux split with a shell.
+	OpenActions []OpenAction `json:"open_actions,omitempty"`
+	// Repos holds per-repository overrides of the settings in RepoConfig, keyed by the absolute path
+	// of the repository root.
+	Repos map[string]RepoConfig `json:"repos,omitempty"`
 }
 
 // DefaultConfig returns the default configuration
@@ -137,6 +160,9 @@ func LoadConfig() *Config {
 		log.ErrorLog.Printf("failed to parse config file: %v", err)
 		return DefaultConfig()
 	}
+	if err := config.ValidateOpenActions(); err != nil {
+		log.WarningLog.Printf("invalid config: %v", err)
+	}
 
 	return &config
 }
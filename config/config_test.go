@@ -237,3 +237,43 @@ func TestSaveConfig(t *testing.T) {
 		assert.Equal(t, testConfig.BranchPrefix, loadedConfig.BranchPrefix)
 	})
 }
+
+func TestExpandTemplate(t *testing.T) {
+	vars := map[string]string{"repo": "app", "branch": "me/feature"}
+
+	result, err := ExpandTemplate("../{{repo}}-wt/{{ branch }}", vars)
+	require.NoError(t, err)
+	assert.Equal(t, "../app-wt/me/feature", result)
+
+	_, err = ExpandTemplate("{{repo}}/{{brnach}}", vars)
+	assert.ErrorContains(t, err, "brnach")
+}
+
+func TestForRepo(t *testing.T) {
+	cfg := &Config{
+		WorktreePath: "../{{repo}}-wt/{{branch}}",
+		Repos: map[string]RepoConfig{
+			"/src/mono": {WorktreePath: "/tmp/wt/{{name}}", SparseCheckout: []string{"services/api"}},
+		},
+	}
+
+	assert.Equal(t, "../{{repo}}-wt/{{branch}}", cfg.ForRepo("/src/app").WorktreePath)
+	assert.Equal(t, "/tmp/wt/{{name}}", cfg.ForRepo("/src/mono/").WorktreePath)
+	assert.Equal(t, []string{"services/api"}, cfg.ForRepo("/src/mono").SparseCheckout)
+	assert.Empty(t, cfg.ForRepo("/src/app").SparseCheckout)
+}
+
+func TestValidateOpenActions(t *testing.T) {
+	assert.NoError(t, DefaultConfig().ValidateOpenActions())
+
+	cfg := &Config{OpenActions: []OpenAction{
+		{Name: "code", Type: OpenCommand, Command: "code {{worktree}}", Key: "I"},
+		{Name: "revert", Type: OpenEditor, Key: "V"},
+		{Name: "again", Type: OpenTmuxSplit, Key: "I"},
+		{Name: "unbound", Type: OpenTmuxWindow},
+	}}
+	err := cfg.ValidateOpenActions()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `"V" of "revert" is already used by claude-squad`)
+	assert.Contains(t, err.Error(), `"I" of "again" is already bound to "code"`)
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"claude-squad/keys"
+	"fmt"
+	"strings"
+)
+
+// Types of open actions, see OpenAction.Type.
+const (
+	// OpenEditor opens $VISUAL or $EDITOR in the worktree, taking over the terminal until it exits.
+	OpenEditor = "editor"
+	// OpenCommand runs Command in the background, e.g. to open an IDE or a new terminal window.
+	OpenCommand = "command"
+	// OpenTmuxSplit splits the tmux window claude-squad runs in and starts Command, or a shell, in
+	// the worktree.
+	OpenTmuxSplit = "tmux-split"
+	// OpenTmuxWindow is OpenTmuxSplit with a new tmux window instead of a split.
+	OpenTmuxWindow = "tmux-window"
+)
+
+// OpenAction opens an instance's worktree outside of claude-squad, e.g.
+// {"name": "code", "type": "command", "command": "code {{worktree}}", "key": "I"}.
+type OpenAction struct {
+	// Name identifies the action, e.g. in `claude-squad open --with code`.
+	Name string `json:"name"`
+	// Type is one of "editor", "command", "tmux-split" and "tmux-window".
+	Type string `json:"type"`
+	// Command is a shell command template. The variables are {{worktree}}, the path of the worktree,
+	// {{branch}}, the branch name, {{title}}, the session title, and {{id}}, the session's unique ID.
+	// They are expanded shell quoted. It is required for "command" and optional for the tmux types.
+	Command string `json:"command,omitempty"`
+	// Key runs the action from the instance list, e.g. "E" or "ctrl+o". Keys that claude-squad
+	// already uses can't be bound, see ValidateOpenActions.
+	Key string `json:"key,omitempty"`
+}
+
+// defaultOpenActions are used when no open actions are configured.
+var defaultOpenActions = []OpenAction{
+	{Name: "editor", Type: OpenEditor, Key: "E"},
+	{Name: "shell", Type: OpenTmuxSplit},
+}
+
+// GetOpenActions returns the configured open actions, or the defaults if there are none.
+func (c *Config) GetOpenActions() []OpenAction {
+	if len(c.OpenActions) == 0 {
+		return defaultOpenActions
+	}
+	return c.OpenActions
+}
+
+// FindOpenAction returns the open action with the given name. An empty name selects the first one.
+func (c *Config) FindOpenAction(name string) (OpenAction, error) {
+	actions := c.GetOpenActions()
+	if name == "" {
+		return actions[0], nil
+	}
+	for _, action := range actions {
+		if action.Name == name {
+			return action, nil
+		}
+	}
+	return OpenAction{}, fmt.Errorf("no open action named %q", name)
+}
+
+// ValidateOpenActions reports open actions whose key can't run them: keys claude-squad already uses
+// and keys bound to an earlier action take precedence.
+func (c *Config) ValidateOpenActions() error {
+	var conflicts []string
+	bound := make(map[string]string)
+	for _, action := range c.GetOpenActions() {
+		if action.Key == "" {
+			continue
+		}
+		if _, taken := keys.GlobalKeyStringsMap[action.Key]; taken || action.Key == "ctrl+c" {
+			conflicts = append(conflicts, fmt.Sprintf("%q of %q is already used by claude-squad", action.Key, action.Name))
+		} else if other, ok := bound[action.Key]; ok {
+			conflicts = append(conflicts, fmt.Sprintf("%q of %q is already bound to %q", action.Key, action.Name, other))
+		} else {
+			bound[action.Key] = action.Name
+		}
+	}
+	if len(conflicts) > 0 {
+		return fmt.Errorf("open action keys can't be used: %s", strings.Join(conflicts, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package config
+
+import "path/filepath"
+
+// RepoConfig holds settings that can be set globally and overridden per repository.
+type RepoConfig struct {
+	// WorktreePath is a template for the path of new worktrees, e.g. "../{{repo}}-wt/{{branch}}".
+	// Relative paths are resolved against the repository root. The variables are {{repo}}, the name
+	// of the repository, {{branch}}, the branch name, {{name}}, the sanitized session title, and
+	// {{id}}, the session's unique ID.
+	// Empty keeps worktrees in the config directory.
+	WorktreePath string `json:"worktree_path,omitempty"`
+	// SparseCheckout lists the directories to check out in new worktrees (cone mode sparse-checkout),
+	// e.g. ["services/api", "libs"]. Files in the repository root are always checked out. Empty
+	// checks out the full tree. It only makes sense per repository, so it is not read globally.
+	SparseCheckout []string `json:"sparse_checkout,omitempty"`
+	// Isolation selects how new instances are separated from the main checkout: "worktree" (the
+	// default) uses a linked git worktree, "clone" a local clone sharing the repository's objects, and
+	// "copy" a reflink copy of the whole repository directory, including ignored files like caches.
+	Isolation string `json:"isolation,omitempty"`
+	// BranchName is a template for the names of new branches, e.g. "{{user}}/{{date}}-{{ticket}}-{{slug}}".
+	// The variables are {{prefix}}, the branch prefix, {{user}}, the login name, {{date}}, today as
+	// YYYY-MM-DD, {{repo}}, the name of the repository, {{title}}, the sanitized session title,
+	// {{ticket}}, a ticket ID like ABC-123 or #123 found in the title, and {{slug}}, the sanitized title
+	// without the ticket ID. Empty uses the branch prefix followed by the title.
+	BranchName string `json:"branch_name,omitempty"`
+	// Commit controls how commits made on behalf of agents are attributed.
+	Commit CommitConfig `json:"commit,omitempty"`
+	// PrePush lists shell commands, e.g. ["go test ./...", "golangci-lint run"], that must pass in the
+	// instance's worktree before its changes are pushed. They run in order and stop at the first
+	// failure.
+	PrePush []string `json:"pre_push,omitempty"`
+	// PauseMode selects what happens to uncommitted changes when an instance is paused: "wip" (the
+	// default) saves them to a hidden ref and restores them uncommitted on resume, "commit" commits
+	// them to the instance's branch.
+	PauseMode string `json:"pause_mode,omitempty"`
+	// Windows lists extra tmux windows started next to the agent in its worktree, e.g.
+	// [{"name": "shell"}, {"name": "dev", "command": "npm run dev"}].
+	Windows []WindowConfig `json:"windows,omitempty"`
+}
+
+// WindowConfig is an extra tmux window of an instance, see RepoConfig.Windows.
+type WindowConfig struct {
+	// Name is the name of the window.
+	Name string `json:"name"`
+	// Command runs in the window. Empty starts a shell.
+	Command string `json:"command,omitempty"`
+}
+
+// CommitConfig controls how commits made on behalf of agents (when pushing, pausing and the like) are
+// attributed. The zero value commits with the user's git identity, adds trailers and skips hooks.
+type CommitConfig struct {
+	// AgentName and AgentEmail are the git identity of the agent, e.g. "{{agent}}" and
+	// "{{agent}}@agents.example.com". {{agent}} is the name of the instance's program, e.g. claude.
+	// Empty leaves the user's identity on the commit.
+	AgentName  string `json:"agent_name,omitempty"`
+	AgentEmail string `json:"agent_email,omitempty"`
+	// AgentRole is "author" (the default) to make the agent the author and the user the committer,
+	// or "committer" for the other way around.
+	AgentRole string `json:"agent_role,omitempty"`
+	// NoTrailers disables the "Agent:" and "Instance:" trailers added to commit messages.
+	NoTrailers bool `json:"no_trailers,omitempty"`
+	// RunHooks runs the repository's commit hooks instead of bypassing them with --no-verify.
+	RunHooks bool `json:"run_hooks,omitempty"`
+}
+
+// ForRepo returns the settings for the repository at repoPath, with its overrides from Repos applied
+// on top of the global settings.
+func (c *Config) ForRepo(repoPath string) RepoConfig {
+	rc := RepoConfig{
+		WorktreePath: c.WorktreePath,
+		Isolation:    c.Isolation,
+		BranchName:   c.BranchName,
+		Commit:       c.Commit,
+		PrePush:      c.PrePush,
+		PauseMode:    c.PauseMode,
+		Windows:      c.Windows,
+	}
+
+	override, ok := c.Repos[filepath.Clean(repoPath)]
+	if !ok {
+		return rc
+	}
+	if override.WorktreePath != "" {
+		rc.WorktreePath = override.WorktreePath
+	}
+	if override.Isolation != "" {
+		rc.Isolation = override.Isolation
+	}
+	if override.BranchName != "" {
+		rc.BranchName = override.BranchName
+	}
+	rc.Commit = rc.Commit.merge(override.Commit)
+	if override.PrePush != nil {
+		rc.PrePush = override.PrePush
+	}
+	if override.PauseMode != "" {
+		rc.PauseMode = override.PauseMode
+	}
+	if override.Windows != nil {
+		rc.Windows = override.Windows
+	}
+	rc.SparseCheckout = override.SparseCheckout
+	return rc
+}
+
+// merge returns c with the settings made in override applied on top.
+func (c CommitConfig) merge(override CommitConfig) CommitConfig {
+	if override.AgentName != "" {
+		c.AgentName = override.AgentName
+	}
+	if override.AgentEmail != "" {
+		c.AgentEmail = override.AgentEmail
+	}
+	if override.AgentRole != "" {
+		c.AgentRole = override.AgentRole
+	}
+	c.NoTrailers = c.NoTrailers || override.NoTrailers
+	c.RunHooks = c.RunHooks || override.RunHooks
+	return c
+}
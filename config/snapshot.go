@@ -0,0 +1,50 @@
+package config
+
+// Defaults of SnapshotConfig.
+const (
+	defaultSnapshotMaxFiles  = 20000
+	defaultSnapshotMaxSizeMB = 500
+)
+
+// defaultSnapshotExclude are the directories left out of snapshots when none are configured. They
+// hold dependencies and caches that are large and can be recreated.
+var defaultSnapshotExclude = []string{
+	".git", "node_modules", ".venv", "venv", "__pycache__", ".tox", ".mypy_cache", ".pytest_cache",
+	".cache", ".gradle", ".next",
+}
+
+// SnapshotConfig limits the snapshots taken of directories that aren't git repositories. A snapshot
+// copies the directory twice, so starting claude-squad in a large directory like $HOME is refused.
+type SnapshotConfig struct {
+	// Exclude lists the names of directories that are left out of snapshots wherever they appear,
+	// e.g. "node_modules". Empty uses a list of common dependency and cache directories.
+	Exclude []string `json:"exclude,omitempty"`
+	// MaxFiles is the most files a snapshot copies. 0 uses the default of 20000.
+	MaxFiles int `json:"max_files,omitempty"`
+	// MaxSizeMB is the most data in MB a snapshot copies. 0 uses the default of 500.
+	MaxSizeMB int `json:"max_size_mb,omitempty"`
+}
+
+// GetExclude returns the directories to leave out, or the defaults if there are none.
+func (c SnapshotConfig) GetExclude() []string {
+	if len(c.Exclude) == 0 {
+		return defaultSnapshotExclude
+	}
+	return c.Exclude
+}
+
+// GetMaxFiles returns the most files a snapshot copies.
+func (c SnapshotConfig) GetMaxFiles() int {
+	if c.MaxFiles <= 0 {
+		return defaultSnapshotMaxFiles
+	}
+	return c.MaxFiles
+}
+
+// GetMaxSize returns the most bytes a snapshot copies.
+func (c SnapshotConfig) GetMaxSize() int64 {
+	if c.MaxSizeMB <= 0 {
+		return defaultSnapshotMaxSizeMB << 20
+	}
+	return int64(c.MaxSizeMB) << 20
+}
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var templateVarRegex = regexp.MustCompile(`{{\s*([a-zA-Z_]+)\s*}}`)
+
+// ExpandTemplate replaces every {{name}} in tmpl with vars[name]. It fails on unknown variables so
+// typos in the config don't silently produce odd paths or names.
+func ExpandTemplate(tmpl string, vars map[string]string) (string, error) {
+	var unknown []string
+	result := templateVarRegex.ReplaceAllStringFunc(tmpl, func(match string) string {
+		name := templateVarRegex.FindStringSubmatch(match)[1]
+		value, ok := vars[name]
+		if !ok {
+			unknown = append(unknown, name)
+		}
+		return value
+	})
+	if len(unknown) > 0 {
+		return "", fmt.Errorf("unknown template variables in %q: %s", tmpl, strings.Join(unknown, ", "))
+	}
+	return result, nil
+}
+
+// ExpandTemplatePrefix expands tmpl up to the first variable that is not in vars. It returns the
+// part of the result that is the same no matter what the other variables are.
+func ExpandTemplatePrefix(tmpl string, vars map[string]string) string {
+	var b strings.Builder
+	rest := tmpl
+	for {
+		loc := templateVarRegex.FindStringSubmatchIndex(rest)
+		if loc == nil {
+			b.WriteString(rest)
+			return b.String()
+		}
+		value, ok := vars[rest[loc[2]:loc[3]]]
+		b.WriteString(rest[:loc[0]])
+		if !ok {
+			return b.String()
+		}
+		b.WriteString(value)
+		rest = rest[loc[1]:]
+	}
+}
@@ -17,17 +17,45 @@ const (
 	KeyPush
 	KeySubmit
 
-	KeyTab        // Tab is a special keybinding for switching between panes.
-	KeySubmitName // SubmitName is a special keybinding for submitting the name of a new instance.
+	KeyTab          // Tab is a special keybinding for switching between panes.
+	KeySubmitName   // SubmitName is a special keybinding for submitting the name of a new instance.
+	KeyCarryChanges // CarryChanges is a special keybinding for bringing uncommitted changes into a new instance.
 
 	KeyCheckout
+	KeyReturnCheckout // ReturnCheckout is a special keybinding for undoing a checkout, on the checkout key.
 	KeyResume
-	KeyPrompt // New key for entering a prompt
-	KeyHelp   // Key for showing help screen
+	KeyPrompt   // New key for entering a prompt
+	KeyHelp     // Key for showing help screen
+	KeyWiden    // Key for widening the sparse checkout of an instance
+	KeyRename   // Key for renaming an instance
+	KeyOverlaps // Key for showing files changed by several instances
+	KeyFreeze   // Key for freezing or thawing an instance
+	KeyWindow   // Key for switching between the tmux windows of an instance
 
 	// Diff keybindings
 	KeyShiftUp
 	KeyShiftDown
+	KeyDiffNextFile
+	KeyDiffPrevFile
+	KeyDiffNextHunk
+	KeyDiffPrevHunk
+	KeyDiffToggleFile
+	KeyDiffFilter
+	KeyDiffSideBySide
+	KeyDiffWordDiff
+	KeyDiffAcceptHunk
+	KeyDiffDiscardHunk
+	KeyDiffRevertFile
+	KeyDiffNextLine
+	KeyDiffPrevLine
+	KeyDiffComment
+	KeyDiffSubmitReview
+	KeyDiffBaseline
+
+	// Commits tab keybindings
+	KeyCommitView
+	KeyCommitCherryPick
+	KeyCommitRevert
 )
 
 // GlobalKeyStringsMap is a global, immutable map string to keybinding.
@@ -49,6 +77,30 @@ var GlobalKeyStringsMap = map[string]KeyName{
 	"r":          KeyResume,
 	"p":          KeySubmit,
 	"?":          KeyHelp,
+	"W":          KeyWiden,
+	"e":          KeyRename,
+	"O":          KeyOverlaps,
+	"F":          KeyFreeze,
+	"t":          KeyWindow,
+	"]":          KeyDiffNextFile,
+	"[":          KeyDiffPrevFile,
+	"}":          KeyDiffNextHunk,
+	"{":          KeyDiffPrevHunk,
+	"z":          KeyDiffToggleFile,
+	"f":          KeyDiffFilter,
+	"s":          KeyDiffSideBySide,
+	"w":          KeyDiffWordDiff,
+	"a":          KeyDiffAcceptHunk,
+	"x":          KeyDiffDiscardHunk,
+	"X":          KeyDiffRevertFile,
+	"J":          KeyDiffNextLine,
+	"K":          KeyDiffPrevLine,
+	"C":          KeyDiffComment,
+	"R":          KeyDiffSubmitReview,
+	"b":          KeyDiffBaseline,
+	"v":          KeyCommitView,
+	"P":          KeyCommitCherryPick,
+	"V":          KeyCommitRevert,
 }
 
 // GlobalkeyBindings is a global, immutable map of KeyName tot keybinding.
@@ -101,6 +153,10 @@ var GlobalkeyBindings = map[KeyName]key.Binding{
 		key.WithKeys("c"),
 		key.WithHelp("c", "checkout"),
 	),
+	KeyReturnCheckout: key.NewBinding(
+		key.WithKeys("c"),
+		key.WithHelp("c", "return"),
+	),
 	KeyTab: key.NewBinding(
 		key.WithKeys("tab"),
 		key.WithHelp("tab", "switch tab"),
@@ -109,6 +165,90 @@ var GlobalkeyBindings = map[KeyName]key.Binding{
 		key.WithKeys("r"),
 		key.WithHelp("r", "resume"),
 	),
+	KeyWiden: key.NewBinding(
+		key.WithKeys("W"),
+		key.WithHelp("W", "widen checkout"),
+	),
+	KeyRename: key.NewBinding(
+		key.WithKeys("e"),
+		key.WithHelp("e", "rename"),
+	),
+	KeyOverlaps: key.NewBinding(
+		key.WithKeys("O"),
+		key.WithHelp("O", "overlaps"),
+	),
+	KeyFreeze: key.NewBinding(
+		key.WithKeys("F"),
+		key.WithHelp("F", "freeze/thaw"),
+	),
+	KeyWindow: key.NewBinding(
+		key.WithKeys("t"),
+		key.WithHelp("t", "next window"),
+	),
+	KeyDiffNextFile: key.NewBinding(
+		key.WithKeys("]"),
+		key.WithHelp("]", "next file"),
+	),
+	KeyDiffPrevFile: key.NewBinding(
+		key.WithKeys("["),
+		key.WithHelp("[", "prev file"),
+	),
+	KeyDiffNextHunk: key.NewBinding(
+		key.WithKeys("}"),
+		key.WithHelp("}", "next hunk"),
+	),
+	KeyDiffPrevHunk: key.NewBinding(
+		key.WithKeys("{"),
+		key.WithHelp("{", "prev hunk"),
+	),
+	KeyDiffToggleFile: key.NewBinding(
+		key.WithKeys("z"),
+		key.WithHelp("z", "collapse file"),
+	),
+	KeyDiffFilter: key.NewBinding(
+		key.WithKeys("f"),
+		key.WithHelp("f", "hide generated"),
+	),
+	KeyDiffSideBySide: key.NewBinding(
+		key.WithKeys("s"),
+		key.WithHelp("s", "side-by-side"),
+	),
+	KeyDiffWordDiff: key.NewBinding(
+		key.WithKeys("w"),
+		key.WithHelp("w", "word diff"),
+	),
+	KeyDiffAcceptHunk: key.NewBinding(
+		key.WithKeys("a"),
+		key.WithHelp("a", "accept hunk"),
+	),
+	KeyDiffDiscardHunk: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "discard hunk"),
+	),
+	KeyDiffRevertFile: key.NewBinding(
+		key.WithKeys("X"),
+		key.WithHelp("X", "revert file"),
+	),
+	KeyDiffNextLine: key.NewBinding(
+		key.WithKeys("J"),
+		key.WithHelp("J", "next line"),
+	),
+	KeyDiffPrevLine: key.NewBinding(
+		key.WithKeys("K"),
+		key.WithHelp("K", "previous line"),
+	),
+	KeyDiffComment: key.NewBinding(
+		key.WithKeys("C"),
+		key.WithHelp("C", "comment on line"),
+	),
+	KeyDiffSubmitReview: key.NewBinding(
+		key.WithKeys("R"),
+		key.WithHelp("R", "send review"),
+	),
+	KeyDiffBaseline: key.NewBinding(
+		key.WithKeys("b"),
+		key.WithHelp("b", "diff baseline"),
+	),
 
 	// -- Special keybindings --
 
@@ -116,4 +256,20 @@ var GlobalkeyBindings = map[KeyName]key.Binding{
 		key.WithKeys("enter"),
 		key.WithHelp("enter", "submit name"),
 	),
+	KeyCommitView: key.NewBinding(
+		key.WithKeys("v"),
+		key.WithHelp("v", "view commit"),
+	),
+	KeyCommitCherryPick: key.NewBinding(
+		key.WithKeys("P"),
+		key.WithHelp("P", "cherry-pick"),
+	),
+	KeyCommitRevert: key.NewBinding(
+		key.WithKeys("V"),
+		key.WithHelp("V", "revert"),
+	),
+	KeyCarryChanges: key.NewBinding(
+		key.WithKeys("tab"),
+		key.WithHelp("tab", "carry changes"),
+	),
 }
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bufio"
 	"claude-squad/app"
 	cmd2 "claude-squad/cmd"
 	"claude-squad/config"
@@ -8,11 +9,12 @@ import (
 	"claude-squad/log"
 	"claude-squad/session"
 	"claude-squad/session/git"
-	"claude-squad/session/tmux"
 	"context"
 	"encoding/json"
 	"fmt"
+	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
@@ -22,7 +24,14 @@ var (
 	programFlag string
 	autoYesFlag bool
 	daemonFlag  bool
-	rootCmd     = &cobra.Command{
+	dryRunFlag  bool
+	yesFlag     bool
+
+	resetRepoFlag     string
+	resetInstanceFlag string
+	keepBranchesFlag  bool
+	openWithFlag      string
+	rootCmd           = &cobra.Command{
 		Use:   "claude-squad",
 		Short: "Claude Squad - Manage multiple AI agents like Claude Code, Aider, Codex, and Amp.",
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -37,16 +46,6 @@ var (
 				return err
 			}
 
-			// Check if we're in a git repository
-			currentDir, err := filepath.Abs(".")
-			if err != nil {
-				return fmt.Errorf("failed to get current directory: %w", err)
-			}
-
-			if !git.IsGitRepo(currentDir) {
-				return fmt.Errorf("error: claude-squad must be run from within a git repository")
-			}
-
 			cfg := config.LoadConfig()
 
 			// Program flag overrides config
@@ -77,7 +76,9 @@ var (
 
 	resetCmd = &cobra.Command{
 		Use:   "reset",
-		Short: "Reset all stored instances",
+		Short: "Reset stored instances, optionally limited to a repository or instance",
+		Long: "Removes stored instances along with their tmux sessions, worktrees and branches. " +
+			"Without --repo or --instance, all instances and any leftover claude-squad tmux sessions and worktrees are removed.",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			log.Initialize(false)
 			defer log.Close()
@@ -87,22 +88,37 @@ var (
 			if err != nil {
 				return fmt.Errorf("failed to initialize storage: %w", err)
 			}
-			if err := storage.DeleteAllInstances(); err != nil {
-				return fmt.Errorf("failed to reset storage: %w", err)
+			plan, err := session.PlanReset(storage, cmd2.MakeExecutor(), session.ResetOptions{
+				RepoPath:     resetRepoFlag,
+				Title:        resetInstanceFlag,
+				KeepBranches: keepBranchesFlag,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to plan reset: %w", err)
 			}
-			fmt.Println("Storage has been reset successfully")
 
-			if err := tmux.CleanupSessions(cmd2.MakeExecutor()); err != nil {
-				return fmt.Errorf("failed to cleanup tmux sessions: %w", err)
+			if plan.Empty() {
+				fmt.Println("Nothing to reset")
+			} else {
+				fmt.Print("The following will be removed:\n" + plan.String())
+				printAtRisk(plan)
+				if dryRunFlag {
+					return nil
+				}
+				if !yesFlag && !confirm() {
+					fmt.Println("Reset cancelled")
+					return nil
+				}
+				if err := plan.Execute(); err != nil {
+					return err
+				}
+				fmt.Println("Reset completed successfully")
 			}
-			fmt.Println("Tmux sessions have been cleaned up")
-
-			if err := git.CleanupWorktrees(); err != nil {
-				return fmt.Errorf("failed to cleanup worktrees: %w", err)
+			if dryRunFlag {
+				return nil
 			}
-			fmt.Println("Worktrees have been cleaned up")
 
-			// Kill any daemon that's running.
+			// Kill any daemon that's running, it would otherwise keep acting on removed instances.
 			if err := daemon.StopDaemon(); err != nil {
 				return err
 			}
@@ -112,6 +128,135 @@ var (
 		},
 	}
 
+	gcCmd = &cobra.Command{
+		Use:   "gc",
+		Short: "Find and clean up orphaned worktrees, branches, tmux sessions and saved changes",
+		Long: "Reconciles the stored instances with the worktrees, branches and tmux sessions on disk. " +
+			"Each orphan can be deleted, adopted as a paused instance, or skipped.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Initialize(false)
+			defer log.Close()
+
+			storage, err := session.NewStorage(config.LoadState())
+			if err != nil {
+				return fmt.Errorf("failed to initialize storage: %w", err)
+			}
+			gc, err := session.NewGC(storage, config.LoadConfig(), cmd2.MakeExecutor())
+			if err != nil {
+				return fmt.Errorf("failed to load instances: %w", err)
+			}
+
+			// Also scan the repository gc is run from, which may not be known yet.
+			var extraRepos []string
+			if currentDir, err := filepath.Abs("."); err == nil {
+				if repo, err := git.RepoRoot(currentDir); err == nil {
+					extraRepos = append(extraRepos, repo)
+				}
+			}
+
+			orphans, err := gc.Find(extraRepos...)
+			if err != nil {
+				return fmt.Errorf("failed to find orphans: %w", err)
+			}
+			if len(orphans) == 0 {
+				fmt.Println("Nothing to clean up")
+				return nil
+			}
+
+			if dryRunFlag {
+				for _, orphan := range orphans {
+					fmt.Println(orphan)
+				}
+				return nil
+			}
+
+			reader := bufio.NewReader(os.Stdin)
+			for _, orphan := range orphans {
+				fmt.Println(orphan)
+				prompt := "(d)elete, (s)kip [s]: "
+				if orphan.CanAdopt() {
+					prompt = "(d)elete, (a)dopt, (s)kip [s]: "
+				}
+				fmt.Print(prompt)
+				answer, err := reader.ReadString('\n')
+				if err != nil && answer == "" {
+					return nil
+				}
+
+				switch strings.ToLower(strings.TrimSpace(answer)) {
+				case "d":
+					if err := gc.Delete(orphan); err != nil {
+						fmt.Printf("failed to delete: %v\n", err)
+						continue
+					}
+					fmt.Println("deleted")
+				case "a":
+					if !orphan.CanAdopt() {
+						fmt.Println("skipped")
+						continue
+					}
+					if err := gc.Adopt(orphan); err != nil {
+						fmt.Printf("failed to adopt: %v\n", err)
+						continue
+					}
+					fmt.Println("adopted as a paused instance")
+				default:
+					fmt.Println("skipped")
+				}
+			}
+			return nil
+		},
+	}
+
+	openCmd = &cobra.Command{
+		Use:   "open <instance>",
+		Short: "Open the worktree of an instance in an editor, IDE or terminal",
+		Long: "Runs one of the open actions from the config on the worktree of the instance with the given " +
+			"title or ID. Without --with, the first action is used.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Initialize(false)
+			defer log.Close()
+
+			action, err := config.LoadConfig().FindOpenAction(openWithFlag)
+			if err != nil {
+				return err
+			}
+			storage, err := session.NewStorage(config.LoadState())
+			if err != nil {
+				return fmt.Errorf("failed to initialize storage: %w", err)
+			}
+			instances, err := storage.LoadInstanceData()
+			if err != nil {
+				return fmt.Errorf("failed to load instances: %w", err)
+			}
+			var target *session.InstanceData
+			for idx := range instances {
+				if instances[idx].Title == args[0] || instances[idx].ID == args[0] {
+					target = &instances[idx]
+					break
+				}
+			}
+			if target == nil {
+				return fmt.Errorf("no instance with title or ID %q", args[0])
+			}
+
+			openTarget, err := target.OpenTarget()
+			if err != nil {
+				return err
+			}
+			command, foreground, err := session.OpenCmd(action, openTarget)
+			if err != nil {
+				return err
+			}
+			if !foreground {
+				return command.Start()
+			}
+			command.Stdin, command.Stdout, command.Stderr = os.Stdin, os.Stdout, os.Stderr
+			return command.Run()
+		},
+	}
+
 	debugCmd = &cobra.Command{
 		Use:   "debug",
 		Short: "Print debug information like config paths",
@@ -156,7 +301,57 @@ func init() {
 
 	rootCmd.AddCommand(debugCmd)
 	rootCmd.AddCommand(versionCmd)
+	resetCmd.Flags().StringVar(&resetRepoFlag, "repo", "", "Only reset instances of the repository at this path")
+	resetCmd.Flags().StringVar(&resetInstanceFlag, "instance", "", "Only reset the instance with this title or ID")
+	resetCmd.Flags().BoolVar(&keepBranchesFlag, "keep-branches", false, "Keep the branches of removed instances")
+	resetCmd.Flags().BoolVar(&dryRunFlag, "dry-run", false, "Only print what would be removed")
+	resetCmd.Flags().BoolVar(&yesFlag, "yes", false, "Don't ask for confirmation")
 	rootCmd.AddCommand(resetCmd)
+
+	gcCmd.Flags().BoolVar(&dryRunFlag, "dry-run", false, "Only list orphans without changing anything")
+	rootCmd.AddCommand(gcCmd)
+
+	openCmd.Flags().StringVar(&openWithFlag, "with", "", "Name of the open action to run")
+	rootCmd.AddCommand(openCmd)
+}
+
+// printAtRisk lists the work a reset would lose.
+func printAtRisk(plan *session.ResetPlan) {
+	atRisk := plan.AtRisk()
+	if len(atRisk) == 0 {
+		return
+	}
+	fmt.Println("\nThe following work will be lost:")
+	for _, target := range atRisk {
+		name := target.Title
+		if name == "" {
+			name = target.WorktreePath
+		}
+		fmt.Printf("%s:\n", name)
+		if _, err := os.Stat(target.WorktreePath); target.Dirty && err == nil {
+			fmt.Println("  uncommitted changes in the worktree")
+		}
+		if target.RepoChanges {
+			fmt.Printf("  uncommitted changes on %s in %s\n", target.Branch, target.RepoPath)
+		}
+		if len(target.WIPRefs) > 0 {
+			fmt.Printf("  uncommitted changes saved on pause in %s\n", strings.Join(target.WIPRefs, ", "))
+		}
+		if len(target.Unpushed) > 0 {
+			fmt.Printf("  %d unpushed commit(s) on %s:\n", len(target.Unpushed), target.Branch)
+			for _, commit := range target.Unpushed {
+				fmt.Printf("    %s\n", commit)
+			}
+		}
+	}
+}
+
+// confirm asks the user for confirmation on stdin.
+func confirm() bool {
+	fmt.Print("\nContinue? [y/N]: ")
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
 }
 
 func main() {
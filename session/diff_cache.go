@@ -0,0 +1,213 @@
+package session
+
+import (
+	"claude-squad/config"
+	"claude-squad/session/git"
+	"claude-squad/session/snapshot"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DiffUpdate is the result of computing an instance's diff, see PrepareDiff.
+type DiffUpdate struct {
+	// Stats holds the new diff statistics. The diff content is written to the diff cache instead of
+	// being kept in memory, see DiffContent.
+	Stats *git.DiffStats
+	// Fingerprint identifies the worktree state Stats were computed from.
+	Fingerprint string
+	// Unchanged is true if the worktree still matched the fingerprint passed to PrepareDiff, in which
+	// case Stats is nil.
+	Unchanged bool
+	// Err holds any error that occurred while computing the diff.
+	Err error
+}
+
+// DiffFingerprint returns the fingerprint of the worktree state the current diff stats were computed
+// from, or "" if they have not been computed yet.
+func (i *Instance) DiffFingerprint() string {
+	return i.diffFingerprint
+}
+
+// PrepareDiff returns a function that computes the diff of the instance's worktree, unless the worktree
+// still matches the given fingerprint. Call it on the UI goroutine: it copies what the diff depends on,
+// so the returned function doesn't touch the instance and is safe to run on another goroutine while the
+// instance changes, e.g. on pause. Apply the result with ApplyDiff.
+func (i *Instance) PrepareDiff(fingerprint string) func() DiffUpdate {
+	if i.snapshot != nil {
+		snap := *i.snapshot
+		return func() DiffUpdate { return computeSnapshotDiff(&snap, fingerprint) }
+	}
+	if i.gitWorktree == nil || i.gitWorktree.GetBaseCommitSHA() == "" {
+		// Worktree is not fully set up yet
+		return func() DiffUpdate { return DiffUpdate{} }
+	}
+	worktree := i.gitWorktree.Copy()
+	return func() DiffUpdate { return computeWorktreeDiff(worktree, fingerprint) }
+}
+
+// computeWorktreeDiff is the diff computation of PrepareDiff for instances working on a git worktree.
+func computeWorktreeDiff(worktree *git.GitWorktree, fingerprint string) DiffUpdate {
+	// Paused sessions have no checkout, their diff comes from the branch instead.
+	fingerprintFunc, diffFunc := worktree.Fingerprint, worktree.Diff
+	if !worktree.HasCheckout() {
+		fingerprintFunc, diffFunc = worktree.BranchFingerprint, worktree.BranchDiff
+	}
+
+	current, err := fingerprintFunc()
+	if err != nil {
+		return DiffUpdate{Err: err}
+	}
+	if current == fingerprint {
+		return DiffUpdate{Fingerprint: current, Unchanged: true}
+	}
+
+	stats := diffFunc()
+	if stats.Error != nil {
+		return DiffUpdate{Err: stats.Error}
+	}
+	if err := writeDiffCache(worktree.GetWorktreePath(), stats.Content); err != nil {
+		return DiffUpdate{Err: err}
+	}
+	stats.Ranges = git.ChangedRanges(git.ParseDiff(stats.Content))
+	stats.Content = ""
+	return DiffUpdate{Stats: stats, Fingerprint: current}
+}
+
+// computeSnapshotDiff is computeWorktreeDiff for instances working on a snapshot.
+func computeSnapshotDiff(snap *snapshot.Snapshot, fingerprint string) DiffUpdate {
+	current, err := snap.Fingerprint()
+	if err != nil {
+		return DiffUpdate{Err: err}
+	}
+	if current == fingerprint {
+		return DiffUpdate{Fingerprint: current, Unchanged: true}
+	}
+
+	stats := snap.Diff()
+	if stats.Error != nil {
+		return DiffUpdate{Err: stats.Error}
+	}
+	if err := writeDiffCache(snap.GetWorkPath(), stats.Content); err != nil {
+		return DiffUpdate{Err: err}
+	}
+	stats.Ranges = git.ChangedRanges(git.ParseDiff(stats.Content))
+	stats.Content = ""
+	return DiffUpdate{Stats: stats, Fingerprint: current}
+}
+
+// ApplyDiff stores the result of a diff computation returned by PrepareDiff. Results for instances
+// that were killed in the meantime are dropped, along with the diff cache they wrote.
+func (i *Instance) ApplyDiff(update DiffUpdate) error {
+	if !i.started {
+		i.diffStats = nil
+		return nil
+	}
+	i.killedMu.Lock()
+	killed := i.killed
+	i.killedMu.Unlock()
+	if killed {
+		i.diffStats = nil
+		return i.removeDiffCache()
+	}
+	if update.Unchanged {
+		return nil
+	}
+
+	var baselineErr *git.BaselineError
+	if errors.As(update.Err, &baselineErr) {
+		// Show why the selected baseline can't be diffed against instead of a stale diff.
+		i.diffStats = &git.DiffStats{Error: update.Err}
+		i.diffFingerprint = ""
+		return nil
+	}
+	if update.Err != nil {
+		if strings.Contains(update.Err.Error(), "base commit SHA not set") {
+			// Worktree is not fully set up yet, not an error
+			i.diffStats = nil
+			return nil
+		}
+		return fmt.Errorf("failed to get diff stats: %w", update.Err)
+	}
+
+	i.diffStats = update.Stats
+	i.diffFingerprint = update.Fingerprint
+	return nil
+}
+
+// DiffContent returns the full diff last computed for the instance, read from the diff cache.
+func (i *Instance) DiffContent() (string, error) {
+	if i.gitWorktree == nil && i.snapshot == nil {
+		return "", nil
+	}
+	path, err := diffCachePath(i.workspacePath())
+	if err != nil {
+		return "", err
+	}
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read diff cache: %w", err)
+	}
+	return string(content), nil
+}
+
+// removeDiffCache deletes the instance's cached diff.
+func (i *Instance) removeDiffCache() error {
+	if i.gitWorktree == nil && i.snapshot == nil {
+		return nil
+	}
+	path, err := diffCachePath(i.workspacePath())
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove diff cache: %w", err)
+	}
+	return nil
+}
+
+// diffCachePath returns where the diff of a workspace is cached. Worktree and snapshot paths are
+// unique per instance, so they make a stable key.
+func diffCachePath(workspacePath string) (string, error) {
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get config directory: %w", err)
+	}
+	sum := sha1.Sum([]byte(workspacePath))
+	return filepath.Join(configDir, "diffs", hex.EncodeToString(sum[:8])+".diff"), nil
+}
+
+// writeDiffCache atomically replaces the cached diff of a workspace, so readers never see a partial
+// diff.
+func writeDiffCache(workspacePath string, content string) error {
+	path, err := diffCachePath(workspacePath)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create diff cache directory: %w", err)
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), ".diff-*")
+	if err != nil {
+		return fmt.Errorf("failed to write diff cache: %w", err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write diff cache: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write diff cache: %w", err)
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return fmt.Errorf("failed to write diff cache: %w", err)
+	}
+	return nil
+}
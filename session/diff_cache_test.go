@@ -0,0 +1,48 @@
+package session
+
+import (
+	"claude-squad/session/git"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyDiffAfterKill(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	run := func(args ...string) string {
+		out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+		require.NoError(t, err, string(out))
+		return strings.TrimSpace(string(out))
+	}
+	run("init", "-q")
+	run("config", "user.email", "test@example.com")
+	run("config", "user.name", "Test")
+	run("commit", "-q", "--allow-empty", "-m", "initial")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "file.txt"), []byte("change\n"), 0644))
+
+	worktree := git.NewGitWorktreeFromStorage(dir, dir, "test", "main", run("rev-parse", "HEAD"), "")
+	instance := &Instance{Status: Running, started: true, gitWorktree: worktree}
+
+	require.NoError(t, instance.ApplyDiff(instance.PrepareDiff("")()))
+	content, err := instance.DiffContent()
+	require.NoError(t, err)
+	assert.Contains(t, content, "+change")
+
+	// A diff computed while the instance is killed writes the cache after Kill removed it.
+	compute := instance.PrepareDiff("")
+	instance.killed = true
+	require.NoError(t, instance.removeDiffCache())
+	update := compute()
+	require.NoError(t, instance.ApplyDiff(update))
+	assert.Nil(t, instance.GetDiffStats())
+	path, err := diffCachePath(dir)
+	require.NoError(t, err)
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
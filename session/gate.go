@@ -0,0 +1,129 @@
+package session
+
+import (
+	"bufio"
+	"claude-squad/config"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxGateOutputLines caps how much output of the checks is kept in memory.
+	maxGateOutputLines = 2000
+	// maxGatePromptLines caps how much output of a failed check is sent to the agent.
+	maxGatePromptLines = 80
+)
+
+// PrePushGate runs the checks configured for a repository (tests, linters and the like) in an
+// instance's workspace before its changes are pushed. See config.RepoConfig.PrePush.
+type PrePushGate struct {
+	// Commands are the shell commands to run, in order.
+	Commands []string
+	dir      string
+
+	mu sync.Mutex
+	// output holds the last maxGateOutputLines lines of output.
+	output []string
+	// failed is the command that failed, if any.
+	failed string
+}
+
+// PrePushGate returns the checks to run before pushing the instance's changes, or nil if none are
+// configured.
+func (i *Instance) PrePushGate() *PrePushGate {
+	if !i.started || (i.gitWorktree == nil && i.snapshot == nil) {
+		return nil
+	}
+	repoPath := i.Path
+	if i.gitWorktree != nil {
+		repoPath = i.gitWorktree.GetRepoPath()
+	}
+	commands := config.LoadConfig().ForRepo(repoPath).PrePush
+	if len(commands) == 0 {
+		return nil
+	}
+	return &PrePushGate{Commands: commands, dir: i.workspacePath()}
+}
+
+// Run runs the checks one after another and stops at the first one that fails. Every line of output
+// is passed to onLine as it is produced, preceded by a "$ <command>" line for each check.
+func (g *PrePushGate) Run(ctx context.Context, onLine func(string)) error {
+	emit := func(line string) {
+		g.mu.Lock()
+		g.output = append(g.output, line)
+		if len(g.output) > maxGateOutputLines {
+			g.output = g.output[len(g.output)-maxGateOutputLines:]
+		}
+		g.mu.Unlock()
+		onLine(line)
+	}
+
+	for _, command := range g.Commands {
+		emit("$ " + command)
+		if err := g.run(ctx, command, emit); err != nil {
+			g.mu.Lock()
+			g.failed = command
+			g.mu.Unlock()
+			if ctx.Err() != nil {
+				return fmt.Errorf("pre-push checks cancelled")
+			}
+			return fmt.Errorf("pre-push check %q failed: %w", command, err)
+		}
+	}
+	return nil
+}
+
+func (g *PrePushGate) run(ctx context.Context, command string, emit func(string)) error {
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Dir = g.dir
+	// Don't wait for stray background processes holding on to the output after a cancel.
+	cmd.WaitDelay = time.Second
+	pr, pw := io.Pipe()
+	cmd.Stdout = pw
+	cmd.Stderr = pw
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		err := cmd.Wait()
+		pw.Close()
+		done <- err
+	}()
+
+	scanner := bufio.NewScanner(pr)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		emit(scanner.Text())
+	}
+	// Drain whatever is left if a line was too long, so the command isn't blocked on the pipe.
+	_, _ = io.Copy(io.Discard, pr)
+	return <-done
+}
+
+// FailurePrompt returns a prompt asking the agent to fix the failed check, including the end of its
+// output.
+func (g *PrePushGate) FailurePrompt() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	// Only the output of the failed command is relevant.
+	output := g.output
+	for idx := len(output) - 1; idx >= 0; idx-- {
+		if output[idx] == "$ "+g.failed {
+			output = output[idx+1:]
+			break
+		}
+	}
+	if len(output) > maxGatePromptLines {
+		output = output[len(output)-maxGatePromptLines:]
+	}
+	return fmt.Sprintf("The pre-push check `%s` failed. Please fix the problems and make sure it passes. "+
+		"Its output was:\n\n%s", g.failed, strings.Join(output, "\n"))
+}
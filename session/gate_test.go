@@ -0,0 +1,31 @@
+package session
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrePushGate(t *testing.T) {
+	dir := t.TempDir()
+	run := func(commands ...string) (*PrePushGate, []string, error) {
+		gate := &PrePushGate{Commands: commands, dir: dir}
+		var lines []string
+		err := gate.Run(context.Background(), func(line string) { lines = append(lines, line) })
+		return gate, lines, err
+	}
+
+	_, lines, err := run("echo ok", "pwd")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"$ echo ok", "ok", "$ pwd", dir}, lines)
+
+	gate, lines, err := run("echo first", "echo bad >&2; exit 3", "echo never")
+	assert.ErrorContains(t, err, `"echo bad >&2; exit 3" failed`)
+	assert.Equal(t, []string{"$ echo first", "first", "$ echo bad >&2; exit 3", "bad"}, lines)
+	prompt := gate.FailurePrompt()
+	assert.Contains(t, prompt, "`echo bad >&2; exit 3` failed")
+	assert.Contains(t, prompt, "\n\nbad")
+	assert.NotContains(t, prompt, "first")
+}
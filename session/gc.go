@@ -0,0 +1,555 @@
+package session
+
+import (
+	"claude-squad/cmd"
+	"claude-squad/config"
+	"claude-squad/session/git"
+	"claude-squad/session/snapshot"
+	"claude-squad/session/tmux"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// OrphanKind is the kind of resource an Orphan refers to.
+type OrphanKind int
+
+const (
+	// OrphanWorktree is a git worktree of a claude-squad branch that no stored instance uses.
+	OrphanWorktree OrphanKind = iota
+	// OrphanWorktreeDir is a directory in the worktree directory that is neither used by an instance
+	// nor registered as a git worktree.
+	OrphanWorktreeDir
+	// OrphanBranch is a claude-squad branch that no stored instance uses.
+	OrphanBranch
+	// OrphanTmuxSession is a claude-squad tmux session that no stored instance uses.
+	OrphanTmuxSession
+	// OrphanInstance is a stored instance whose branch, worktree or tmux session is gone.
+	OrphanInstance
+	// OrphanWIP is a ref holding uncommitted changes saved on pause by an instance that is gone.
+	OrphanWIP
+)
+
+func (k OrphanKind) String() string {
+	switch k {
+	case OrphanWorktree:
+		return "worktree"
+	case OrphanWorktreeDir:
+		return "directory"
+	case OrphanBranch:
+		return "branch"
+	case OrphanTmuxSession:
+		return "tmux session"
+	case OrphanInstance:
+		return "instance"
+	case OrphanWIP:
+		return "saved changes"
+	}
+	return "unknown"
+}
+
+// Orphan is a resource that is out of sync between the stored instances and git or tmux.
+type Orphan struct {
+	Kind OrphanKind
+	// RepoPath is the repository the orphan belongs to, if any.
+	RepoPath string
+	// Path is the worktree or directory path, if any.
+	Path string
+	// Branch is the branch name, if any.
+	Branch string
+	// Session is the tmux session name, if any.
+	Session string
+	// Ref is the ref holding saved uncommitted changes, for OrphanWIP.
+	Ref string
+	// Title and ID identify the stored instance, for OrphanInstance.
+	Title string
+	ID    string
+	// Reason explains why the resource is considered orphaned.
+	Reason string
+	// Unpushed lists the commits lost by deleting the orphan, for OrphanInstance. See
+	// git.UnpushedCommits.
+	Unpushed []string
+}
+
+func (o Orphan) String() string {
+	var subject string
+	switch o.Kind {
+	case OrphanWorktree:
+		subject = fmt.Sprintf("%s (%s)", o.Path, o.Branch)
+	case OrphanWorktreeDir:
+		subject = o.Path
+	case OrphanBranch:
+		subject = fmt.Sprintf("%s in %s", o.Branch, o.RepoPath)
+	case OrphanTmuxSession:
+		subject = o.Session
+	case OrphanInstance:
+		subject = fmt.Sprintf("'%s'", o.Title)
+	case OrphanWIP:
+		subject = fmt.Sprintf("%s in %s", o.Ref, o.RepoPath)
+	}
+	if len(o.Unpushed) > 0 {
+		return fmt.Sprintf("%s %s: %s (%d unpushed commit(s) on %s)", o.Kind, subject, o.Reason, len(o.Unpushed), o.Branch)
+	}
+	return fmt.Sprintf("%s %s: %s", o.Kind, subject, o.Reason)
+}
+
+// CanAdopt returns true if the orphan can be turned back into a (paused) stored instance.
+func (o Orphan) CanAdopt() bool {
+	switch o.Kind {
+	case OrphanWorktree, OrphanBranch:
+		return o.Branch != ""
+	case OrphanInstance:
+		// Instances whose branch is gone can't be recovered. The branch of a clone or copy may only
+		// exist in its checkout.
+		return o.Branch != "" && (git.BranchExists(o.RepoPath, o.Branch) || git.BranchExists(o.Path, o.Branch))
+	}
+	return false
+}
+
+// GC reconciles the stored instances with the worktrees, branches and tmux sessions on disk.
+type GC struct {
+	storage   *Storage
+	cfg       *config.Config
+	cmdExec   cmd.Executor
+	instances []InstanceData
+}
+
+// NewGC loads the stored instances without starting them.
+func NewGC(storage *Storage, cfg *config.Config, cmdExec cmd.Executor) (*GC, error) {
+	instances, err := storage.LoadInstanceData()
+	if err != nil {
+		return nil, err
+	}
+	return &GC{storage: storage, cfg: cfg, cmdExec: cmdExec, instances: instances}, nil
+}
+
+// repos returns all repositories claude-squad knows about: those of stored instances, those with a
+// repository config, those that own a directory in the worktree directory, and extraRepos.
+func (gc *GC) repos(extraRepos []string) []string {
+	seen := make(map[string]bool)
+	var repos []string
+	add := func(path string) {
+		if path == "" {
+			return
+		}
+		path = filepath.Clean(path)
+		if seen[path] || !git.IsGitRepo(path) {
+			return
+		}
+		seen[path] = true
+		repos = append(repos, path)
+	}
+
+	for _, data := range gc.instances {
+		add(data.Worktree.RepoPath)
+	}
+	for repo := range gc.cfg.Repos {
+		add(repo)
+	}
+	for _, repo := range extraRepos {
+		add(repo)
+	}
+	if dir, err := git.WorktreeDirectory(); err == nil {
+		entries, _ := os.ReadDir(dir)
+		for _, entry := range entries {
+			add(git.WorktreeRepo(filepath.Join(dir, entry.Name())))
+		}
+	}
+	sort.Strings(repos)
+	return repos
+}
+
+// Find returns all orphaned resources. extraRepos are scanned in addition to the known repositories.
+func (gc *GC) Find(extraRepos ...string) ([]Orphan, error) {
+	var orphans []Orphan
+
+	usedPaths := make(map[string]bool)
+	usedBranches := make(map[string]bool)
+	usedSessions := make(map[string]bool)
+	// stored holds the worktrees of the stored instances by repository, to match saved changes.
+	stored := make(map[string][]*git.GitWorktree)
+	for _, data := range gc.instances {
+		usedPaths[filepath.Clean(data.Worktree.WorktreePath)] = true
+		usedBranches[branchKey(data.Worktree.RepoPath, data.Worktree.BranchName)] = true
+		usedSessions[tmux.SessionName(data.ID)] = true
+		if data.Snapshot == nil {
+			repo := filepath.Clean(data.Worktree.RepoPath)
+			stored[repo] = append(stored[repo], storedWorktree(data))
+		}
+	}
+
+	liveSessions, err := tmux.ListSessions(gc.cmdExec)
+	if err != nil {
+		return nil, err
+	}
+	live := make(map[string]bool)
+	for _, name := range liveSessions {
+		live[name] = true
+		if !usedSessions[name] {
+			orphans = append(orphans, Orphan{
+				Kind:    OrphanTmuxSession,
+				Session: name,
+				Reason:  "no stored instance uses it",
+			})
+		}
+	}
+
+	registered := make(map[string]bool)
+	for _, repo := range gc.repos(extraRepos) {
+		worktrees, err := git.ListWorktrees(repo)
+		if err != nil {
+			return nil, err
+		}
+		prefix := git.BranchNamePrefix(gc.cfg, repo)
+		for _, wt := range worktrees {
+			path := filepath.Clean(wt.Path)
+			registered[path] = true
+			if usedPaths[path] {
+				continue
+			}
+			if wt.Prunable {
+				orphans = append(orphans, Orphan{
+					Kind:     OrphanWorktree,
+					RepoPath: repo,
+					Path:     path,
+					Branch:   wt.Branch,
+					Reason:   "worktree directory is missing",
+				})
+				continue
+			}
+			if prefix != "" && strings.HasPrefix(wt.Branch, prefix) {
+				orphans = append(orphans, Orphan{
+					Kind:     OrphanWorktree,
+					RepoPath: repo,
+					Path:     path,
+					Branch:   wt.Branch,
+					Reason:   "no stored instance uses it",
+				})
+			}
+		}
+
+		refs, err := git.ListWIPRefs(repo)
+		if err != nil {
+			return nil, err
+		}
+		for _, ref := range refs {
+			owned := false
+			for _, worktree := range stored[repo] {
+				owned = owned || worktree.OwnsWIPRef(ref)
+			}
+			if !owned {
+				orphans = append(orphans, Orphan{
+					Kind:     OrphanWIP,
+					RepoPath: repo,
+					Ref:      ref,
+					Reason:   "no stored instance uses it",
+				})
+			}
+		}
+
+		// Without a prefix every branch would look like a claude-squad branch.
+		if prefix == "" {
+			continue
+		}
+		branches, err := git.ListBranches(repo, prefix)
+		if err != nil {
+			return nil, err
+		}
+		checkedOut := make(map[string]bool)
+		for _, wt := range worktrees {
+			checkedOut[wt.Branch] = true
+		}
+		for _, branch := range branches {
+			// Branches checked out in an orphaned worktree are reported with the worktree.
+			if usedBranches[branchKey(repo, branch)] || checkedOut[branch] {
+				continue
+			}
+			orphans = append(orphans, Orphan{
+				Kind:     OrphanBranch,
+				RepoPath: repo,
+				Branch:   branch,
+				Reason:   "no stored instance uses it",
+			})
+		}
+	}
+
+	if dir, err := git.WorktreeDirectory(); err == nil {
+		entries, _ := os.ReadDir(dir)
+		for _, entry := range entries {
+			path := filepath.Join(dir, entry.Name())
+			if usedPaths[path] || registered[path] {
+				continue
+			}
+			orphans = append(orphans, Orphan{
+				Kind:   OrphanWorktreeDir,
+				Path:   path,
+				Reason: "not a registered git worktree",
+			})
+		}
+	}
+
+	for _, data := range gc.instances {
+		if data.Snapshot != nil {
+			if orphan, ok := snapshotOrphan(data, live); ok {
+				orphans = append(orphans, orphan)
+			}
+			continue
+		}
+		orphan := Orphan{
+			Kind:     OrphanInstance,
+			RepoPath: data.Worktree.RepoPath,
+			Path:     data.Worktree.WorktreePath,
+			Branch:   data.Worktree.BranchName,
+			Session:  tmux.SessionName(data.ID),
+			Title:    data.Title,
+			ID:       data.ID,
+		}
+		worktree := storedWorktree(data)
+		_, statErr := os.Stat(data.Worktree.WorktreePath)
+		switch {
+		case !git.IsGitRepo(data.Worktree.RepoPath):
+			orphan.Reason = "repository no longer exists"
+		case !worktree.HasBranch():
+			orphan.Reason = "branch no longer exists"
+		case data.Status != Paused && os.IsNotExist(statErr):
+			orphan.Reason = "worktree no longer exists"
+		case data.Status != Paused && !live[orphan.Session]:
+			orphan.Reason = "tmux session no longer exists"
+		default:
+			continue
+		}
+		orphan.Unpushed, _ = worktree.UnpushedCommits()
+		orphans = append(orphans, orphan)
+	}
+
+	return orphans, nil
+}
+
+// snapshotOrphan checks a stored instance working on a snapshot of a directory without git.
+func snapshotOrphan(data InstanceData, live map[string]bool) (Orphan, bool) {
+	orphan := Orphan{
+		Kind:    OrphanInstance,
+		Path:    data.Snapshot.Dir,
+		Session: tmux.SessionName(data.ID),
+		Title:   data.Title,
+		ID:      data.ID,
+	}
+	s := snapshot.FromStorage(data.Snapshot.SourcePath, data.Snapshot.Dir)
+	if _, err := os.Stat(s.GetWorkPath()); os.IsNotExist(err) {
+		orphan.Reason = "snapshot no longer exists"
+		return orphan, true
+	}
+	if data.Status != Paused && !live[orphan.Session] {
+		orphan.Reason = "tmux session no longer exists"
+		return orphan, true
+	}
+	return orphan, false
+}
+
+// Delete removes an orphaned resource. Deleting an orphaned worktree also deletes its branch, and
+// deleting an orphaned instance removes it from storage along with everything it still owns.
+func (gc *GC) Delete(o Orphan) error {
+	switch o.Kind {
+	case OrphanWorktree:
+		if err := git.RemoveWorktree(o.RepoPath, o.Path); err != nil {
+			return err
+		}
+		if o.Branch != "" && git.BranchExists(o.RepoPath, o.Branch) {
+			return git.DeleteBranch(o.RepoPath, o.Branch)
+		}
+	case OrphanWorktreeDir:
+		if err := os.RemoveAll(o.Path); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", o.Path, err)
+		}
+	case OrphanBranch:
+		return git.DeleteBranch(o.RepoPath, o.Branch)
+	case OrphanTmuxSession:
+		return tmux.KillSession(gc.cmdExec, o.Session)
+	case OrphanWIP:
+		return git.DeleteWIPRef(o.RepoPath, o.Ref)
+	case OrphanInstance:
+		for _, data := range gc.instances {
+			if data.ID != o.ID || data.Worktree.RepoCheckout == nil || !git.IsGitRepo(o.RepoPath) {
+				continue
+			}
+			// The branch can't be deleted while it is checked out, and the instance is the only record
+			// of what to switch back to. Stashed changes that don't apply anymore stay in the stash.
+			worktree := storedWorktree(data)
+			if err := worktree.ReturnFromRepo(); err != nil && worktree.GetRepoCheckout() != nil {
+				return fmt.Errorf("failed to switch %s back: %w", o.RepoPath, err)
+			}
+		}
+		if err := gc.killSession(o.Session); err != nil {
+			return err
+		}
+		if o.RepoPath == "" {
+			// A snapshot instance, whose directory isn't a git worktree.
+			if err := os.RemoveAll(o.Path); err != nil {
+				return fmt.Errorf("failed to remove %s: %w", o.Path, err)
+			}
+		} else if git.IsGitRepo(o.RepoPath) {
+			if err := git.RemoveWorktree(o.RepoPath, o.Path); err != nil {
+				return err
+			}
+			if git.BranchExists(o.RepoPath, o.Branch) {
+				if err := git.DeleteBranch(o.RepoPath, o.Branch); err != nil {
+					return err
+				}
+			}
+			for _, data := range gc.instances {
+				if data.ID == o.ID {
+					if err := storedWorktree(data).DeleteWIP(); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		return gc.removeInstance(o.ID)
+	}
+	return nil
+}
+
+// Adopt turns an orphaned worktree or branch into a paused stored instance, and pauses an orphaned
+// instance whose branch still exists so that it can be resumed. Uncommitted changes in the worktree
+// are committed to the branch first.
+func (gc *GC) Adopt(o Orphan) error {
+	if !o.CanAdopt() {
+		return fmt.Errorf("cannot adopt %s", o.Kind)
+	}
+
+	switch o.Kind {
+	case OrphanWorktree, OrphanBranch:
+		title := gc.uniqueTitle(strings.TrimPrefix(o.Branch, git.BranchNamePrefix(gc.cfg, o.RepoPath)))
+		id := newInstanceID()
+		worktree, err := git.NewGitWorktreeForBranch(o.RepoPath, title, id, o.Branch, o.Path)
+		if err != nil {
+			return err
+		}
+		if err := releaseWorktree(worktree, title); err != nil {
+			return err
+		}
+		now := time.Now()
+		gc.instances = append(gc.instances, InstanceData{
+			ID:        id,
+			Title:     title,
+			Path:      o.RepoPath,
+			Branch:    o.Branch,
+			Status:    Paused,
+			CreatedAt: now,
+			UpdatedAt: now,
+			Program:   gc.cfg.DefaultProgram,
+			Worktree: GitWorktreeData{
+				RepoPath:      worktree.GetRepoPath(),
+				WorktreePath:  worktree.GetWorktreePath(),
+				SessionName:   title,
+				BranchName:    o.Branch,
+				BaseCommitSHA: worktree.GetBaseCommitSHA(),
+				BaseBranch:    worktree.GetBaseBranch(),
+			},
+		})
+		return gc.storage.SaveInstanceData(gc.instances)
+	case OrphanInstance:
+		for idx := range gc.instances {
+			data := &gc.instances[idx]
+			if data.ID != o.ID {
+				continue
+			}
+			worktree := storedWorktree(*data)
+			if err := releaseWorktree(worktree, data.Title); err != nil {
+				return err
+			}
+			if err := gc.killSession(o.Session); err != nil {
+				return err
+			}
+			data.Status = Paused
+			data.UpdatedAt = time.Now()
+			return gc.storage.SaveInstanceData(gc.instances)
+		}
+		return fmt.Errorf("instance '%s' not found", o.Title)
+	}
+	return nil
+}
+
+// storedWorktree returns the git worktree of a stored instance without loading the instance.
+func storedWorktree(data InstanceData) *git.GitWorktree {
+	worktree := git.NewGitWorktreeFromStorage(data.Worktree.RepoPath, data.Worktree.WorktreePath,
+		data.Worktree.SessionName, data.Worktree.BranchName, data.Worktree.BaseCommitSHA, data.Worktree.BaseBranch)
+	worktree.SetSessionID(data.ID)
+	worktree.SetAgent(data.Program)
+	worktree.SetIsolation(git.Isolation(data.Worktree.Isolation))
+	if checkout := data.Worktree.RepoCheckout; checkout != nil {
+		worktree.SetRepoCheckout(&git.RepoCheckout{
+			Previous: checkout.Previous,
+			Detached: checkout.Detached,
+			Stash:    checkout.Stash,
+		})
+	}
+	return worktree
+}
+
+// releaseWorktree commits any uncommitted changes in the worktree and removes it, leaving the
+// branch as a paused instance expects it.
+func releaseWorktree(worktree *git.GitWorktree, title string) error {
+	if _, err := os.Stat(worktree.GetWorktreePath()); err == nil {
+		if dirty, err := worktree.IsDirty(); err != nil {
+			return err
+		} else if dirty {
+			commitMsg := fmt.Sprintf("[claudesquad] update from '%s' on %s (adopted)", title, time.Now().Format(time.RFC822))
+			if err := worktree.CommitChanges(commitMsg); err != nil {
+				return err
+			}
+		}
+		if err := worktree.Remove(); err != nil {
+			return err
+		}
+	}
+	return worktree.Prune()
+}
+
+// killSession kills a tmux session if it is still running.
+func (gc *GC) killSession(name string) error {
+	sessions, err := tmux.ListSessions(gc.cmdExec)
+	if err != nil {
+		return err
+	}
+	for _, session := range sessions {
+		if session == name {
+			return tmux.KillSession(gc.cmdExec, name)
+		}
+	}
+	return nil
+}
+
+func (gc *GC) removeInstance(id string) error {
+	for idx, data := range gc.instances {
+		if data.ID == id {
+			gc.instances = append(gc.instances[:idx], gc.instances[idx+1:]...)
+			return gc.storage.SaveInstanceData(gc.instances)
+		}
+	}
+	return nil
+}
+
+// uniqueTitle returns title, or title with a numeric suffix if an instance already uses it.
+func (gc *GC) uniqueTitle(title string) string {
+	taken := func(candidate string) bool {
+		for _, data := range gc.instances {
+			if data.Title == candidate {
+				return true
+			}
+		}
+		return false
+	}
+	candidate := title
+	for n := 2; taken(candidate); n++ {
+		candidate = fmt.Sprintf("%s-%d", title, n)
+	}
+	return candidate
+}
+
+func branchKey(repoPath, branch string) string {
+	return filepath.Clean(repoPath) + "\x00" + branch
+}
@@ -0,0 +1,304 @@
+package session
+
+import (
+	"claude-squad/cmd/cmd_test"
+	"claude-squad/config"
+	"claude-squad/session/git"
+	"claude-squad/session/tmux"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gcFixture is a repository with one resource of each orphan kind next to a healthy stored instance.
+type gcFixture struct {
+	repo    string
+	storage *Storage
+	cfg     *config.Config
+	cmdExec cmd_test.MockCmdExec
+	// killed records the tmux sessions killed through cmdExec.
+	killed *[]string
+	// strayDir is a directory in the worktree directory that isn't a git worktree.
+	strayDir string
+	// lostWorktree is a worktree of a claude-squad branch no instance uses.
+	lostWorktree string
+	// crashedWorktree belongs to the stored instance whose tmux session is gone.
+	crashedWorktree string
+}
+
+func setupGC(t *testing.T) *gcFixture {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	f := &gcFixture{repo: t.TempDir(), cfg: config.DefaultConfig()}
+	f.cfg.BranchPrefix = "cs/"
+
+	f.git(t, f.repo, "init", "-q")
+	f.git(t, f.repo, "config", "user.email", "test@example.com")
+	f.git(t, f.repo, "config", "user.name", "Test")
+	f.git(t, f.repo, "commit", "-q", "--allow-empty", "-m", "initial")
+
+	var instances []InstanceData
+	for _, title := range []string{"kept", "crashed"} {
+		worktree := filepath.Join(t.TempDir(), title)
+		f.git(t, f.repo, "worktree", "add", "-q", "-b", "cs/"+title, worktree)
+		instances = append(instances, InstanceData{
+			ID:     title + "-id",
+			Title:  title,
+			Path:   f.repo,
+			Branch: "cs/" + title,
+			Status: Running,
+			Worktree: GitWorktreeData{
+				RepoPath:     f.repo,
+				WorktreePath: worktree,
+				SessionName:  title,
+				BranchName:   "cs/" + title,
+			},
+		})
+	}
+	f.crashedWorktree = instances[1].Worktree.WorktreePath
+
+	f.lostWorktree = filepath.Join(t.TempDir(), "lost")
+	f.git(t, f.repo, "worktree", "add", "-q", "-b", "cs/lost", f.lostWorktree)
+	f.git(t, f.repo, "branch", "cs/stale")
+	// Branches without the prefix aren't claude-squad's.
+	f.git(t, f.repo, "branch", "feature")
+	// Uncommitted changes saved on pause, by "kept" and by an instance that is gone.
+	head := f.git(t, f.repo, "rev-parse", "HEAD")
+	f.git(t, f.repo, "update-ref", "refs/claudesquad/wip/kept-id", head)
+	f.git(t, f.repo, "update-ref", "refs/claudesquad/wip/gone-id", head)
+
+	dir, err := git.WorktreeDirectory()
+	require.NoError(t, err)
+	f.strayDir = filepath.Join(dir, "stray")
+	require.NoError(t, os.MkdirAll(f.strayDir, 0755))
+
+	f.storage, err = NewStorage(&memoryInstanceStorage{})
+	require.NoError(t, err)
+	require.NoError(t, f.storage.SaveInstanceData(instances))
+
+	// Only the session of "kept" and a stray one are running.
+	live := []string{tmux.SessionName("kept-id"), tmux.SessionName("stray")}
+	killed := []string{}
+	f.killed = &killed
+	f.cmdExec = cmd_test.MockCmdExec{
+		RunFunc: func(cmd *exec.Cmd) error {
+			if len(cmd.Args) > 2 && cmd.Args[1] == "kill-session" {
+				killed = append(killed, strings.TrimPrefix(cmd.Args[2], "-t="))
+			}
+			return nil
+		},
+		OutputFunc: func(cmd *exec.Cmd) ([]byte, error) {
+			return []byte(strings.Join(live, "\n") + "\n"), nil
+		},
+	}
+	return f
+}
+
+func (f *gcFixture) git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+	require.NoError(t, err, string(out))
+	return strings.TrimSpace(string(out))
+}
+
+func (f *gcFixture) newGC(t *testing.T) *GC {
+	gc, err := NewGC(f.storage, f.cfg, f.cmdExec)
+	require.NoError(t, err)
+	return gc
+}
+
+// findOrphan returns the orphan of the given kind, failing the test if there isn't exactly one.
+func findOrphan(t *testing.T, orphans []Orphan, kind OrphanKind) Orphan {
+	t.Helper()
+	var found []Orphan
+	for _, o := range orphans {
+		if o.Kind == kind {
+			found = append(found, o)
+		}
+	}
+	require.Len(t, found, 1, "orphans of kind %s: %v", kind, found)
+	return found[0]
+}
+
+func TestGCFind(t *testing.T) {
+	f := setupGC(t)
+	orphans, err := f.newGC(t).Find()
+	require.NoError(t, err)
+	require.Len(t, orphans, 6, "%v", orphans)
+
+	wt := findOrphan(t, orphans, OrphanWorktree)
+	assert.Equal(t, f.lostWorktree, wt.Path)
+	assert.Equal(t, "cs/lost", wt.Branch)
+	assert.True(t, wt.CanAdopt())
+
+	branch := findOrphan(t, orphans, OrphanBranch)
+	assert.Equal(t, "cs/stale", branch.Branch)
+
+	dir := findOrphan(t, orphans, OrphanWorktreeDir)
+	assert.Equal(t, f.strayDir, dir.Path)
+	assert.False(t, dir.CanAdopt())
+
+	session := findOrphan(t, orphans, OrphanTmuxSession)
+	assert.Equal(t, tmux.SessionName("stray"), session.Session)
+
+	wip := findOrphan(t, orphans, OrphanWIP)
+	assert.Equal(t, "refs/claudesquad/wip/gone-id", wip.Ref)
+	assert.False(t, wip.CanAdopt())
+
+	instance := findOrphan(t, orphans, OrphanInstance)
+	assert.Equal(t, "crashed", instance.Title)
+	assert.Equal(t, "tmux session no longer exists", instance.Reason)
+	assert.True(t, instance.CanAdopt())
+
+	// The branches of stored instances are in use, whatever their state.
+	for _, o := range orphans {
+		if o.Kind != OrphanInstance {
+			assert.NotEqual(t, "cs/kept", o.Branch)
+			assert.NotEqual(t, "cs/crashed", o.Branch)
+		}
+		assert.NotEqual(t, "feature", o.Branch)
+	}
+}
+
+func TestGCDelete(t *testing.T) {
+	f := setupGC(t)
+	gc := f.newGC(t)
+	orphans, err := gc.Find()
+	require.NoError(t, err)
+
+	branchExists := func(branch string) bool { return git.BranchExists(f.repo, branch) }
+
+	require.NoError(t, gc.Delete(findOrphan(t, orphans, OrphanBranch)))
+	assert.False(t, branchExists("cs/stale"))
+	assert.True(t, branchExists("cs/lost"))
+	assert.True(t, branchExists("feature"))
+
+	require.NoError(t, gc.Delete(findOrphan(t, orphans, OrphanWorktree)))
+	assert.False(t, branchExists("cs/lost"))
+	_, err = os.Stat(f.lostWorktree)
+	assert.True(t, os.IsNotExist(err))
+	assert.True(t, branchExists("cs/kept"))
+
+	require.NoError(t, gc.Delete(findOrphan(t, orphans, OrphanWorktreeDir)))
+	_, err = os.Stat(f.strayDir)
+	assert.True(t, os.IsNotExist(err))
+
+	require.NoError(t, gc.Delete(findOrphan(t, orphans, OrphanTmuxSession)))
+	assert.Equal(t, []string{tmux.SessionName("stray")}, *f.killed)
+
+	require.NoError(t, gc.Delete(findOrphan(t, orphans, OrphanWIP)))
+	assert.Equal(t, "refs/claudesquad/wip/kept-id", f.git(t, f.repo, "for-each-ref", "--format=%(refname)", "refs/claudesquad/"))
+
+	require.NoError(t, gc.Delete(findOrphan(t, orphans, OrphanInstance)))
+	assert.False(t, branchExists("cs/crashed"))
+	_, err = os.Stat(f.crashedWorktree)
+	assert.True(t, os.IsNotExist(err))
+
+	stored, err := f.storage.LoadInstanceData()
+	require.NoError(t, err)
+	require.Len(t, stored, 1)
+	assert.Equal(t, "kept", stored[0].Title)
+
+	// Nothing else is left to collect.
+	orphans, err = f.newGC(t).Find()
+	require.NoError(t, err)
+	for _, o := range orphans {
+		assert.Equal(t, OrphanTmuxSession, o.Kind, "%v", o)
+	}
+}
+
+func TestGCAdopt(t *testing.T) {
+	f := setupGC(t)
+	gc := f.newGC(t)
+	orphans, err := gc.Find()
+	require.NoError(t, err)
+
+	// Uncommitted changes in an adopted worktree are committed to its branch.
+	require.NoError(t, os.WriteFile(filepath.Join(f.lostWorktree, "work.txt"), []byte("work\n"), 0644))
+	require.NoError(t, gc.Adopt(findOrphan(t, orphans, OrphanWorktree)))
+	require.NoError(t, gc.Adopt(findOrphan(t, orphans, OrphanInstance)))
+	assert.Error(t, gc.Adopt(findOrphan(t, orphans, OrphanWorktreeDir)))
+
+	_, err = os.Stat(f.lostWorktree)
+	assert.True(t, os.IsNotExist(err))
+	assert.Equal(t, "work\n", f.git(t, f.repo, "show", "cs/lost:work.txt")+"\n")
+	_, err = os.Stat(f.crashedWorktree)
+	assert.True(t, os.IsNotExist(err))
+
+	stored, err := f.storage.LoadInstanceData()
+	require.NoError(t, err)
+	require.Len(t, stored, 3)
+	byBranch := make(map[string]InstanceData)
+	for _, data := range stored {
+		byBranch[data.Branch] = data
+	}
+	assert.Equal(t, Running, byBranch["cs/kept"].Status)
+	// Loading the adopted instances doesn't need a tmux session or worktree, they are paused.
+	for _, branch := range []string{"cs/lost", "cs/crashed"} {
+		require.Contains(t, byBranch, branch)
+		instance, err := FromInstanceData(byBranch[branch])
+		require.NoError(t, err)
+		assert.True(t, instance.Paused())
+		assert.Equal(t, branch, instance.Branch)
+	}
+	assert.Equal(t, "lost", byBranch["cs/lost"].Title)
+
+	// Adopted resources are no longer orphans.
+	orphans, err = f.newGC(t).Find()
+	require.NoError(t, err)
+	for _, o := range orphans {
+		assert.NotEqual(t, OrphanWorktree, o.Kind, "%v", o)
+		assert.NotEqual(t, OrphanInstance, o.Kind, "%v", o)
+	}
+}
+
+func TestGCClone(t *testing.T) {
+	f := setupGC(t)
+	// The branch of a clone only reaches the repository when the instance is paused.
+	clone := filepath.Join(t.TempDir(), "clone")
+	f.git(t, f.repo, "clone", "-q", "--no-checkout", "--reference", f.repo, "--origin", "source", f.repo, clone)
+	f.git(t, clone, "config", "include.path", filepath.Join(f.repo, ".git", "config"))
+	f.git(t, clone, "checkout", "-q", "--no-track", "-b", "cs/cloned", "HEAD")
+	f.git(t, clone, "commit", "-q", "--allow-empty", "-m", "work in clone")
+	instances, err := f.storage.LoadInstanceData()
+	require.NoError(t, err)
+	instances = append(instances, InstanceData{
+		ID:     "cloned-id",
+		Title:  "cloned",
+		Path:   f.repo,
+		Branch: "cs/cloned",
+		Status: Running,
+		Worktree: GitWorktreeData{
+			RepoPath:     f.repo,
+			WorktreePath: clone,
+			SessionName:  "cloned",
+			BranchName:   "cs/cloned",
+			Isolation:    "clone",
+		},
+	})
+	require.NoError(t, f.storage.SaveInstanceData(instances))
+
+	gc := f.newGC(t)
+	orphans, err := gc.Find()
+	require.NoError(t, err)
+	var cloned Orphan
+	for _, o := range orphans {
+		if o.Kind == OrphanInstance && o.Title == "cloned" {
+			cloned = o
+		}
+	}
+	assert.Equal(t, "tmux session no longer exists", cloned.Reason)
+	require.Len(t, cloned.Unpushed, 1)
+	assert.Contains(t, cloned.String(), "1 unpushed commit(s) on cs/cloned")
+	assert.True(t, cloned.CanAdopt())
+
+	require.NoError(t, gc.Adopt(cloned))
+	_, err = os.Stat(clone)
+	assert.True(t, os.IsNotExist(err))
+	assert.Equal(t, "work in clone", f.git(t, f.repo, "log", "-1", "--format=%s", "cs/cloned"))
+}
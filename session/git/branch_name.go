@@ -0,0 +1,191 @@
+package git
+
+import (
+	"claude-squad/config"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// maxBranchSuffix bounds the search for a free branch name. Suffixes can't resolve every collision,
+// e.g. when a branch is named like a directory of the new branch.
+const maxBranchSuffix = 100
+
+var ticketRegex = regexp.MustCompile(`\b[A-Z][A-Z0-9]+-[0-9]+\b|#[0-9]+\b`)
+
+// branchNameVars returns the variables available in branch name templates, see
+// config.RepoConfig.BranchName.
+func branchNameVars(cfg *config.Config, repoPath, sessionName string) map[string]string {
+	ticket := ticketRegex.FindString(sessionName)
+	slug := sessionName
+	if ticket != "" {
+		slug = strings.Replace(slug, ticket, "", 1)
+	}
+	return map[string]string{
+		"prefix": cfg.BranchPrefix,
+		"user":   loginName(),
+		"date":   time.Now().Format("2006-01-02"),
+		"repo":   filepath.Base(repoPath),
+		"title":  sanitizeBranchName(sessionName),
+		"ticket": strings.TrimPrefix(ticket, "#"),
+		"slug":   sanitizeBranchName(slug),
+	}
+}
+
+func loginName() string {
+	u, err := user.Current()
+	if err != nil || u.Username == "" {
+		return "session"
+	}
+	return strings.ToLower(u.Username)
+}
+
+// newBranchName returns the name of the branch for a new session, following the configured template.
+// The name is not checked for collisions, see uniqueBranchName.
+func newBranchName(cfg *config.Config, repoPath, sessionName string) (string, error) {
+	name := cfg.BranchPrefix + sanitizeBranchName(sessionName)
+	if tmpl := cfg.ForRepo(repoPath).BranchName; tmpl != "" {
+		expanded, err := config.ExpandTemplate(tmpl, branchNameVars(cfg, repoPath, sessionName))
+		if err != nil {
+			return "", fmt.Errorf("invalid branch name template: %w", err)
+		}
+		name = expanded
+	}
+	name = sanitizeRefName(name)
+	if name == "" {
+		return "", fmt.Errorf("cannot derive a branch name from '%s'", sessionName)
+	}
+	return name, nil
+}
+
+// BranchNamePrefix returns the part that all branch names created for the repository start with. It
+// is empty if the names don't share a prefix, e.g. because the template starts with the date.
+func BranchNamePrefix(cfg *config.Config, repoPath string) string {
+	tmpl := cfg.ForRepo(repoPath).BranchName
+	if tmpl == "" {
+		return cfg.BranchPrefix
+	}
+	vars := branchNameVars(cfg, repoPath, "")
+	// Only the variables that don't depend on the session or the day are fixed.
+	for _, name := range []string{"date", "title", "ticket", "slug"} {
+		delete(vars, name)
+	}
+	return config.ExpandTemplatePrefix(tmpl, vars)
+}
+
+// uniqueBranchName returns name, or name with a numeric suffix if a local or remote branch already
+// uses it. Existing branches are never reused or replaced, they may belong to someone else.
+func uniqueBranchName(repoPath, name string) (string, error) {
+	output, err := runGit(repoPath, "for-each-ref", "--format=%(refname)", "refs/heads/", "refs/remotes/")
+	if err != nil {
+		return "", fmt.Errorf("failed to list branches: %w", err)
+	}
+	existing := make(map[string]bool)
+	for _, ref := range strings.Split(output, "\n") {
+		switch {
+		case strings.HasPrefix(ref, "refs/heads/"):
+			existing[strings.TrimPrefix(ref, "refs/heads/")] = true
+		case strings.HasPrefix(ref, "refs/remotes/"):
+			// Strip the remote name as well.
+			if parts := strings.SplitN(ref, "/", 4); len(parts) == 4 && parts[3] != "HEAD" {
+				existing[parts[3]] = true
+			}
+		}
+	}
+
+	taken := func(candidate string) bool {
+		for branch := range existing {
+			// Refs are files, so a branch can't also be a directory of branches and vice versa.
+			if branch == candidate || strings.HasPrefix(branch, candidate+"/") || strings.HasPrefix(candidate, branch+"/") {
+				return true
+			}
+		}
+		return false
+	}
+	candidate := name
+	for n := 2; taken(candidate); n++ {
+		if n > maxBranchSuffix {
+			return "", fmt.Errorf("branch %s already exists", name)
+		}
+		candidate = fmt.Sprintf("%s-%d", name, n)
+	}
+	return candidate, nil
+}
+
+// BranchNameFor returns the name the session's branch gets when the session is renamed to title. It
+// doesn't collide with other existing branches.
+func (g *GitWorktree) BranchNameFor(title string) (string, error) {
+	name, err := newBranchName(config.LoadConfig(), g.repoPath, title)
+	if err != nil || name == g.branchName {
+		return name, err
+	}
+	return uniqueBranchName(g.repoPath, name)
+}
+
+// RenameBranch renames the session's branch in the main repository and, for clones and copies, in the
+// checkout. A pushed branch keeps its old name on the remote.
+func (g *GitWorktree) RenameBranch(name string) error {
+	if name == g.branchName {
+		return nil
+	}
+	renamed := false
+	if g.GetIsolation() != IsolationWorktree {
+		if _, err := os.Stat(g.worktreePath); err == nil {
+			if _, err := g.runGitCommand(g.worktreePath, "branch", "-m", g.branchName, name); err != nil {
+				return fmt.Errorf("failed to rename branch %s: %w", g.branchName, err)
+			}
+			renamed = true
+		}
+	}
+	if BranchExists(g.repoPath, g.branchName) {
+		if _, err := g.runGitCommand(g.repoPath, "branch", "-m", g.branchName, name); err != nil {
+			return fmt.Errorf("failed to rename branch %s: %w", g.branchName, err)
+		}
+		renamed = true
+	}
+	if !renamed {
+		return fmt.Errorf("branch %s does not exist", g.branchName)
+	}
+	g.branchName = name
+	return nil
+}
+
+var (
+	// invalidRefChars are characters git-check-ref-format rejects anywhere in a ref name.
+	invalidRefChars = regexp.MustCompile(`[\x00-\x20\x7f~^:?*\[\\]+|@{`)
+	repeatedDots    = regexp.MustCompile(`\.\.+`)
+	repeatedDashes  = regexp.MustCompile(`--+`)
+)
+
+// sanitizeRefName turns name into a valid branch name according to git-check-ref-format, keeping it
+// as close to the original as possible.
+func sanitizeRefName(name string) string {
+	name = invalidRefChars.ReplaceAllString(name, "-")
+	name = repeatedDots.ReplaceAllString(name, ".")
+	name = repeatedDashes.ReplaceAllString(name, "-")
+
+	var components []string
+	for _, component := range strings.Split(name, "/") {
+		// Components can't start with a dot or end with .lock. Dashes are trimmed as well since empty
+		// template variables leave them dangling.
+		for {
+			trimmed := strings.TrimSuffix(strings.Trim(component, "-."), ".lock")
+			if trimmed == component {
+				break
+			}
+			component = trimmed
+		}
+		if component != "" {
+			components = append(components, component)
+		}
+	}
+	name = strings.Join(components, "/")
+	if name == "@" {
+		return ""
+	}
+	return name
+}
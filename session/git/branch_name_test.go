@@ -0,0 +1,87 @@
+package git
+
+import (
+	"claude-squad/config"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSanitizeRefName(t *testing.T) {
+	tests := map[string]string{
+		"me/feature":           "me/feature",
+		"Me/Fix bug: crash?":   "Me/Fix-bug-crash",
+		"me//.hidden/x.lock":   "me/hidden/x",
+		"me/a..b/c.":           "me/a.b/c",
+		"me/2026-01-02--slug-": "me/2026-01-02-slug",
+		"me/@{upstream}":       "me/upstream}",
+		"/-lead/trail-/":       "lead/trail",
+		"@":                    "",
+	}
+	for input, expected := range tests {
+		assert.Equal(t, expected, sanitizeRefName(input), input)
+	}
+}
+
+func TestNewBranchName(t *testing.T) {
+	cfg := &config.Config{BranchPrefix: "me/"}
+
+	name, err := newBranchName(cfg, "/src/app", "Fix Login")
+	require.NoError(t, err)
+	assert.Equal(t, "me/fix-login", name)
+
+	cfg.BranchName = "{{prefix}}{{date}}-{{ticket}}-{{slug}}"
+	date := time.Now().Format("2006-01-02")
+	name, err = newBranchName(cfg, "/src/app", "ABC-123 fix login")
+	require.NoError(t, err)
+	assert.Equal(t, "me/"+date+"-ABC-123-fix-login", name)
+	name, err = newBranchName(cfg, "/src/app", "fix login")
+	require.NoError(t, err)
+	assert.Equal(t, "me/"+date+"-fix-login", name)
+	assert.Equal(t, "me/", BranchNamePrefix(cfg, "/src/app"))
+
+	cfg.BranchName = "{{prefix}}{{tikcet}}"
+	_, err = newBranchName(cfg, "/src/app", "fix")
+	assert.ErrorContains(t, err, "tikcet")
+}
+
+func TestUniqueBranchName(t *testing.T) {
+	g := setupHunkTestRepo(t)
+	repo := g.repoPath
+	for _, branch := range []string{"me/feature", "me/feature-2", "me/dir/nested"} {
+		_, err := runGit(repo, "branch", branch)
+		require.NoError(t, err)
+	}
+	_, err := runGit(repo, "update-ref", "refs/remotes/origin/me/remote", "HEAD")
+	require.NoError(t, err)
+
+	for name, expected := range map[string]string{
+		"me/new":     "me/new",
+		"me/feature": "me/feature-3",
+		"me/remote":  "me/remote-2",
+		"me/dir":     "me/dir-2",
+	} {
+		unique, err := uniqueBranchName(repo, name)
+		require.NoError(t, err)
+		assert.Equal(t, expected, unique, name)
+	}
+
+	_, err = uniqueBranchName(repo, "me/feature/sub")
+	assert.ErrorContains(t, err, "already exists")
+}
+
+func TestRenameBranch(t *testing.T) {
+	repo := setupHunkTestRepo(t).repoPath
+	path := filepath.Join(t.TempDir(), "wt")
+	_, err := runGit(repo, "worktree", "add", "-q", "-b", "me/old", path)
+	require.NoError(t, err)
+	g := NewGitWorktreeFromStorage(repo, path, "old", "me/old", "", "")
+
+	require.NoError(t, g.RenameBranch("me/new"))
+	assert.Equal(t, "me/new", g.GetBranchName())
+	assert.False(t, BranchExists(repo, "me/old"))
+	assert.Equal(t, "me/new", CurrentBranch(path))
+}
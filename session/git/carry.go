@@ -0,0 +1,113 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CarryMode selects what happens to the uncommitted changes of the main checkout when a new worktree
+// is created.
+type CarryMode string
+
+const (
+	// CarryNone starts the worktree from a clean HEAD.
+	CarryNone CarryMode = ""
+	// CarryCopy copies the staged, unstaged and untracked changes into the worktree.
+	CarryCopy CarryMode = "copy"
+	// CarryMove copies the changes and then stashes them in the main checkout.
+	CarryMove CarryMode = "move"
+)
+
+// Next returns the mode following m, cycling through none, copy and move.
+func (m CarryMode) Next() CarryMode {
+	switch m {
+	case CarryNone:
+		return CarryCopy
+	case CarryCopy:
+		return CarryMove
+	}
+	return CarryNone
+}
+
+// SetCarryChanges sets whether Setup brings the uncommitted changes of the main checkout into a new
+// worktree. It has no effect on existing branches.
+func (g *GitWorktree) SetCarryChanges(mode CarryMode) {
+	g.carry = mode
+}
+
+// carryChanges recreates the staged, unstaged and untracked changes of the main checkout in the
+// worktree, which must have been created at the checkout's HEAD. The changes stay uncommitted, so the
+// diff against the base commit shows them along with the agent's changes.
+func (g *GitWorktree) carryChanges() error {
+	staged, err := g.runGitCommand(g.repoPath, "diff", "--cached", "--binary")
+	if err != nil {
+		return fmt.Errorf("failed to get staged changes: %w", err)
+	}
+	unstaged, err := g.runGitCommand(g.repoPath, "diff", "--binary")
+	if err != nil {
+		return fmt.Errorf("failed to get unstaged changes: %w", err)
+	}
+	untracked, err := g.runGitCommand(g.repoPath, "ls-files", "--others", "--exclude-standard", "-z")
+	if err != nil {
+		return fmt.Errorf("failed to list untracked files: %w", err)
+	}
+
+	if strings.TrimSpace(staged) != "" {
+		if err := g.applyPatch(staged, "--index"); err != nil {
+			return fmt.Errorf("failed to apply staged changes: %w", err)
+		}
+	}
+	if strings.TrimSpace(unstaged) != "" {
+		if err := g.applyPatch(unstaged); err != nil {
+			return fmt.Errorf("failed to apply unstaged changes: %w", err)
+		}
+	}
+	for _, path := range strings.Split(untracked, "\x00") {
+		if path == "" {
+			continue
+		}
+		if err := copyUntracked(filepath.Join(g.repoPath, path), filepath.Join(g.worktreePath, path)); err != nil {
+			return err
+		}
+	}
+
+	if g.carry == CarryMove && (staged != "" || unstaged != "" || untracked != "") {
+		// Stashing rather than discarding keeps the changes recoverable from the main checkout.
+		message := fmt.Sprintf("claude-squad: moved to %s", g.branchName)
+		if _, err := g.runGitCommand(g.repoPath, "stash", "push", "--include-untracked", "-m", message); err != nil {
+			return fmt.Errorf("failed to stash the moved changes: %w", err)
+		}
+	}
+	return nil
+}
+
+// copyUntracked copies an untracked file or symlink, keeping its mode.
+func copyUntracked(src, dst string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return fmt.Errorf("failed to read untracked file: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for untracked file: %w", err)
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(src)
+		if err != nil {
+			return fmt.Errorf("failed to read untracked symlink: %w", err)
+		}
+		if err := os.Symlink(target, dst); err != nil {
+			return fmt.Errorf("failed to copy untracked symlink: %w", err)
+		}
+		return nil
+	}
+	content, err := os.ReadFile(src)
+	if err != nil {
+		return fmt.Errorf("failed to read untracked file: %w", err)
+	}
+	if err := os.WriteFile(dst, content, info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to copy untracked file: %w", err)
+	}
+	return nil
+}
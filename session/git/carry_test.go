@@ -0,0 +1,55 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCarryChanges(t *testing.T) {
+	for _, mode := range []CarryMode{CarryCopy, CarryMove} {
+		t.Run(string(mode), func(t *testing.T) {
+			repo := setupHunkTestRepo(t).repoPath
+			writeFile(t, repo, "staged.txt", "staged\n")
+			_, err := runGit(repo, "add", "staged.txt")
+			require.NoError(t, err)
+			writeFile(t, repo, "file.txt", strings.Replace(readFile(t, repo, "file.txt"), "line c", "line C", 1))
+			require.NoError(t, os.MkdirAll(filepath.Join(repo, "notes"), 0755))
+			writeFile(t, repo, "notes/todo.md", "untracked\n")
+
+			g := &GitWorktree{
+				repoPath:     repo,
+				worktreePath: filepath.Join(t.TempDir(), "checkout"),
+				sessionName:  "carry",
+				branchName:   "cs/carry",
+				carry:        mode,
+			}
+			require.NoError(t, g.Setup())
+
+			status, err := runGit(g.worktreePath, "status", "--porcelain")
+			require.NoError(t, err)
+			assert.Equal(t, " M file.txt\nA  staged.txt\n?? notes/\n", status)
+			assert.Equal(t, "untracked\n", readFile(t, g.worktreePath, "notes/todo.md"))
+			stats := g.Diff()
+			require.NoError(t, stats.Error)
+			assert.Equal(t, 3, stats.Added)
+
+			// Moving stashes the changes in the main checkout, copying leaves them alone.
+			status, err = runGit(repo, "status", "--porcelain")
+			require.NoError(t, err)
+			stashes, err := runGit(repo, "stash", "list")
+			require.NoError(t, err)
+			if mode == CarryMove {
+				assert.Empty(t, status)
+				assert.Contains(t, stashes, "claude-squad: moved to cs/carry")
+			} else {
+				assert.NotEmpty(t, status)
+				assert.Empty(t, stashes)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RepoCheckout records what the main checkout looked like before the session's branch was checked out
+// in it, so ReturnFromRepo can put it back.
+type RepoCheckout struct {
+	// Previous is the branch checked out before, or the commit if HEAD was detached.
+	Previous string
+	// Detached is true if HEAD was detached at Previous.
+	Detached bool
+	// Stash is the stash commit holding the main checkout's uncommitted changes, "" if there were
+	// none.
+	Stash string
+}
+
+// SetRepoCheckout restores the record of a checkout made by CheckOutInRepo, e.g. from storage.
+func (g *GitWorktree) SetRepoCheckout(checkout *RepoCheckout) {
+	g.repoCheckout = checkout
+}
+
+// GetRepoCheckout returns the record of the checkout made by CheckOutInRepo, or nil if the branch
+// isn't checked out that way.
+func (g *GitWorktree) GetRepoCheckout() *RepoCheckout {
+	return g.repoCheckout
+}
+
+// CheckOutInRepo checks out the session's branch in the main repository. The main checkout's
+// uncommitted changes are stashed and the uncommitted changes saved on pause are brought along, so
+// the main checkout looks like the session did. The checkout must have been removed first, as a
+// branch can't be checked out twice.
+func (g *GitWorktree) CheckOutInRepo() error {
+	if g.repoCheckout != nil {
+		return fmt.Errorf("%s is already checked out", g.branchName)
+	}
+	if g.HasCheckout() {
+		return fmt.Errorf("pause the session before checking out %s", g.branchName)
+	}
+
+	checkout := &RepoCheckout{}
+	if branch, err := g.runGitCommand(g.repoPath, "symbolic-ref", "--short", "-q", "HEAD"); err == nil {
+		checkout.Previous = strings.TrimSpace(branch)
+	} else {
+		head, err := g.runGitCommand(g.repoPath, "rev-parse", "HEAD")
+		if err != nil {
+			return fmt.Errorf("failed to find what is checked out: %w", err)
+		}
+		checkout.Previous = strings.TrimSpace(head)
+		checkout.Detached = true
+	}
+	if checkout.Previous == g.branchName {
+		return fmt.Errorf("%s is already checked out", g.branchName)
+	}
+
+	status, err := g.runGitCommand(g.repoPath, "status", "--porcelain")
+	if err != nil {
+		return fmt.Errorf("failed to check repository status: %w", err)
+	}
+	if len(status) > 0 {
+		message := fmt.Sprintf("claude-squad: checkout of %s", g.branchName)
+		if _, err := g.runGitCommand(g.repoPath, "stash", "push", "--include-untracked", "-m", message); err != nil {
+			return fmt.Errorf("failed to stash uncommitted changes: %w", err)
+		}
+		stash, err := g.runGitCommand(g.repoPath, "rev-parse", "refs/stash")
+		if err != nil {
+			return fmt.Errorf("failed to find stashed changes: %w", err)
+		}
+		checkout.Stash = strings.TrimSpace(stash)
+	}
+
+	if _, err := g.runGitCommand(g.repoPath, "checkout", "-q", g.branchName); err != nil {
+		if checkout.Stash != "" {
+			if popErr := g.popStash(checkout.Stash); popErr != nil {
+				err = fmt.Errorf("%v (%v)", err, popErr)
+			}
+		}
+		return fmt.Errorf("failed to check out %s: %w", g.branchName, err)
+	}
+	g.repoCheckout = checkout
+
+	if err := g.restoreWIP(g.repoPath); err != nil {
+		// The branch is checked out regardless, the changes stay in the ref.
+		return err
+	}
+	return nil
+}
+
+// ReturnFromRepo undoes CheckOutInRepo. Uncommitted changes made on the branch in the main checkout
+// are set aside for the session like on pause, then the previous branch and its stashed changes are
+// restored.
+func (g *GitWorktree) ReturnFromRepo() error {
+	checkout := g.repoCheckout
+	if checkout == nil {
+		return fmt.Errorf("%s is not checked out in the repository", g.branchName)
+	}
+	onBranch, err := g.IsBranchCheckedOut()
+	if err != nil {
+		return err
+	}
+
+	if onBranch {
+		saved, err := g.saveWIP(g.repoPath)
+		if err != nil {
+			return fmt.Errorf("failed to save uncommitted changes: %w", err)
+		}
+		if saved {
+			// The changes are safe in the ref, clear them so the previous branch can be checked out.
+			if _, err := g.runGitCommand(g.repoPath, "reset", "-q", "--hard"); err != nil {
+				return fmt.Errorf("failed to reset the main checkout: %w", err)
+			}
+			if _, err := g.runGitCommand(g.repoPath, "clean", "-q", "-fd"); err != nil {
+				return fmt.Errorf("failed to clean the main checkout: %w", err)
+			}
+		}
+
+		args := []string{"checkout", "-q", checkout.Previous}
+		if checkout.Detached {
+			args = []string{"checkout", "-q", "--detach", checkout.Previous}
+		}
+		if _, err := g.runGitCommand(g.repoPath, args...); err != nil {
+			return fmt.Errorf("failed to check out %s: %w", checkout.Previous, err)
+		}
+	}
+	// Otherwise something else was checked out in the meantime, leave it alone.
+
+	g.repoCheckout = nil
+	if checkout.Stash != "" {
+		if err := g.popStash(checkout.Stash); err != nil {
+			return fmt.Errorf("failed to restore your uncommitted changes, they are kept in the stash: %w", err)
+		}
+	}
+	return nil
+}
+
+// RepoCheckoutDirty returns true if the session's branch is checked out in the main repository by
+// CheckOutInRepo and has uncommitted changes there, which ReturnFromRepo saves for the session.
+func (g *GitWorktree) RepoCheckoutDirty() (bool, error) {
+	if g.repoCheckout == nil {
+		return false, nil
+	}
+	if onBranch, err := g.IsBranchCheckedOut(); err != nil || !onBranch {
+		return false, err
+	}
+	status, err := g.runGitCommand(g.repoPath, "status", "--porcelain")
+	if err != nil {
+		return false, fmt.Errorf("failed to check repository status: %w", err)
+	}
+	return len(status) > 0, nil
+}
+
+// popStash applies the stash entry with the given commit to the main checkout and drops it. The
+// entry is kept if it doesn't apply.
+func (g *GitWorktree) popStash(stash string) error {
+	if _, err := g.runGitCommand(g.repoPath, "stash", "apply", "--index", stash); err != nil {
+		return err
+	}
+	// Other stashes may have been pushed in the meantime, find the entry by its commit.
+	entries, err := g.runGitCommand(g.repoPath, "stash", "list", "--format=%H")
+	if err != nil {
+		return err
+	}
+	for idx, entry := range strings.Split(strings.TrimSpace(entries), "\n") {
+		if entry == stash {
+			_, err := g.runGitCommand(g.repoPath, "stash", "drop", "-q", fmt.Sprintf("stash@{%d}", idx))
+			return err
+		}
+	}
+	return nil
+}
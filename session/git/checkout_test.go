@@ -0,0 +1,57 @@
+package git
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckOutInRepoAndReturn(t *testing.T) {
+	repo := setupHunkTestRepo(t).repoPath
+	previous, err := runGit(repo, "branch", "--show-current")
+	require.NoError(t, err)
+	g := &GitWorktree{
+		repoPath:     repo,
+		worktreePath: filepath.Join(t.TempDir(), "checkout"),
+		sessionName:  "checkout",
+		sessionID:    "ghi789",
+		branchName:   "cs/checkout",
+	}
+	require.NoError(t, g.Setup())
+	writeFile(t, g.worktreePath, "agent.txt", "from the agent\n")
+	_, err = g.SaveWIP()
+	require.NoError(t, err)
+
+	require.Error(t, g.CheckOutInRepo(), "the branch is still checked out in the worktree")
+	require.NoError(t, g.Remove())
+	require.NoError(t, g.Prune())
+
+	// Uncommitted changes of the main checkout are stashed.
+	writeFile(t, repo, "file.txt", "mine\n")
+	require.NoError(t, g.CheckOutInRepo())
+	require.NotNil(t, g.GetRepoCheckout())
+	onBranch, err := g.IsBranchCheckedOut()
+	require.NoError(t, err)
+	assert.True(t, onBranch)
+	assert.Equal(t, "from the agent\n", readFile(t, repo, "agent.txt"))
+	assert.NotEqual(t, "mine\n", readFile(t, repo, "file.txt"))
+
+	// Changes made on the branch go back to the session.
+	writeFile(t, repo, "agent.txt", "edited by me\n")
+	require.NoError(t, g.ReturnFromRepo())
+	assert.Nil(t, g.GetRepoCheckout())
+	current, err := runGit(repo, "branch", "--show-current")
+	require.NoError(t, err)
+	assert.Equal(t, previous, current)
+	assert.Equal(t, "mine\n", readFile(t, repo, "file.txt"))
+	assert.NoFileExists(t, filepath.Join(repo, "agent.txt"))
+	stashes, err := runGit(repo, "stash", "list")
+	require.NoError(t, err)
+	assert.Empty(t, stashes)
+
+	require.NoError(t, g.Setup())
+	require.NoError(t, g.RestoreWIP())
+	assert.Equal(t, "edited by me\n", readFile(t, g.worktreePath, "agent.txt"))
+}
@@ -0,0 +1,93 @@
+package git
+
+import (
+	"claude-squad/config"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// SetAgent sets the program running in the session. Commits are attributed to it, see
+// config.CommitConfig.
+func (g *GitWorktree) SetAgent(program string) {
+	g.agent = agentName(program)
+}
+
+// agentName returns the name of the program in an instance's command, e.g. "claude" for
+// "/usr/local/bin/claude --model opus".
+func agentName(program string) string {
+	fields := strings.Fields(program)
+	if len(fields) == 0 {
+		return ""
+	}
+	return filepath.Base(fields[0])
+}
+
+// commit commits the staged changes in the worktree, attributed according to the repository's
+// commit settings.
+func (g *GitWorktree) commit(message string) error {
+	args, err := g.commitArgs(config.LoadConfig().ForRepo(g.repoPath).Commit, message)
+	if err != nil {
+		return err
+	}
+	if _, err := g.runGitCommand(g.worktreePath, args...); err != nil {
+		return fmt.Errorf("failed to commit changes: %w", err)
+	}
+	return nil
+}
+
+// commitArgs returns the git arguments that commit the staged changes with the given settings.
+func (g *GitWorktree) commitArgs(cfg config.CommitConfig, message string) ([]string, error) {
+	if !cfg.NoTrailers {
+		var trailers []string
+		if g.agent != "" {
+			trailers = append(trailers, "Agent: "+g.agent)
+		}
+		if g.sessionName != "" {
+			trailers = append(trailers, "Instance: "+g.sessionName)
+		}
+		if len(trailers) > 0 {
+			message = strings.TrimRight(message, "\n") + "\n\n" + strings.Join(trailers, "\n")
+		}
+	}
+
+	var args []string
+	if cfg.AgentName != "" || cfg.AgentEmail != "" {
+		vars := map[string]string{"agent": g.agent}
+		name, err := config.ExpandTemplate(cfg.AgentName, vars)
+		if err != nil {
+			return nil, fmt.Errorf("invalid agent name: %w", err)
+		}
+		email, err := config.ExpandTemplate(cfg.AgentEmail, vars)
+		if err != nil {
+			return nil, fmt.Errorf("invalid agent email: %w", err)
+		}
+		if name == "" || email == "" {
+			return nil, fmt.Errorf("the agent's commit identity needs both a name and an email")
+		}
+
+		switch cfg.AgentRole {
+		case "", "author":
+			args = append(args, "commit", "--author", fmt.Sprintf("%s <%s>", name, email))
+		case "committer":
+			// The agent commits on behalf of the user, who stays the author.
+			userName, _ := g.runGitCommand(g.worktreePath, "config", "user.name")
+			userEmail, _ := g.runGitCommand(g.worktreePath, "config", "user.email")
+			if strings.TrimSpace(userName) == "" || strings.TrimSpace(userEmail) == "" {
+				return nil, fmt.Errorf("user.name and user.email must be configured to make the agent the committer")
+			}
+			args = append(args, "-c", "user.name="+name, "-c", "user.email="+email, "commit",
+				"--author", fmt.Sprintf("%s <%s>", strings.TrimSpace(userName), strings.TrimSpace(userEmail)))
+		default:
+			return nil, fmt.Errorf("unknown agent role %q, expected author or committer", cfg.AgentRole)
+		}
+	} else {
+		args = append(args, "commit")
+	}
+
+	args = append(args, "-m", message)
+	if !cfg.RunHooks {
+		args = append(args, "--no-verify")
+	}
+	return args, nil
+}
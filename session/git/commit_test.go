@@ -0,0 +1,44 @@
+package git
+
+import (
+	"claude-squad/config"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommitAttribution(t *testing.T) {
+	g := setupHunkTestRepo(t)
+	g.SetAgent("/usr/local/bin/claude --model opus")
+	identity := config.CommitConfig{AgentName: "{{agent}} agent", AgentEmail: "{{agent}}@agents.example.com"}
+
+	commits := 0
+	commit := func(cfg config.CommitConfig) string {
+		commits++
+		writeFile(t, g.worktreePath, "file.txt", strings.Repeat("changed\n", commits))
+		_, err := runGit(g.worktreePath, "add", ".")
+		require.NoError(t, err)
+		args, err := g.commitArgs(cfg, "update")
+		require.NoError(t, err)
+		_, err = runGit(g.worktreePath, args...)
+		require.NoError(t, err)
+		out, err := runGit(g.worktreePath, "log", "-1", "--format=%an <%ae>|%cn <%ce>|%B")
+		require.NoError(t, err)
+		return strings.TrimSpace(out)
+	}
+
+	assert.Equal(t, "Test <test@example.com>|Test <test@example.com>|update\n\nAgent: claude\nInstance: test",
+		commit(config.CommitConfig{}))
+	assert.Equal(t, "claude agent <claude@agents.example.com>|Test <test@example.com>|update",
+		commit(config.CommitConfig{AgentName: identity.AgentName, AgentEmail: identity.AgentEmail, NoTrailers: true}))
+	assert.Equal(t, "Test <test@example.com>|claude agent <claude@agents.example.com>|update",
+		commit(config.CommitConfig{AgentName: identity.AgentName, AgentEmail: identity.AgentEmail, AgentRole: "committer", NoTrailers: true}))
+
+	args, err := g.commitArgs(config.CommitConfig{RunHooks: true}, "update")
+	require.NoError(t, err)
+	assert.NotContains(t, args, "--no-verify")
+	_, err = g.commitArgs(config.CommitConfig{AgentName: "{{agent}}"}, "update")
+	assert.ErrorContains(t, err, "both a name and an email")
+}
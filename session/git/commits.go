@@ -0,0 +1,130 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Commit is a commit on the session's branch.
+type Commit struct {
+	// SHA is the full commit hash.
+	SHA string
+	// Subject is the first line of the commit message.
+	Subject string
+	// Author is the name of the commit's author.
+	Author string
+	// Time is when the commit was authored.
+	Time time.Time
+	// Files is the number of files the commit changes.
+	Files int
+	// Added and Removed are the number of added and removed lines.
+	Added   int
+	Removed int
+}
+
+// ShortSHA returns the abbreviated commit hash.
+func (c Commit) ShortSHA() string {
+	return shortSHA(c.SHA)
+}
+
+// commitsDir returns the directory to inspect the branch in: the checkout while it exists, the main
+// repository once it was removed on pause.
+func (g *GitWorktree) commitsDir() string {
+	if g.HasCheckout() {
+		return g.worktreePath
+	}
+	return g.repoPath
+}
+
+// Commits returns the commits on the branch since the base commit, newest first. This includes the
+// commits made when pausing.
+func (g *GitWorktree) Commits() ([]Commit, error) {
+	if g.baseCommitSHA == "" {
+		return nil, fmt.Errorf("base commit SHA not set")
+	}
+	// Records start with \x1e so the numstat lines following each header can be told apart.
+	output, err := g.runGitCommand(g.commitsDir(), "log", "--numstat",
+		"--format=%x1e%H%x1f%an%x1f%at%x1f%s", g.baseCommitSHA+".."+g.branchName, "--")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commits: %w", err)
+	}
+	return parseCommitLog(output), nil
+}
+
+// parseCommitLog parses the output of git log in the format used by Commits.
+func parseCommitLog(output string) []Commit {
+	var commits []Commit
+	for _, record := range strings.Split(output, "\x1e") {
+		lines := strings.Split(strings.TrimSpace(record), "\n")
+		fields := strings.SplitN(lines[0], "\x1f", 4)
+		if len(fields) != 4 {
+			continue
+		}
+		commit := Commit{SHA: fields[0], Author: fields[1], Subject: fields[3]}
+		if seconds, err := strconv.ParseInt(fields[2], 10, 64); err == nil {
+			commit.Time = time.Unix(seconds, 0)
+		}
+		for _, line := range lines[1:] {
+			parts := strings.SplitN(line, "\t", 3)
+			if len(parts) != 3 {
+				continue
+			}
+			commit.Files++
+			// Binary files show "-" instead of line counts.
+			commit.Added += atoiDefault(parts[0], 0)
+			commit.Removed += atoiDefault(parts[1], 0)
+		}
+		commits = append(commits, commit)
+	}
+	return commits
+}
+
+// CommitDiff returns the changes made by a single commit.
+func (g *GitWorktree) CommitDiff(sha string) (string, error) {
+	output, err := g.runGitCommand(g.commitsDir(), "show", "--format=", "--no-color", sha, "--")
+	if err != nil {
+		return "", fmt.Errorf("failed to show commit %s: %w", sha, err)
+	}
+	return output, nil
+}
+
+// CherryPick applies a commit of the branch to the branch checked out in the main repository. A
+// cherry-pick that doesn't apply cleanly is aborted, leaving the main checkout as it was.
+func (g *GitWorktree) CherryPick(sha string) error {
+	checkedOut, err := g.IsBranchCheckedOut()
+	if err != nil {
+		return err
+	}
+	if checkedOut {
+		return fmt.Errorf("the main checkout is already on %s", g.branchName)
+	}
+	if g.GetIsolation() != IsolationWorktree {
+		if _, err := os.Stat(g.worktreePath); err == nil {
+			// The commit only exists in the checkout, bring it into the main repository first.
+			if err := g.saveBranch(); err != nil {
+				return err
+			}
+		}
+	}
+	if _, err := g.runGitCommand(g.repoPath, "cherry-pick", sha); err != nil {
+		_, _ = g.runGitCommand(g.repoPath, "cherry-pick", "--abort")
+		return fmt.Errorf("failed to cherry-pick %s: %w", shortSHA(sha), err)
+	}
+	return nil
+}
+
+// Revert adds a commit to the branch that undoes the given commit. A revert that doesn't apply
+// cleanly is aborted.
+func (g *GitWorktree) Revert(sha string) error {
+	if _, err := os.Stat(g.worktreePath); err != nil {
+		return fmt.Errorf("cannot revert commits without a checkout: %w", err)
+	}
+	if _, err := g.runGitCommand(g.worktreePath, "revert", "--no-edit", sha); err != nil {
+		_, _ = g.runGitCommand(g.worktreePath, "revert", "--abort")
+		return fmt.Errorf("failed to revert %s: %w", shortSHA(sha), err)
+	}
+	return nil
+}
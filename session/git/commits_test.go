@@ -0,0 +1,57 @@
+package git
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommits(t *testing.T) {
+	repo := setupHunkTestRepo(t).repoPath
+	g := &GitWorktree{
+		repoPath:     repo,
+		worktreePath: filepath.Join(t.TempDir(), "checkout"),
+		sessionName:  "commits",
+		branchName:   "cs/commits",
+	}
+	require.NoError(t, g.Setup())
+
+	commit := func(name, content, message string) {
+		writeFile(t, g.worktreePath, name, content)
+		_, err := runGit(g.worktreePath, "add", ".")
+		require.NoError(t, err)
+		_, err = runGit(g.worktreePath, "commit", "-q", "-m", message)
+		require.NoError(t, err)
+	}
+	commit("a.txt", "one\ntwo\n", "add a")
+	commit("b.txt", "three\n", "add b")
+
+	commits, err := g.Commits()
+	require.NoError(t, err)
+	require.Len(t, commits, 2)
+	assert.Equal(t, "add b", commits[0].Subject)
+	assert.Equal(t, "add a", commits[1].Subject)
+	assert.Equal(t, 1, commits[1].Files)
+	assert.Equal(t, 2, commits[1].Added)
+	assert.Equal(t, "Test", commits[1].Author)
+
+	diff, err := g.CommitDiff(commits[1].SHA)
+	require.NoError(t, err)
+	files := ParseDiff(diff)
+	require.Len(t, files, 1)
+	assert.Equal(t, "a.txt", files[0].Path())
+
+	// Cherry-picking lands the commit on the branch of the main checkout.
+	require.NoError(t, g.CherryPick(commits[0].SHA))
+	assert.Equal(t, "three\n", readFile(t, repo, "b.txt"))
+
+	require.NoError(t, g.Revert(commits[1].SHA))
+	commits, err = g.Commits()
+	require.NoError(t, err)
+	require.Len(t, commits, 3)
+	assert.True(t, strings.HasPrefix(commits[0].Subject, `Revert "add a"`))
+	assert.NoFileExists(t, filepath.Join(g.worktreePath, "a.txt"))
+}
@@ -1,6 +1,11 @@
 package git
 
 import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 )
 
@@ -12,31 +17,96 @@ type DiffStats struct {
 	Added int
 	// Removed is the number of removed lines
 	Removed int
+	// Files holds the per-file line counts from --numstat
+	Files []FileStat
+	// Ranges holds the lines of the baseline touched by each changed file, keyed by path. It is used
+	// to detect instances changing the same code, see session.FindOverlaps.
+	Ranges map[string][]LineRange
+	// Baseline describes what the worktree was diffed against
+	Baseline string
 	// Error holds any error that occurred during diff computation
 	// This allows propagating setup errors (like missing base commit) without breaking the flow
 	Error error
 }
 
 func (d *DiffStats) IsEmpty() bool {
-	return d.Added == 0 && d.Removed == 0 && d.Content == ""
+	return d.Added == 0 && d.Removed == 0 && d.Content == "" && len(d.Files) == 0
 }
 
 // Diff returns the git diff between the worktree and the base branch along with statistics
 func (g *GitWorktree) Diff() *DiffStats {
 	stats := &DiffStats{}
 
-	// -N stages untracked files (intent to add), including them in the diff
-	_, err := g.runGitCommand(g.worktreePath, "add", "-N", ".")
+	base, label, err := g.resolveBaseline()
 	if err != nil {
 		stats.Error = err
 		return stats
 	}
+	stats.Baseline = label
 
-	content, err := g.runGitCommand(g.worktreePath, "--no-pager", "diff", g.GetBaseCommitSHA())
+	// Diffs are computed off the UI goroutine, so Diff must not write the worktree's index while
+	// actions like CommitHunks do. -N stages untracked files (intent to add), including them in the
+	// diff, in a copy of the index instead.
+	index, cleanup, err := g.indexCopy()
 	if err != nil {
 		stats.Error = err
 		return stats
 	}
+	defer cleanup()
+	if _, err := runGitWithIndex(g.worktreePath, index, "add", "-N", "."); err != nil {
+		stats.Error = err
+		return stats
+	}
+
+	g.diffInto(stats, g.worktreePath, index, base)
+	return stats
+}
+
+// indexCopy copies the worktree's index to a temporary file. Call cleanup to remove it.
+func (g *GitWorktree) indexCopy() (index string, cleanup func(), err error) {
+	out, err := g.runGitCommand(g.worktreePath, "rev-parse", "--git-path", "index")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to find the index: %w", err)
+	}
+	path := strings.TrimSpace(out)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(g.worktreePath, path)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return "", nil, fmt.Errorf("failed to read the index: %w", err)
+	}
+
+	dir, err := os.MkdirTemp("", "claudesquad-diff-")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create temporary index: %w", err)
+	}
+	cleanup = func() { os.RemoveAll(dir) }
+	index = filepath.Join(dir, "index")
+	// A repository without commits may have no index yet, git starts from an empty one then.
+	if content != nil {
+		if err := os.WriteFile(index, content, 0644); err != nil {
+			cleanup()
+			return "", nil, fmt.Errorf("failed to write temporary index: %w", err)
+		}
+	}
+	return index, cleanup, nil
+}
+
+// diffInto runs git diff with the given revisions in dir and fills in stats. A non-empty index
+// replaces the checkout's index.
+func (g *GitWorktree) diffInto(stats *DiffStats, dir string, index string, revs ...string) {
+	run := func(args ...string) (string, error) {
+		if index != "" {
+			return runGitWithIndex(dir, index, args...)
+		}
+		return g.runGitCommand(dir, args...)
+	}
+	content, err := run(append([]string{"--no-pager", "diff"}, revs...)...)
+	if err != nil {
+		stats.Error = err
+		return
+	}
 	lines := strings.Split(content, "\n")
 	for _, line := range lines {
 		if strings.HasPrefix(line, "+") && !strings.HasPrefix(line, "+++") {
@@ -47,5 +117,57 @@ func (g *GitWorktree) Diff() *DiffStats {
 	}
 	stats.Content = content
 
-	return stats
+	numstat, err := run(append([]string{"--no-pager", "diff", "--numstat", "-z"}, revs...)...)
+	if err != nil {
+		stats.Error = err
+		return
+	}
+	stats.Files = parseNumstat(numstat)
+}
+
+// Fingerprint returns a digest of the worktree state that changes whenever the diff can change: a new
+// HEAD or baseline, a change in git status, or an edit to any file that is already modified or
+// untracked. It is much cheaper than Diff, so callers use it to skip recomputing
+// unchanged diffs.
+func (g *GitWorktree) Fingerprint() (string, error) {
+	base, label, err := g.resolveBaseline()
+	if err != nil {
+		return "", err
+	}
+	head, err := g.runGitCommand(g.worktreePath, "rev-parse", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	// Like Diff, Fingerprint runs off the UI goroutine, so keep status from refreshing the index.
+	status, err := g.runGitCommand(g.worktreePath, "--no-optional-locks", "status", "--porcelain", "-z", "--untracked-files=all")
+	if err != nil {
+		return "", err
+	}
+
+	h := sha1.New()
+	fmt.Fprintf(h, "%s\n%s\n%s\n", base, label, strings.TrimSpace(head))
+	entries := strings.Split(status, "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 4 {
+			continue
+		}
+		code, path := entry[:2], entry[3:]
+		// CommitHunks stages untracked files with add -N, which turns "??" into " A". Treat both the
+		// same so that alone doesn't change the fingerprint.
+		if code == "??" {
+			code = " A"
+		}
+		fmt.Fprintf(h, "%s %s", code, path)
+		// The status of a file that is modified again stays the same, so also hash its size and mtime.
+		if info, err := os.Stat(filepath.Join(g.worktreePath, path)); err == nil {
+			fmt.Fprintf(h, " %d %d", info.Size(), info.ModTime().UnixNano())
+		}
+		h.Write([]byte{0})
+		// Renames and copies are followed by the original path.
+		if code[0] == 'R' || code[0] == 'C' {
+			i++
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
 }
@@ -0,0 +1,154 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DiffBaseline selects what the worktree is diffed against.
+type DiffBaseline int
+
+const (
+	// BaselineBaseCommit diffs against the commit the worktree was created from.
+	BaselineBaseCommit DiffBaseline = iota
+	// BaselineBaseBranch diffs against the current tip of the branch the worktree was created from.
+	BaselineBaseBranch
+	// BaselineRemote diffs against the last pushed state of the branch.
+	BaselineRemote
+	// BaselineLastCommit diffs against the parent of HEAD, showing the last commit and uncommitted
+	// changes.
+	BaselineLastCommit
+	// BaselineUncommitted diffs against HEAD, showing only uncommitted changes.
+	BaselineUncommitted
+)
+
+// BaselineError is returned when the selected diff baseline can't be resolved, e.g. because the
+// branch was never pushed.
+type BaselineError struct {
+	err error
+}
+
+func (e *BaselineError) Error() string {
+	return e.err.Error()
+}
+
+func (e *BaselineError) Unwrap() error {
+	return e.err
+}
+
+// Next returns the baseline after b, wrapping around.
+func (b DiffBaseline) Next() DiffBaseline {
+	return (b + 1) % (BaselineUncommitted + 1)
+}
+
+// SetDiffBaseline changes what Diff compares the worktree against. Diffs computed in the background
+// work on a Copy, so they keep the baseline they were started with.
+func (g *GitWorktree) SetDiffBaseline(baseline DiffBaseline) {
+	g.baseline = baseline
+}
+
+// GetDiffBaseline returns what Diff compares the worktree against.
+func (g *GitWorktree) GetDiffBaseline() DiffBaseline {
+	return g.baseline
+}
+
+// DescribeDiffBaseline returns a description of the commit the worktree is currently diffed
+// against, e.g. "tip of main 1a2b3c4". It is what RevertFile restores files to.
+func (g *GitWorktree) DescribeDiffBaseline() (string, error) {
+	_, label, err := g.resolveBaseline()
+	return label, err
+}
+
+// GetBaseBranch returns the branch the worktree was created from, or "" if it is unknown.
+func (g *GitWorktree) GetBaseBranch() string {
+	return g.baseBranch
+}
+
+// resolveBaseline returns the commit the worktree is diffed against along with a description for
+// display.
+func (g *GitWorktree) resolveBaseline() (sha string, label string, err error) {
+	base := g.GetBaseCommitSHA()
+	if base == "" {
+		return "", "", fmt.Errorf("base commit SHA not set")
+	}
+
+	switch g.baseline {
+	case BaselineBaseBranch:
+		branch := g.baseBranch
+		if branch == "" {
+			// Instances created before the base branch was recorded fall back to the branch checked
+			// out in the main repository.
+			out, err := g.runGitCommand(g.repoPath, "symbolic-ref", "--short", "-q", "HEAD")
+			if err != nil {
+				return "", "", &BaselineError{fmt.Errorf("failed to find the base branch: %w", err)}
+			}
+			branch = strings.TrimSpace(out)
+		}
+		sha, err := g.revParse("refs/heads/" + branch)
+		if err != nil {
+			return "", "", &BaselineError{fmt.Errorf("base branch %s no longer exists", branch)}
+		}
+		return sha, "tip of " + branch + " " + shortSHA(sha), nil
+	case BaselineRemote:
+		remote := g.branchName + "@{upstream}"
+		name, err := g.runGitCommand(g.commitsDir(), "rev-parse", "--abbrev-ref", "--symbolic-full-name", remote)
+		if err == nil {
+			remote = strings.TrimSpace(name)
+		} else {
+			remote = "origin/" + g.branchName
+		}
+		sha, err := g.revParse("refs/remotes/" + remote)
+		if err != nil {
+			return "", "", &BaselineError{fmt.Errorf("branch %s has not been pushed yet", g.branchName)}
+		}
+		return sha, "last push " + remote + " " + shortSHA(sha), nil
+	case BaselineLastCommit:
+		head, err := g.revParse(g.headRev())
+		if err != nil {
+			return "", "", err
+		}
+		if head == base {
+			// Nothing was committed on top of the base commit yet.
+			return base, "last commit (none yet) " + shortSHA(base), nil
+		}
+		sha, err := g.revParse(g.headRev() + "^")
+		if err != nil {
+			return "", "", err
+		}
+		return sha, "last commit " + shortSHA(head), nil
+	case BaselineUncommitted:
+		sha, err := g.revParse(g.headRev())
+		if err != nil {
+			return "", "", err
+		}
+		return sha, "uncommitted only", nil
+	default:
+		return base, "base commit " + shortSHA(base), nil
+	}
+}
+
+// headRev returns the revision of the branch tip: HEAD of the checkout, or the branch itself once the
+// checkout was removed on pause.
+func (g *GitWorktree) headRev() string {
+	if g.commitsDir() == g.worktreePath {
+		return "HEAD"
+	}
+	return "refs/heads/" + g.branchName
+}
+
+// revParse resolves a revision to a commit SHA in the worktree, or in the main repository while
+// there is no worktree.
+func (g *GitWorktree) revParse(rev string) (string, error) {
+	out, err := g.runGitCommand(g.commitsDir(), "rev-parse", "--verify", "-q", rev+"^{commit}")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
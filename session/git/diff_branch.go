@@ -0,0 +1,107 @@
+package git
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// HasCheckout returns true if the worktree currently exists on disk. It doesn't while the session is
+// paused.
+func (g *GitWorktree) HasCheckout() bool {
+	_, err := os.Stat(g.worktreePath)
+	return err == nil
+}
+
+// pausedTip returns the state of a paused session for diffing: the branch tip with the uncommitted
+// changes saved on pause applied, like Resume would restore them.
+func (g *GitWorktree) pausedTip() (string, error) {
+	branch, wip, err := g.pausedRevs()
+	if err != nil || wip == "" {
+		return branch, err
+	}
+	parent, err := g.revParse(wip + "^")
+	if err == nil && parent == branch {
+		return wip, nil
+	}
+
+	// The branch moved since the changes were saved, apply them to the new tip in a temporary index.
+	dir, err := os.MkdirTemp("", "claudesquad-diff-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary index: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	patch, err := g.runGitCommand(g.repoPath, "diff", "--binary", wip+"^", wip)
+	if err != nil {
+		return "", fmt.Errorf("failed to read uncommitted changes from %s: %w", g.WIPRef(), err)
+	}
+	patchFile := filepath.Join(dir, "wip.patch")
+	if err := os.WriteFile(patchFile, []byte(patch), 0644); err != nil {
+		return "", fmt.Errorf("failed to write patch: %w", err)
+	}
+	index := filepath.Join(dir, "index")
+	if _, err := runGitWithIndex(g.repoPath, index, "read-tree", branch); err != nil {
+		return "", fmt.Errorf("failed to prepare temporary index: %w", err)
+	}
+	if _, err := runGitWithIndex(g.repoPath, index, "apply", "--cached", "--binary", patchFile); err != nil {
+		// Resume keeps the changes in the ref when they don't apply, so they aren't part of the diff.
+		return branch, nil
+	}
+	tree, err := runGitWithIndex(g.repoPath, index, "write-tree")
+	if err != nil {
+		return "", fmt.Errorf("failed to write tree: %w", err)
+	}
+	return strings.TrimSpace(tree), nil
+}
+
+// pausedRevs returns the branch tip and the commit holding the uncommitted changes saved on pause,
+// or "" if there are none.
+func (g *GitWorktree) pausedRevs() (branch string, wip string, err error) {
+	branch, err = g.revParse("refs/heads/" + g.branchName)
+	if err != nil {
+		return "", "", fmt.Errorf("branch %s no longer exists", g.branchName)
+	}
+	wip, _ = g.revParse(g.WIPRef())
+	return branch, wip, nil
+}
+
+// BranchDiff is Diff for a session without a checkout, e.g. because it is paused. It diffs the
+// branch, including the uncommitted changes saved on pause, against the baseline in the main
+// repository.
+func (g *GitWorktree) BranchDiff() *DiffStats {
+	stats := &DiffStats{}
+
+	base, label, err := g.resolveBaseline()
+	if err != nil {
+		stats.Error = err
+		return stats
+	}
+	stats.Baseline = label
+
+	tip, err := g.pausedTip()
+	if err != nil {
+		stats.Error = err
+		return stats
+	}
+	g.diffInto(stats, g.repoPath, "", base, tip)
+	return stats
+}
+
+// BranchFingerprint is Fingerprint for a session without a checkout. All inputs of the diff are
+// commits, so their hashes identify it.
+func (g *GitWorktree) BranchFingerprint() (string, error) {
+	base, label, err := g.resolveBaseline()
+	if err != nil {
+		return "", err
+	}
+	branch, wip, err := g.pausedRevs()
+	if err != nil {
+		return "", err
+	}
+	h := sha1.New()
+	fmt.Fprintf(h, "%s\n%s\n%s\n%s\n", base, label, branch, wip)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,59 @@
+package git
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBranchDiffWithoutCheckout(t *testing.T) {
+	repo := setupHunkTestRepo(t).repoPath
+	g := &GitWorktree{
+		repoPath:     repo,
+		worktreePath: filepath.Join(t.TempDir(), "checkout"),
+		sessionName:  "paused",
+		sessionID:    "def456",
+		branchName:   "cs/paused",
+	}
+	require.NoError(t, g.Setup())
+	require.NotEmpty(t, g.GetBaseCommitSHA())
+
+	writeFile(t, g.worktreePath, "new.txt", "one\ntwo\n")
+	running := g.Diff()
+	require.NoError(t, running.Error)
+	_, err := g.SaveWIP()
+	require.NoError(t, err)
+	require.NoError(t, g.Remove())
+	require.NoError(t, g.Prune())
+	require.False(t, g.HasCheckout())
+
+	// The uncommitted changes saved on pause still count.
+	paused := g.BranchDiff()
+	require.NoError(t, paused.Error)
+	assert.Equal(t, running.Added, paused.Added)
+	assert.Equal(t, running.Removed, paused.Removed)
+	before, err := g.BranchFingerprint()
+	require.NoError(t, err)
+
+	// Commit to the branch from the main checkout.
+	_, err = runGit(repo, "checkout", "-q", "cs/paused")
+	require.NoError(t, err)
+	writeFile(t, repo, "other.txt", "three\n")
+	_, err = runGit(repo, "add", "other.txt")
+	require.NoError(t, err)
+	_, err = runGit(repo, "commit", "-q", "-m", "from the main checkout")
+	require.NoError(t, err)
+	_, err = runGit(repo, "checkout", "-q", "-")
+	require.NoError(t, err)
+
+	after, err := g.BranchFingerprint()
+	require.NoError(t, err)
+	assert.NotEqual(t, before, after)
+	paused = g.BranchDiff()
+	require.NoError(t, paused.Error)
+	assert.Equal(t, running.Added+1, paused.Added)
+	assert.Contains(t, paused.Content, "other.txt")
+	assert.Contains(t, paused.Content, "new.txt")
+}
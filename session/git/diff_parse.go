@@ -0,0 +1,307 @@
+package git
+
+import (
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// FileStat holds the per-file line counts reported by `git diff --numstat`.
+type FileStat struct {
+	// Path is the path of the file after the change.
+	Path string
+	// Added is the number of added lines
+	Added int
+	// Removed is the number of removed lines
+	Removed int
+	// Binary is true if git reported the file as binary (no line counts)
+	Binary bool
+}
+
+// FileDiff is the part of a unified diff that applies to a single file.
+type FileDiff struct {
+	// OldPath is the path before the change. It is /dev/null for added files.
+	OldPath string
+	// NewPath is the path after the change. It is /dev/null for deleted files.
+	NewPath string
+	// Header holds the raw header lines (diff --git, index, mode changes, ---/+++).
+	Header []string
+	// Hunks are the hunks of the diff, in order.
+	Hunks []Hunk
+	// Added is the number of added lines
+	Added int
+	// Removed is the number of removed lines
+	Removed int
+	// Binary is true if git reported the file as binary.
+	Binary bool
+}
+
+// Hunk is a single @@ section of a file diff.
+type Hunk struct {
+	// Header is the raw @@ line.
+	Header   string
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	// Lines holds the hunk body, each line still carrying its ' ', '+', '-' or '\' prefix.
+	Lines []string
+}
+
+// Path returns the path used to identify the file in the UI.
+func (f *FileDiff) Path() string {
+	if f.NewPath == "" || f.NewPath == "/dev/null" {
+		return f.OldPath
+	}
+	return f.NewPath
+}
+
+// LineRange is a range of lines [Start, End) in the old version of a file.
+type LineRange struct {
+	Start int
+	End   int
+}
+
+// Overlaps reports whether the two ranges share at least one line.
+func (r LineRange) Overlaps(other LineRange) bool {
+	return r.Start < other.End && other.Start < r.End
+}
+
+// ChangedRanges returns the lines of the old versions touched by each file's hunks, keyed by path.
+// Files without hunks, e.g. binary files, map to no ranges.
+func ChangedRanges(files []FileDiff) map[string][]LineRange {
+	ranges := make(map[string][]LineRange, len(files))
+	for _, file := range files {
+		fileRanges := []LineRange{}
+		for _, hunk := range file.Hunks {
+			// Pure insertions don't cover any old line, they go after OldStart.
+			fileRanges = append(fileRanges, LineRange{Start: hunk.OldStart, End: hunk.OldStart + max(hunk.OldLines, 1)})
+		}
+		ranges[file.Path()] = fileRanges
+	}
+	return ranges
+}
+
+var hunkHeaderRegex = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// ParseDiff splits unified diff output from git into per-file diffs.
+func ParseDiff(content string) []FileDiff {
+	var files []FileDiff
+	var file *FileDiff
+	var hunk *Hunk
+
+	flushHunk := func() {
+		if file != nil && hunk != nil {
+			file.Hunks = append(file.Hunks, *hunk)
+		}
+		hunk = nil
+	}
+	flushFile := func() {
+		flushHunk()
+		if file != nil {
+			files = append(files, *file)
+		}
+		file = nil
+	}
+
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	for _, line := range lines {
+		if strings.HasPrefix(line, "diff --git ") {
+			flushFile()
+			file = &FileDiff{Header: []string{line}}
+			file.OldPath, file.NewPath = parseDiffGitPaths(strings.TrimPrefix(line, "diff --git "))
+			continue
+		}
+		if file == nil {
+			// Anything before the first file header (e.g. warnings) is ignored.
+			continue
+		}
+
+		if m := hunkHeaderRegex.FindStringSubmatch(line); m != nil {
+			flushHunk()
+			hunk = &Hunk{
+				Header:   line,
+				OldStart: atoiDefault(m[1], 0),
+				OldLines: atoiDefault(m[2], 1),
+				NewStart: atoiDefault(m[3], 0),
+				NewLines: atoiDefault(m[4], 1),
+			}
+			continue
+		}
+
+		if hunk == nil {
+			file.Header = append(file.Header, line)
+			switch {
+			case strings.HasPrefix(line, "--- "):
+				file.OldPath = parseHeaderPath(strings.TrimPrefix(line, "--- "))
+			case strings.HasPrefix(line, "+++ "):
+				file.NewPath = parseHeaderPath(strings.TrimPrefix(line, "+++ "))
+			case strings.HasPrefix(line, "rename from "):
+				file.OldPath = unquotePath(strings.TrimPrefix(line, "rename from "))
+			case strings.HasPrefix(line, "rename to "):
+				file.NewPath = unquotePath(strings.TrimPrefix(line, "rename to "))
+			case strings.HasPrefix(line, "new file mode"):
+				file.OldPath = "/dev/null"
+			case strings.HasPrefix(line, "deleted file mode"):
+				file.NewPath = "/dev/null"
+			case strings.HasPrefix(line, "Binary files ") || line == "GIT binary patch":
+				file.Binary = true
+			}
+			continue
+		}
+
+		if line == "" {
+			// Some tools strip the trailing space of empty context lines.
+			line = " "
+		}
+		switch line[0] {
+		case '+':
+			file.Added++
+		case '-':
+			file.Removed++
+		case ' ', '\\':
+		default:
+			// Not part of the hunk body; treat it as trailing noise.
+			continue
+		}
+		hunk.Lines = append(hunk.Lines, line)
+	}
+	flushFile()
+
+	return files
+}
+
+// parseDiffGitPaths extracts both paths from the remainder of a "diff --git" line. Paths containing
+// spaces are ambiguous there, so this assumes both sides are the same length unless quoted. The
+// ---/+++ and rename lines, when present, override the result.
+func parseDiffGitPaths(s string) (string, string) {
+	if strings.HasPrefix(s, `"`) {
+		if end := strings.Index(s[1:], `" `); end >= 0 {
+			return unquotePath(s[:end+2]), unquotePath(s[end+3:])
+		}
+	}
+	if len(s) >= 5 && (len(s)-1)%2 == 0 {
+		half := (len(s) - 1) / 2
+		oldPath, newPath := strings.TrimPrefix(s[:half], "a/"), strings.TrimPrefix(s[half+1:], "b/")
+		if s[half] == ' ' && oldPath == newPath {
+			return oldPath, newPath
+		}
+	}
+	if i := strings.Index(s, " b/"); i >= 0 {
+		return strings.TrimPrefix(s[:i], "a/"), s[i+3:]
+	}
+	return s, s
+}
+
+// parseHeaderPath parses the path from a ---/+++ line, dropping the a/ or b/ prefix.
+func parseHeaderPath(s string) string {
+	s = unquotePath(strings.TrimRight(s, "\t"))
+	if s == "/dev/null" {
+		return s
+	}
+	if strings.HasPrefix(s, "a/") || strings.HasPrefix(s, "b/") {
+		return s[2:]
+	}
+	return s
+}
+
+// unquotePath undoes git's C-style quoting of unusual paths.
+func unquotePath(s string) string {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		if unquoted, err := strconv.Unquote(s); err == nil {
+			return unquoted
+		}
+	}
+	return s
+}
+
+func atoiDefault(s string, def int) int {
+	if s == "" {
+		return def
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return def
+	}
+	return n
+}
+
+// parseNumstat parses the output of `git diff --numstat -z`.
+func parseNumstat(output string) []FileStat {
+	var stats []FileStat
+	fields := strings.Split(output, "\x00")
+	for i := 0; i < len(fields); i++ {
+		record := fields[i]
+		if record == "" {
+			continue
+		}
+		parts := strings.SplitN(record, "\t", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		stat := FileStat{Path: parts[2]}
+		if parts[0] == "-" && parts[1] == "-" {
+			stat.Binary = true
+		} else {
+			stat.Added = atoiDefault(parts[0], 0)
+			stat.Removed = atoiDefault(parts[1], 0)
+		}
+		// Renames leave the path empty and are followed by the old and new paths.
+		if stat.Path == "" && i+2 < len(fields) {
+			stat.Path = fields[i+2]
+			i += 2
+		}
+		stats = append(stats, stat)
+	}
+	return stats
+}
+
+var generatedFileNames = map[string]bool{
+	"go.sum":              true,
+	"package-lock.json":   true,
+	"npm-shrinkwrap.json": true,
+	"yarn.lock":           true,
+	"pnpm-lock.yaml":      true,
+	"bun.lockb":           true,
+	"Cargo.lock":          true,
+	"Gemfile.lock":        true,
+	"poetry.lock":         true,
+	"Pipfile.lock":        true,
+	"composer.lock":       true,
+	"mix.lock":            true,
+	"flake.lock":          true,
+}
+
+var generatedFileSuffixes = []string{
+	".min.js",
+	".min.css",
+	".map",
+	".pb.go",
+	".pb.gw.go",
+	"_generated.go",
+	".gen.go",
+	".generated.ts",
+	"_pb2.py",
+	".snap",
+}
+
+// IsGeneratedFile reports whether path looks like a lockfile or generated source that is usually
+// not worth reviewing line by line.
+func IsGeneratedFile(path string) bool {
+	base := filepath.Base(path)
+	if generatedFileNames[base] {
+		return true
+	}
+	for _, suffix := range generatedFileSuffixes {
+		if strings.HasSuffix(base, suffix) {
+			return true
+		}
+	}
+	for _, dir := range strings.Split(filepath.ToSlash(filepath.Dir(path)), "/") {
+		if dir == "vendor" || dir == "node_modules" {
+			return true
+		}
+	}
+	return false
+}
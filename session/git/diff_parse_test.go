@@ -0,0 +1,102 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sampleDiff = `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -1,3 +1,4 @@
+ package main
++import "fmt"
+ func main() {
+-	println("hi")
+@@ -10 +11,2 @@ func other() {
++	fmt.Println("a")
++	fmt.Println("b")
+diff --git a/new file.txt b/new file.txt
+new file mode 100644
+index 0000000..3333333
+--- /dev/null
++++ b/new file.txt
+@@ -0,0 +1 @@
++hello
+\ No newline at end of file
+diff --git a/old.txt b/renamed.txt
+similarity index 90%
+rename from old.txt
+rename to renamed.txt
+diff --git a/logo.png b/logo.png
+index 4444444..5555555 100644
+Binary files a/logo.png and b/logo.png differ
+`
+
+func TestParseDiff(t *testing.T) {
+	files := ParseDiff(sampleDiff)
+	require.Len(t, files, 4)
+
+	main := files[0]
+	assert.Equal(t, "main.go", main.Path())
+	require.Len(t, main.Hunks, 2)
+	assert.Equal(t, 1, main.Hunks[0].OldStart)
+	assert.Equal(t, 3, main.Hunks[0].OldLines)
+	assert.Equal(t, 4, main.Hunks[0].NewLines)
+	assert.Equal(t, []string{" package main", `+import "fmt"`, " func main() {", `-	println("hi")`}, main.Hunks[0].Lines)
+	// Omitted counts default to 1
+	assert.Equal(t, 1, main.Hunks[1].OldLines)
+	assert.Equal(t, 11, main.Hunks[1].NewStart)
+	assert.Equal(t, 3, main.Added)
+	assert.Equal(t, 1, main.Removed)
+
+	added := files[1]
+	assert.Equal(t, "/dev/null", added.OldPath)
+	assert.Equal(t, "new file.txt", added.Path())
+	require.Len(t, added.Hunks, 1)
+	assert.Equal(t, `\ No newline at end of file`, added.Hunks[0].Lines[1])
+
+	renamed := files[2]
+	assert.Equal(t, "old.txt", renamed.OldPath)
+	assert.Equal(t, "renamed.txt", renamed.Path())
+	assert.Empty(t, renamed.Hunks)
+
+	assert.True(t, files[3].Binary)
+	assert.Equal(t, "logo.png", files[3].Path())
+}
+
+func TestParseNumstat(t *testing.T) {
+	output := "3\t1\tmain.go\x00-\t-\tlogo.png\x002\t0\t\x00old.txt\x00renamed.txt\x00"
+	stats := parseNumstat(output)
+	assert.Equal(t, []FileStat{
+		{Path: "main.go", Added: 3, Removed: 1},
+		{Path: "logo.png", Binary: true},
+		{Path: "renamed.txt", Added: 2},
+	}, stats)
+}
+
+func TestIsGeneratedFile(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{"go.sum", true},
+		{"web/package-lock.json", true},
+		{"api/service.pb.go", true},
+		{"static/app.min.js", true},
+		{"vendor/github.com/foo/bar.go", true},
+		{"main.go", false},
+		{"docs/lockfile.md", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := IsGeneratedFile(tt.path); got != tt.expected {
+				t.Errorf("IsGeneratedFile(%q) = %v, want %v", tt.path, got, tt.expected)
+			}
+		})
+	}
+}
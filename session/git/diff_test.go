@@ -0,0 +1,101 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFingerprint(t *testing.T) {
+	g := setupHunkTestRepo(t)
+	fingerprint := func() string {
+		fp, err := g.Fingerprint()
+		require.NoError(t, err)
+		return fp
+	}
+
+	clean := fingerprint()
+	assert.Equal(t, clean, fingerprint())
+
+	editTwoHunks(t, g)
+	edited := fingerprint()
+	assert.NotEqual(t, clean, edited)
+
+	// Editing an already modified file keeps its status but must still change the fingerprint.
+	writeFile(t, g.worktreePath, "file.txt", readFile(t, g.worktreePath, "file.txt")+"more\n")
+	future := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(filepath.Join(g.worktreePath, "file.txt"), future, future))
+	editedAgain := fingerprint()
+	assert.NotEqual(t, edited, editedAgain)
+
+	// Committing hunks stages untracked files with add -N, which must not change the fingerprint.
+	writeFile(t, g.worktreePath, "new.txt", "new\n")
+	untracked := fingerprint()
+	_, err := g.uncommittedDiff()
+	require.NoError(t, err)
+	assert.Equal(t, untracked, fingerprint())
+}
+
+func TestDiffLeavesIndexAlone(t *testing.T) {
+	g := setupHunkTestRepo(t)
+	editTwoHunks(t, g)
+	writeFile(t, g.worktreePath, "new.txt", "new\n")
+
+	stats := g.Diff()
+	require.NoError(t, stats.Error)
+	assert.Contains(t, stats.Content, "+new")
+	assert.Len(t, stats.Files, 2)
+
+	// Diff runs concurrently with actions writing the index, so it must not stage anything itself.
+	status, err := g.runGitCommand(g.worktreePath, "status", "--porcelain", "--", "new.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "?? new.txt\n", status)
+}
+
+func TestDiffBaselines(t *testing.T) {
+	g := setupHunkTestRepo(t)
+	editTwoHunks(t, g)
+	_, err := g.runGitCommand(g.worktreePath, "commit", "-q", "-am", "edit")
+	require.NoError(t, err)
+	writeFile(t, g.worktreePath, "new.txt", "new\n")
+
+	stats := g.Diff()
+	require.NoError(t, stats.Error)
+	assert.Contains(t, stats.Content, "+line A")
+	assert.Contains(t, stats.Baseline, "base commit")
+
+	g.SetDiffBaseline(BaselineUncommitted)
+	stats = g.Diff()
+	require.NoError(t, stats.Error)
+	assert.NotContains(t, stats.Content, "+line A")
+	assert.Contains(t, stats.Content, "+new")
+
+	g.SetDiffBaseline(BaselineLastCommit)
+	stats = g.Diff()
+	require.NoError(t, stats.Error)
+	assert.Contains(t, stats.Content, "+line A")
+
+	g.SetDiffBaseline(BaselineRemote)
+	stats = g.Diff()
+	var baselineErr *BaselineError
+	assert.ErrorAs(t, stats.Error, &baselineErr)
+}
+
+func TestCopyKeepsDiffBaseline(t *testing.T) {
+	g := setupHunkTestRepo(t)
+	c := g.Copy()
+
+	g.SetDiffBaseline(BaselineUncommitted)
+	assert.Equal(t, BaselineBaseCommit, c.GetDiffBaseline())
+
+	label, err := c.DescribeDiffBaseline()
+	require.NoError(t, err)
+	assert.Contains(t, label, "base commit")
+	label, err = g.DescribeDiffBaseline()
+	require.NoError(t, err)
+	assert.Equal(t, "uncommitted only", label)
+}
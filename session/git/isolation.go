@@ -0,0 +1,250 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+)
+
+// Isolation is how an instance's checkout is separated from the main repository.
+type Isolation string
+
+const (
+	// IsolationWorktree checks out the branch in a linked git worktree. This is the default.
+	IsolationWorktree Isolation = "worktree"
+	// IsolationClone checks out the branch in a local clone that borrows the repository's objects
+	// (git clone --reference), for tooling that doesn't work in linked worktrees.
+	IsolationClone Isolation = "clone"
+	// IsolationCopy checks out the branch in a copy of the whole repository directory, including
+	// ignored files like build caches. The copy uses reflinks where the file system supports them.
+	IsolationCopy Isolation = "copy"
+)
+
+// ParseIsolation validates an isolation name from the configuration. Empty selects the default.
+func ParseIsolation(name string) (Isolation, error) {
+	switch isolation := Isolation(name); isolation {
+	case "":
+		return IsolationWorktree, nil
+	case IsolationWorktree, IsolationClone, IsolationCopy:
+		return isolation, nil
+	}
+	return "", fmt.Errorf("unknown isolation %q, expected worktree, clone or copy", name)
+}
+
+// isolator creates and removes the checkout of a GitWorktree. All of them leave the branch in the main
+// repository when the checkout is removed, so pausing and resuming work the same for every isolation.
+type isolator interface {
+	// create checks out a new branch at the given commit.
+	create(g *GitWorktree, commit string) error
+	// restore checks out the existing branch of the main repository.
+	restore(g *GitWorktree) error
+	// remove deletes the checkout. If keepBranch is set, the branch is saved to the main repository
+	// first.
+	remove(g *GitWorktree, keepBranch bool) error
+}
+
+func (g *GitWorktree) isolator() isolator {
+	switch g.isolation {
+	case IsolationClone:
+		return cloneIsolator{}
+	case IsolationCopy:
+		return copyIsolator{}
+	}
+	return worktreeIsolator{}
+}
+
+// SetIsolation sets how the checkout is separated from the main repository. It takes effect the next
+// time the checkout is set up.
+func (g *GitWorktree) SetIsolation(isolation Isolation) {
+	g.isolation = isolation
+}
+
+// GetIsolation returns how the checkout is separated from the main repository.
+func (g *GitWorktree) GetIsolation() Isolation {
+	if g.isolation == "" {
+		return IsolationWorktree
+	}
+	return g.isolation
+}
+
+type worktreeIsolator struct{}
+
+func (worktreeIsolator) create(g *GitWorktree, commit string) error {
+	// Clean up any existing worktree first
+	_, _ = g.runGitCommand(g.repoPath, "worktree", "remove", "-f", g.worktreePath) // Ignore error if worktree doesn't exist
+
+	// Create a new worktree from the commit. Otherwise, we'll inherit uncommitted changes from the
+	// previous worktree. This way, we can start the worktree with a clean slate.
+	if err := g.addWorktree("-b", g.branchName, g.worktreePath, commit); err != nil {
+		return fmt.Errorf("failed to create worktree from commit %s: %w", commit, err)
+	}
+	return nil
+}
+
+func (worktreeIsolator) restore(g *GitWorktree) error {
+	// Clean up any existing worktree first
+	_, _ = g.runGitCommand(g.repoPath, "worktree", "remove", "-f", g.worktreePath) // Ignore error if worktree doesn't exist
+
+	if err := g.addWorktree(g.worktreePath, g.branchName); err != nil {
+		return fmt.Errorf("failed to create worktree from branch %s: %w", g.branchName, err)
+	}
+	return nil
+}
+
+func (worktreeIsolator) remove(g *GitWorktree, keepBranch bool) error {
+	// The branch already lives in the main repository.
+	if _, err := g.runGitCommand(g.repoPath, "worktree", "remove", "-f", g.worktreePath); err != nil {
+		return fmt.Errorf("failed to remove worktree: %w", err)
+	}
+	return nil
+}
+
+type cloneIsolator struct{}
+
+// cloneRemote is the name of the remote pointing at the main repository in clones. The clone
+// includes the main repository's configuration, so origin is the same remote as in the main
+// repository.
+const cloneRemote = "source"
+
+func (c cloneIsolator) create(g *GitWorktree, commit string) error {
+	if err := c.clone(g); err != nil {
+		return err
+	}
+	return g.checkout("--no-track", "-b", g.branchName, commit)
+}
+
+func (c cloneIsolator) restore(g *GitWorktree) error {
+	if err := c.clone(g); err != nil {
+		return err
+	}
+	return g.checkout("--no-track", "-b", g.branchName, cloneRemote+"/"+g.branchName)
+}
+
+func (cloneIsolator) clone(g *GitWorktree) error {
+	if err := os.RemoveAll(g.worktreePath); err != nil {
+		return fmt.Errorf("failed to clean up %s: %w", g.worktreePath, err)
+	}
+	if _, err := g.runGitCommand(g.repoPath, "clone", "-q", "--no-checkout", "--reference", g.repoPath,
+		"--origin", cloneRemote, g.repoPath, g.worktreePath); err != nil {
+		return fmt.Errorf("failed to clone repository: %w", err)
+	}
+	// Share the identity, hooks and remotes of the main repository, like a worktree would.
+	if _, err := g.runGitCommand(g.worktreePath, "config", "include.path", filepath.Join(g.repoPath, ".git", "config")); err != nil {
+		return fmt.Errorf("failed to configure clone: %w", err)
+	}
+	return nil
+}
+
+func (cloneIsolator) remove(g *GitWorktree, keepBranch bool) error {
+	if keepBranch {
+		if err := g.saveBranch(); err != nil {
+			return err
+		}
+	}
+	if err := os.RemoveAll(g.worktreePath); err != nil {
+		return fmt.Errorf("failed to remove clone: %w", err)
+	}
+	return nil
+}
+
+type copyIsolator struct{}
+
+func (c copyIsolator) create(g *GitWorktree, commit string) error {
+	if err := c.copy(g); err != nil {
+		return err
+	}
+	return g.checkoutClean("-b", g.branchName, commit)
+}
+
+func (c copyIsolator) restore(g *GitWorktree) error {
+	if err := c.copy(g); err != nil {
+		return err
+	}
+	return g.checkoutClean(g.branchName)
+}
+
+func (copyIsolator) copy(g *GitWorktree) error {
+	if info, err := os.Stat(filepath.Join(g.repoPath, ".git")); err != nil || !info.IsDir() {
+		return fmt.Errorf("copy isolation requires %s to be a main checkout, not a linked worktree", g.repoPath)
+	}
+	if err := os.RemoveAll(g.worktreePath); err != nil {
+		return fmt.Errorf("failed to clean up %s: %w", g.worktreePath, err)
+	}
+	if err := copyTree(g.repoPath, g.worktreePath); err != nil {
+		return err
+	}
+	// The copy must not claim the main repository's linked worktrees, or their branches could not be
+	// checked out in it.
+	if err := os.RemoveAll(filepath.Join(g.worktreePath, ".git", "worktrees")); err != nil {
+		return fmt.Errorf("failed to clean up copied worktrees: %w", err)
+	}
+	return nil
+}
+
+func (copyIsolator) remove(g *GitWorktree, keepBranch bool) error {
+	if keepBranch {
+		if err := g.saveBranch(); err != nil {
+			return err
+		}
+	}
+	if err := os.RemoveAll(g.worktreePath); err != nil {
+		return fmt.Errorf("failed to remove copy: %w", err)
+	}
+	return nil
+}
+
+// copyTree copies a directory, cloning file contents with reflinks where supported and falling back
+// to a regular copy otherwise.
+func copyTree(src, dst string) error {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "darwin" {
+		// -c uses clonefile(2), which only works on APFS.
+		cmd = exec.Command("cp", "-c", "-R", src, dst)
+		if err := cmd.Run(); err == nil {
+			return nil
+		}
+		_ = os.RemoveAll(dst)
+		cmd = exec.Command("cp", "-R", src, dst)
+	} else {
+		cmd = exec.Command("cp", "-a", "--reflink=auto", src, dst)
+	}
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to copy repository: %s (%w)", output, err)
+	}
+	return nil
+}
+
+// checkout runs git checkout in the checkout directory, applying the sparse-checkout patterns first.
+func (g *GitWorktree) checkout(args ...string) error {
+	if err := g.applySparseCheckout(); err != nil {
+		return err
+	}
+	if _, err := g.runGitCommand(g.worktreePath, append([]string{"checkout", "-q"}, args...)...); err != nil {
+		return fmt.Errorf("failed to check out %s: %w", g.branchName, err)
+	}
+	return nil
+}
+
+// checkoutClean discards the changes copied from the main checkout, keeping ignored files, and then
+// checks out the branch.
+func (g *GitWorktree) checkoutClean(args ...string) error {
+	if _, err := g.runGitCommand(g.worktreePath, "reset", "-q", "--hard"); err != nil {
+		return fmt.Errorf("failed to reset copy: %w", err)
+	}
+	if _, err := g.runGitCommand(g.worktreePath, "clean", "-q", "-f", "-d"); err != nil {
+		return fmt.Errorf("failed to clean copy: %w", err)
+	}
+	return g.checkout(args...)
+}
+
+// saveBranch updates the branch in the main repository to the branch of the checkout, so that it
+// survives removing the checkout.
+func (g *GitWorktree) saveBranch() error {
+	ref := "refs/heads/" + g.branchName
+	if _, err := g.runGitCommand(g.repoPath, "fetch", "-q", g.worktreePath, "+"+ref+":"+ref); err != nil {
+		return fmt.Errorf("failed to save branch %s to the repository: %w", g.branchName, err)
+	}
+	return nil
+}
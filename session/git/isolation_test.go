@@ -0,0 +1,78 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsolations(t *testing.T) {
+	for _, isolation := range []Isolation{IsolationWorktree, IsolationClone, IsolationCopy} {
+		t.Run(string(isolation), func(t *testing.T) {
+			repo := setupHunkTestRepo(t).repoPath
+			writeFile(t, repo, ".gitignore", "cache/\n")
+			_, err := runGit(repo, "add", ".gitignore")
+			require.NoError(t, err)
+			_, err = runGit(repo, "commit", "-q", "-m", "ignore cache")
+			require.NoError(t, err)
+			require.NoError(t, os.MkdirAll(filepath.Join(repo, "cache"), 0755))
+			writeFile(t, repo, "cache/data", "cached\n")
+			// Uncommitted changes in the main checkout must not leak into the instance.
+			writeFile(t, repo, "file.txt", "dirty\n")
+
+			g := &GitWorktree{
+				repoPath:     repo,
+				worktreePath: filepath.Join(t.TempDir(), "checkout"),
+				sessionName:  "iso",
+				branchName:   "cs/iso",
+				isolation:    isolation,
+			}
+			require.NoError(t, g.Setup())
+			assert.NotEqual(t, "dirty\n", readFile(t, g.worktreePath, "file.txt"))
+			_, err = os.Stat(filepath.Join(g.worktreePath, "cache", "data"))
+			assert.Equal(t, isolation == IsolationCopy, err == nil, "only copies carry ignored files")
+
+			editTwoHunks(t, g)
+			stats := g.Diff()
+			require.NoError(t, stats.Error)
+			assert.Equal(t, 2, stats.Added)
+
+			// Pausing keeps the committed work on the branch of the main repository.
+			require.NoError(t, g.CommitChanges("work"))
+			require.NoError(t, g.Remove())
+			require.NoError(t, g.Prune())
+			_, err = os.Stat(g.worktreePath)
+			assert.True(t, os.IsNotExist(err))
+			msg, err := runGit(repo, "log", "-1", "--format=%s", "cs/iso")
+			require.NoError(t, err)
+			assert.Equal(t, "work\n", msg)
+
+			require.NoError(t, g.Setup())
+			assert.Contains(t, readFile(t, g.worktreePath, "file.txt"), "line A")
+			stats = g.Diff()
+			require.NoError(t, stats.Error)
+			assert.Equal(t, 2, stats.Added)
+
+			require.NoError(t, g.Cleanup())
+			assert.False(t, BranchExists(repo, "cs/iso"))
+			_, err = os.Stat(g.worktreePath)
+			assert.True(t, os.IsNotExist(err))
+		})
+	}
+}
+
+func TestParseIsolation(t *testing.T) {
+	isolation, err := ParseIsolation("")
+	require.NoError(t, err)
+	assert.Equal(t, IsolationWorktree, isolation)
+
+	isolation, err = ParseIsolation("clone")
+	require.NoError(t, err)
+	assert.Equal(t, IsolationClone, isolation)
+
+	_, err = ParseIsolation("docker")
+	assert.Error(t, err)
+}
@@ -0,0 +1,227 @@
+package git
+
+import (
+	"claude-squad/config"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// WorktreeInfo describes a linked worktree of a repository, as reported by git worktree list.
+type WorktreeInfo struct {
+	// Path is the path of the worktree.
+	Path string
+	// Branch is the branch checked out in the worktree, or "" if HEAD is detached.
+	Branch string
+	// Prunable is true if the worktree directory no longer exists.
+	Prunable bool
+}
+
+// ListWorktrees returns the linked worktrees of the repository at repoPath. The main worktree is not
+// included.
+func ListWorktrees(repoPath string) ([]WorktreeInfo, error) {
+	output, err := runGit(repoPath, "worktree", "list", "--porcelain")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list worktrees: %w", err)
+	}
+
+	var worktrees []WorktreeInfo
+	// Entries are separated by blank lines. The first one is the main worktree.
+	for i, block := range strings.Split(strings.TrimSpace(output), "\n\n") {
+		if i == 0 {
+			continue
+		}
+		var info WorktreeInfo
+		for _, line := range strings.Split(block, "\n") {
+			switch {
+			case strings.HasPrefix(line, "worktree "):
+				info.Path = strings.TrimPrefix(line, "worktree ")
+			case strings.HasPrefix(line, "branch "):
+				info.Branch = strings.TrimPrefix(strings.TrimPrefix(line, "branch "), "refs/heads/")
+			case line == "prunable" || strings.HasPrefix(line, "prunable "):
+				info.Prunable = true
+			}
+		}
+		if info.Path == "" {
+			continue
+		}
+		if _, err := os.Stat(info.Path); os.IsNotExist(err) {
+			info.Prunable = true
+		}
+		worktrees = append(worktrees, info)
+	}
+	return worktrees, nil
+}
+
+// ListBranches returns the local branches of the repository whose name starts with prefix.
+func ListBranches(repoPath string, prefix string) ([]string, error) {
+	output, err := runGit(repoPath, "for-each-ref", "--format=%(refname:short)", "refs/heads/")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branches: %w", err)
+	}
+	var branches []string
+	for _, branch := range strings.Split(output, "\n") {
+		if branch != "" && strings.HasPrefix(branch, prefix) {
+			branches = append(branches, branch)
+		}
+	}
+	return branches, nil
+}
+
+// BranchExists returns true if the repository has a local branch with the given name.
+func BranchExists(repoPath string, branch string) bool {
+	_, err := runGit(repoPath, "rev-parse", "--verify", "-q", "refs/heads/"+branch)
+	return err == nil
+}
+
+// DeleteBranch force deletes a local branch.
+func DeleteBranch(repoPath string, branch string) error {
+	if _, err := runGit(repoPath, "branch", "-D", branch); err != nil {
+		return fmt.Errorf("failed to delete branch %s: %w", branch, err)
+	}
+	return nil
+}
+
+// RemoveWorktree removes a linked worktree, discarding any uncommitted changes in it, and prunes
+// the administrative files of worktrees whose directory is gone. Checkouts that aren't linked
+// worktrees, like clones and copies, are deleted.
+func RemoveWorktree(repoPath string, worktreePath string) error {
+	if _, err := os.Stat(worktreePath); err == nil {
+		if WorktreeRepo(worktreePath) == "" && filepath.Clean(worktreePath) != filepath.Clean(repoPath) {
+			if err := os.RemoveAll(worktreePath); err != nil {
+				return fmt.Errorf("failed to remove %s: %w", worktreePath, err)
+			}
+		} else if _, err := runGit(repoPath, "worktree", "remove", "-f", worktreePath); err != nil {
+			return fmt.Errorf("failed to remove worktree %s: %w", worktreePath, err)
+		}
+	}
+	if _, err := runGit(repoPath, "worktree", "prune"); err != nil {
+		return fmt.Errorf("failed to prune worktrees: %w", err)
+	}
+	return nil
+}
+
+// WorktreeDirectory returns the directory worktrees are created in when no worktree path template is
+// configured.
+func WorktreeDirectory() (string, error) {
+	return getWorktreeDirectory()
+}
+
+// WorktreeRepo returns the main repository of a linked worktree directory by following its .git
+// file, or "" if dir isn't a linked worktree.
+func WorktreeRepo(dir string) string {
+	content, err := os.ReadFile(filepath.Join(dir, ".git"))
+	if err != nil {
+		return ""
+	}
+	gitDir, ok := strings.CutPrefix(strings.TrimSpace(string(content)), "gitdir: ")
+	if !ok {
+		return ""
+	}
+	// gitDir looks like <repo>/.git/worktrees/<name>
+	idx := strings.LastIndex(gitDir, string(filepath.Separator)+filepath.Join(".git", "worktrees")+string(filepath.Separator))
+	if idx < 0 {
+		return ""
+	}
+	return gitDir[:idx]
+}
+
+// NewGitWorktreeForBranch creates a GitWorktree for an existing branch, e.g. one left behind by an
+// instance that is no longer stored. The base commit is the merge base of the branch and the
+// repository's HEAD. If worktreePath is empty, the worktree is placed like a new one.
+func NewGitWorktreeForBranch(repoPath string, sessionName string, sessionID string, branchName string, worktreePath string) (*GitWorktree, error) {
+	if worktreePath == "" {
+		path, err := newWorktreePath(config.LoadConfig(), repoPath, sessionName, sessionID, branchName)
+		if err != nil {
+			return nil, err
+		}
+		worktreePath = path
+	}
+
+	base, err := runGit(repoPath, "merge-base", "HEAD", branchName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the base commit of %s: %w", branchName, err)
+	}
+	baseBranch, _ := runGit(repoPath, "symbolic-ref", "--short", "-q", "HEAD")
+
+	worktree := NewGitWorktreeFromStorage(repoPath, worktreePath, sessionName, branchName,
+		strings.TrimSpace(base), strings.TrimSpace(baseBranch))
+	worktree.SetSessionID(sessionID)
+	return worktree, nil
+}
+
+// RepoRoot returns the root of the repository containing path.
+func RepoRoot(path string) (string, error) {
+	return findGitRepoRoot(path)
+}
+
+// UnpushedCommits returns the commits that are only reachable from a branch, i.e. that are neither
+// pushed nor on another local branch, as "<short sha> <subject>" lines, newest first. These are the
+// commits lost when the branch is deleted.
+func UnpushedCommits(repoPath string, branch string) ([]string, error) {
+	commits, _, err := unpushedCommits(repoPath, branch)
+	return commits, err
+}
+
+// UnpushedCommits is the UnpushedCommits function for the branch of the instance. The branch of a
+// clone or copy also lives in the checkout, where it may have commits that were not saved to the
+// repository yet (see saveBranch). These are included.
+func (g *GitWorktree) UnpushedCommits() ([]string, error) {
+	dirs := []string{g.repoPath}
+	if g.GetIsolation() != IsolationWorktree && g.HasCheckout() {
+		dirs = append(dirs, g.worktreePath)
+	}
+	var commits []string
+	seen := make(map[string]bool)
+	for _, dir := range dirs {
+		if !BranchExists(dir, g.branchName) {
+			continue
+		}
+		lines, hashes, err := unpushedCommits(dir, g.branchName)
+		if err != nil {
+			return nil, err
+		}
+		for i, line := range lines {
+			if !seen[hashes[i]] {
+				seen[hashes[i]] = true
+				commits = append(commits, line)
+			}
+		}
+	}
+	return commits, nil
+}
+
+// HasBranch returns true if the branch of the instance exists, either in the repository or in the
+// checkout of a clone or copy, which only saves it to the repository on pause.
+func (g *GitWorktree) HasBranch() bool {
+	if BranchExists(g.repoPath, g.branchName) {
+		return true
+	}
+	return g.GetIsolation() != IsolationWorktree && g.HasCheckout() && BranchExists(g.worktreePath, g.branchName)
+}
+
+// unpushedCommits returns the lines of UnpushedCommits along with the full hash of each commit.
+func unpushedCommits(dir string, branch string) (lines []string, hashes []string, err error) {
+	output, err := runGit(dir, "log", "--format=%H %h %s", "refs/heads/"+branch,
+		"--not", "--remotes", "--exclude="+branch, "--branches")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list unpushed commits of %s: %w", branch, err)
+	}
+	for _, line := range strings.Split(output, "\n") {
+		if hash, rest, ok := strings.Cut(line, " "); ok {
+			hashes = append(hashes, hash)
+			lines = append(lines, rest)
+		}
+	}
+	return lines, hashes, nil
+}
+
+// CurrentBranch returns the branch checked out at path, or "" if HEAD is detached.
+func CurrentBranch(path string) string {
+	output, err := runGit(path, "symbolic-ref", "--short", "-q", "HEAD")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(output)
+}
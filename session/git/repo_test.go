@@ -0,0 +1,45 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListWorktreesAndBranches(t *testing.T) {
+	g := setupHunkTestRepo(t)
+	repo := g.repoPath
+
+	kept := filepath.Join(t.TempDir(), "kept")
+	gone := filepath.Join(t.TempDir(), "gone")
+	_, err := runGit(repo, "worktree", "add", "-q", "-b", "cs/kept", kept)
+	require.NoError(t, err)
+	_, err = runGit(repo, "worktree", "add", "-q", "-b", "cs/gone", gone)
+	require.NoError(t, err)
+	_, err = runGit(repo, "branch", "other")
+	require.NoError(t, err)
+	require.NoError(t, os.RemoveAll(gone))
+
+	worktrees, err := ListWorktrees(repo)
+	require.NoError(t, err)
+	require.Len(t, worktrees, 2)
+	byBranch := map[string]WorktreeInfo{}
+	for _, wt := range worktrees {
+		byBranch[wt.Branch] = wt
+	}
+	assert.False(t, byBranch["cs/kept"].Prunable)
+	assert.True(t, byBranch["cs/gone"].Prunable)
+	assert.Equal(t, repo, WorktreeRepo(byBranch["cs/kept"].Path))
+
+	branches, err := ListBranches(repo, "cs/")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"cs/kept", "cs/gone"}, branches)
+
+	require.NoError(t, RemoveWorktree(repo, gone))
+	require.NoError(t, DeleteBranch(repo, "cs/gone"))
+	assert.False(t, BranchExists(repo, "cs/gone"))
+	assert.True(t, BranchExists(repo, "cs/kept"))
+}
@@ -0,0 +1,91 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// SetSparsePaths sets the directories checked out in the worktree (cone mode sparse-checkout). It
+// takes effect the next time the worktree is set up. An empty list checks out the full tree. The
+// repository's sparse_checkout setting only applies to new worktrees, see NewGitWorktree.
+func (g *GitWorktree) SetSparsePaths(paths []string) {
+	g.sparsePaths = paths
+}
+
+// GetSparsePaths returns the directories checked out in the worktree, or nil for a full checkout.
+func (g *GitWorktree) GetSparsePaths() []string {
+	return g.sparsePaths
+}
+
+// IsSparse returns true if only part of the tree is checked out in the worktree.
+func (g *GitWorktree) IsSparse() bool {
+	return len(g.sparsePaths) > 0
+}
+
+// addWorktree runs git worktree add with the given arguments. For sparse worktrees, the checkout is
+// deferred until the sparse-checkout patterns are in place, so the full tree is never written.
+func (g *GitWorktree) addWorktree(args ...string) error {
+	if !g.IsSparse() {
+		_, err := g.runGitCommand(g.repoPath, append([]string{"worktree", "add"}, args...)...)
+		return err
+	}
+
+	if _, err := g.runGitCommand(g.repoPath, append([]string{"worktree", "add", "--no-checkout"}, args...)...); err != nil {
+		return err
+	}
+	return g.checkout(g.branchName)
+}
+
+// applySparseCheckout sets the sparse-checkout patterns of a checkout that hasn't been populated yet.
+func (g *GitWorktree) applySparseCheckout() error {
+	if !g.IsSparse() {
+		return nil
+	}
+	// In linked worktrees, sparse-checkout enables extensions.worktreeConfig, so the patterns only
+	// apply to this worktree.
+	if _, err := g.runGitCommand(g.worktreePath, append([]string{"sparse-checkout", "set", "--cone"}, g.sparsePaths...)...); err != nil {
+		return fmt.Errorf("failed to set sparse-checkout patterns: %w", err)
+	}
+	return nil
+}
+
+// WidenSparseCheckout adds directories to the sparse checkout of the worktree. Directories that are
+// already checked out are ignored. If the worktree doesn't exist, e.g. while paused, the directories are
+// checked out the next time it is set up.
+func (g *GitWorktree) WidenSparseCheckout(paths []string) error {
+	if !g.IsSparse() {
+		return fmt.Errorf("the worktree already has the full tree checked out")
+	}
+
+	var added []string
+	for _, path := range paths {
+		path = strings.Trim(path, "/")
+		if path == "" || g.hasSparsePath(path) {
+			continue
+		}
+		added = append(added, path)
+	}
+	if len(added) == 0 {
+		return nil
+	}
+	if _, err := os.Stat(g.worktreePath); os.IsNotExist(err) {
+		g.sparsePaths = append(g.sparsePaths, added...)
+		return nil
+	}
+
+	if _, err := g.runGitCommand(g.worktreePath, append([]string{"sparse-checkout", "add"}, added...)...); err != nil {
+		return fmt.Errorf("failed to widen sparse checkout: %w", err)
+	}
+	g.sparsePaths = append(g.sparsePaths, added...)
+	return nil
+}
+
+func (g *GitWorktree) hasSparsePath(path string) bool {
+	for _, existing := range g.sparsePaths {
+		if existing == path {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSparseWorktree(t *testing.T) {
+	repo := t.TempDir()
+	run := func(dir string, args ...string) string {
+		out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+		require.NoError(t, err, string(out))
+		return strings.TrimSpace(string(out))
+	}
+	run(repo, "init", "-q")
+	run(repo, "config", "user.email", "test@example.com")
+	run(repo, "config", "user.name", "Test")
+	for _, dir := range []string{"api", "web", "libs"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(repo, dir), 0755))
+		writeFile(t, filepath.Join(repo, dir), "main.txt", dir+"\n")
+	}
+	writeFile(t, repo, "README", "readme\n")
+	run(repo, "add", ".")
+	run(repo, "commit", "-q", "-m", "initial")
+
+	g := &GitWorktree{
+		repoPath:     repo,
+		worktreePath: filepath.Join(t.TempDir(), "wt"),
+		sessionName:  "sparse",
+		branchName:   "cs/sparse",
+		sparsePaths:  []string{"api"},
+	}
+	require.NoError(t, g.SetupNewWorktree())
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(g.worktreePath, name))
+		return err == nil
+	}
+	assert.True(t, exists("api/main.txt"))
+	assert.True(t, exists("README"))
+	assert.False(t, exists("web"))
+
+	// Files outside the sparse set must not show up as deleted.
+	writeFile(t, filepath.Join(g.worktreePath, "api"), "main.txt", "changed\n")
+	stats := g.Diff()
+	require.NoError(t, stats.Error)
+	assert.Equal(t, 1, stats.Added)
+	assert.Equal(t, 1, stats.Removed)
+	assert.NotContains(t, stats.Content, "web/main.txt")
+
+	require.NoError(t, g.CommitChanges("change api"))
+	assert.Equal(t, "api/main.txt", run(g.worktreePath, "show", "--name-only", "--format=", "HEAD"))
+
+	require.NoError(t, g.WidenSparseCheckout([]string{"web/", "api"}))
+	assert.Equal(t, []string{"api", "web"}, g.GetSparsePaths())
+	assert.True(t, exists("web/main.txt"))
+	assert.False(t, exists("libs"))
+
+	// The main checkout is not affected by the worktree's sparse-checkout.
+	_, err := os.Stat(filepath.Join(repo, "libs", "main.txt"))
+	assert.NoError(t, err)
+
+	// Resuming applies the sparse set again.
+	require.NoError(t, g.Remove())
+	require.NoError(t, g.SetupFromExistingBranch())
+	assert.True(t, exists("web/main.txt"))
+	assert.False(t, exists("libs"))
+
+	// The repository's sparse-checkout configuration doesn't narrow worktrees created with the full
+	// tree when they are set up again.
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	require.NoError(t, os.MkdirAll(filepath.Join(home, ".claude-squad"), 0755))
+	writeFile(t, filepath.Join(home, ".claude-squad"), "config.json", `{"repos": {"`+repo+`": {"sparse_checkout": ["api"]}}}`)
+	full := &GitWorktree{
+		repoPath:     repo,
+		worktreePath: filepath.Join(t.TempDir(), "full"),
+		sessionName:  "full",
+		branchName:   "cs/full",
+	}
+	require.NoError(t, full.SetupNewWorktree())
+	require.NoError(t, full.Remove())
+	require.NoError(t, full.SetupFromExistingBranch())
+	assert.False(t, full.IsSparse())
+	_, err = os.Stat(filepath.Join(full.worktreePath, "libs", "main.txt"))
+	assert.NoError(t, err)
+}
@@ -0,0 +1,219 @@
+package git
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// wipRefPrefix is where the uncommitted changes of paused sessions are kept, outside of refs/heads so
+// they don't show up as branches.
+const wipRefPrefix = "refs/claudesquad/wip/"
+
+// WIPRef returns the hidden ref holding the uncommitted changes saved when the session was paused.
+func (g *GitWorktree) WIPRef() string {
+	name := g.sessionID
+	if name == "" {
+		return wipRefPrefix + sanitizeRefName(g.branchName)
+	}
+	if sanitizeRefName(name) != name || strings.Contains(name, "/") {
+		// Instances created by older versions use their title as ID, which may not be valid in a ref
+		// name. A hash of the ID keeps titles apart that sanitize to the same name.
+		sum := sha1.Sum([]byte(name))
+		name = strings.TrimLeft(sanitizeRefName(strings.ReplaceAll(name, "/", "-"))+"-"+hex.EncodeToString(sum[:4]), "-")
+	}
+	return wipRefPrefix + name
+}
+
+// SaveWIP saves the uncommitted changes of the checkout, including untracked files, to a commit on
+// top of the branch referenced by WIPRef instead of the branch itself. It returns false if there
+// was nothing to save.
+func (g *GitWorktree) SaveWIP() (bool, error) {
+	saved, err := g.saveWIP(g.worktreePath)
+	if err != nil || !saved {
+		return saved, err
+	}
+	if g.GetIsolation() != IsolationWorktree {
+		// Clones and copies are deleted on pause, keep the changes in the main repository.
+		ref := g.WIPRef()
+		if _, err := g.runGitCommand(g.repoPath, "fetch", "-q", g.worktreePath, "+"+ref+":"+ref); err != nil {
+			return false, fmt.Errorf("failed to save uncommitted changes to the repository: %w", err)
+		}
+	}
+	return true, nil
+}
+
+// saveWIP is SaveWIP for the checkout at dir, which has the session's branch checked out.
+func (g *GitWorktree) saveWIP(dir string) (bool, error) {
+	status, err := g.runGitCommand(dir, "status", "--porcelain")
+	if err != nil {
+		return false, fmt.Errorf("failed to check worktree status: %w", err)
+	}
+	if len(status) == 0 {
+		return false, nil
+	}
+
+	// Stage everything in a temporary index so the checkout's own index stays untouched.
+	tmp, err := os.MkdirTemp("", "claudesquad-wip-")
+	if err != nil {
+		return false, fmt.Errorf("failed to create temporary index: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+	index := filepath.Join(tmp, "index")
+	if _, err := runGitWithIndex(dir, index, "read-tree", "HEAD"); err != nil {
+		return false, fmt.Errorf("failed to prepare temporary index: %w", err)
+	}
+	if _, err := runGitWithIndex(dir, index, "add", "-A"); err != nil {
+		return false, fmt.Errorf("failed to stage uncommitted changes: %w", err)
+	}
+	tree, err := runGitWithIndex(dir, index, "write-tree")
+	if err != nil {
+		return false, fmt.Errorf("failed to write tree of uncommitted changes: %w", err)
+	}
+
+	commit, err := g.runGitCommand(dir, "commit-tree", strings.TrimSpace(tree), "-p", "HEAD",
+		"-m", fmt.Sprintf("[claudesquad] work in progress of '%s'", g.sessionName))
+	if err != nil {
+		return false, fmt.Errorf("failed to save uncommitted changes: %w", err)
+	}
+	if err := g.keepUnrestoredWIP(); err != nil {
+		return false, err
+	}
+	// The empty old value makes update-ref fail rather than overwrite changes saved in the meantime.
+	ref := g.WIPRef()
+	if _, err := g.runGitCommand(dir, "update-ref", "--create-reflog", "-m", "claude-squad: save uncommitted changes",
+		ref, strings.TrimSpace(commit), ""); err != nil {
+		return false, fmt.Errorf("failed to update %s: %w", ref, err)
+	}
+	return true, nil
+}
+
+// keepUnrestoredWIP moves changes saved before that were never restored, e.g. because they didn't
+// apply on resume, from WIPRef to a numbered ref next to it like refs/claudesquad/wip/<id>.1, so
+// saving new changes doesn't overwrite them.
+func (g *GitWorktree) keepUnrestoredWIP() error {
+	ref := g.WIPRef()
+	old, err := g.runGitCommand(g.repoPath, "rev-parse", "--verify", "-q", ref)
+	if err != nil {
+		return nil
+	}
+	old = strings.TrimSpace(old)
+	for n := 1; ; n++ {
+		aside := fmt.Sprintf("%s.%d", ref, n)
+		if _, err := g.runGitCommand(g.repoPath, "rev-parse", "--verify", "-q", aside); err == nil {
+			continue
+		}
+		if _, err := g.runGitCommand(g.repoPath, "update-ref", "--create-reflog", "-m", "claude-squad: keep unrestored changes",
+			aside, old, ""); err != nil {
+			return fmt.Errorf("failed to keep unrestored changes in %s: %w", aside, err)
+		}
+		if _, err := g.runGitCommand(g.repoPath, "update-ref", "-d", ref, old); err != nil {
+			return fmt.Errorf("failed to delete %s: %w", ref, err)
+		}
+		return nil
+	}
+}
+
+// RestoreWIP applies the changes saved by SaveWIP to the checkout as uncommitted changes and deletes
+// the ref. It does nothing if no changes were saved. If the changes don't apply, e.g. because the
+// branch moved in the meantime, the ref is kept so nothing is lost, and the next SaveWIP moves it
+// aside instead of overwriting it.
+func (g *GitWorktree) RestoreWIP() error {
+	return g.restoreWIP(g.worktreePath)
+}
+
+// restoreWIP is RestoreWIP for the checkout at dir.
+func (g *GitWorktree) restoreWIP(dir string) error {
+	ref := g.WIPRef()
+	commit, err := g.runGitCommand(g.repoPath, "rev-parse", "--verify", "-q", ref)
+	if err != nil {
+		// No saved changes.
+		return nil
+	}
+	commit = strings.TrimSpace(commit)
+
+	patch, err := g.runGitCommand(g.repoPath, "diff", "--binary", commit+"^", commit)
+	if err != nil {
+		return fmt.Errorf("failed to read uncommitted changes from %s: %w", ref, err)
+	}
+	if strings.TrimSpace(patch) != "" {
+		if err := g.applyPatchIn(dir, patch); err != nil {
+			return fmt.Errorf("failed to restore uncommitted changes, they are kept in %s: %w", ref, err)
+		}
+	}
+	if _, err := g.runGitCommand(g.repoPath, "update-ref", "-d", ref, commit); err != nil {
+		return fmt.Errorf("failed to delete %s: %w", ref, err)
+	}
+	return nil
+}
+
+// WIPRefs returns the refs holding uncommitted changes of the session that were saved on pause and
+// not restored yet: WIPRef, if it exists, and the refs keepUnrestoredWIP moved earlier changes to.
+func (g *GitWorktree) WIPRefs() ([]string, error) {
+	all, err := ListWIPRefs(g.repoPath)
+	if err != nil {
+		return nil, err
+	}
+	var refs []string
+	for _, ref := range all {
+		if g.OwnsWIPRef(ref) {
+			refs = append(refs, ref)
+		}
+	}
+	return refs, nil
+}
+
+// OwnsWIPRef returns true if ref is one of the session's WIPRefs.
+func (g *GitWorktree) OwnsWIPRef(ref string) bool {
+	return ref == g.WIPRef() || strings.HasPrefix(ref, g.WIPRef()+".")
+}
+
+// ListWIPRefs returns the refs holding saved uncommitted changes of all sessions of the repository.
+func ListWIPRefs(repoPath string) ([]string, error) {
+	out, err := runGit(repoPath, "for-each-ref", "--format=%(refname)", wipRefPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list saved uncommitted changes: %w", err)
+	}
+	return strings.Fields(out), nil
+}
+
+// DeleteWIPRef deletes a ref returned by ListWIPRefs.
+func DeleteWIPRef(repoPath, ref string) error {
+	if !strings.HasPrefix(ref, wipRefPrefix) {
+		return fmt.Errorf("%s doesn't hold saved uncommitted changes", ref)
+	}
+	if _, err := runGit(repoPath, "update-ref", "-d", ref); err != nil {
+		return fmt.Errorf("failed to delete %s: %w", ref, err)
+	}
+	return nil
+}
+
+// DeleteWIP removes all refs holding saved uncommitted changes of the session.
+func (g *GitWorktree) DeleteWIP() error {
+	refs, err := g.WIPRefs()
+	if err != nil {
+		return err
+	}
+	for _, ref := range refs {
+		if err := DeleteWIPRef(g.repoPath, ref); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runGitWithIndex runs a git command in path using index, an absolute path, instead of the checkout's
+// index file.
+func runGitWithIndex(path, index string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", path}, args...)...)
+	cmd.Env = append(os.Environ(), "GIT_INDEX_FILE="+index)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git command failed: %s (%w)", output, err)
+	}
+	return string(output), nil
+}
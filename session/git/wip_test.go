@@ -0,0 +1,132 @@
+package git
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveAndRestoreWIP(t *testing.T) {
+	for _, isolation := range []Isolation{IsolationWorktree, IsolationClone} {
+		t.Run(string(isolation), func(t *testing.T) {
+			repo := setupHunkTestRepo(t).repoPath
+			g := &GitWorktree{
+				repoPath:     repo,
+				worktreePath: filepath.Join(t.TempDir(), "checkout"),
+				sessionName:  "wip",
+				sessionID:    "abc123",
+				branchName:   "cs/wip",
+				isolation:    isolation,
+			}
+			require.NoError(t, g.Setup())
+			head, err := runGit(g.worktreePath, "rev-parse", "HEAD")
+			require.NoError(t, err)
+
+			saved, err := g.SaveWIP()
+			require.NoError(t, err)
+			assert.False(t, saved, "a clean checkout has nothing to save")
+
+			writeFile(t, g.worktreePath, "file.txt", "changed\n")
+			writeFile(t, g.worktreePath, "new.txt", "untracked\n")
+			saved, err = g.SaveWIP()
+			require.NoError(t, err)
+			assert.True(t, saved)
+
+			require.NoError(t, g.Remove())
+			require.NoError(t, g.Prune())
+			// The branch doesn't get a commit, the changes live in the hidden ref.
+			tip, err := runGit(repo, "rev-parse", "cs/wip")
+			require.NoError(t, err)
+			assert.Equal(t, head, tip)
+			_, err = runGit(repo, "rev-parse", "--verify", "refs/claudesquad/wip/abc123")
+			require.NoError(t, err)
+
+			require.NoError(t, g.Setup())
+			require.NoError(t, g.RestoreWIP())
+			assert.Equal(t, "changed\n", readFile(t, g.worktreePath, "file.txt"))
+			assert.Equal(t, "untracked\n", readFile(t, g.worktreePath, "new.txt"))
+			status, err := runGit(g.worktreePath, "status", "--porcelain")
+			require.NoError(t, err)
+			assert.Equal(t, " M file.txt\n?? new.txt\n", status)
+			_, err = runGit(repo, "rev-parse", "--verify", "-q", "refs/claudesquad/wip/abc123")
+			assert.Error(t, err, "the ref is deleted once restored")
+		})
+	}
+}
+
+func TestSaveWIPKeepsUnrestoredChanges(t *testing.T) {
+	repo := setupHunkTestRepo(t).repoPath
+	g := &GitWorktree{
+		repoPath:     repo,
+		worktreePath: filepath.Join(t.TempDir(), "checkout"),
+		sessionName:  "wip",
+		sessionID:    "abc123",
+		branchName:   "cs/wip",
+		isolation:    IsolationWorktree,
+	}
+	require.NoError(t, g.Setup())
+	writeFile(t, g.worktreePath, "file.txt", "saved on pause\n")
+	saved, err := g.SaveWIP()
+	require.NoError(t, err)
+	require.True(t, saved)
+	first, err := runGit(repo, "rev-parse", "refs/claudesquad/wip/abc123")
+	require.NoError(t, err)
+	require.NoError(t, g.Remove())
+
+	// The branch moves while paused so the saved changes no longer apply on resume.
+	require.NoError(t, g.Setup())
+	writeFile(t, g.worktreePath, "file.txt", "committed elsewhere\n")
+	_, err = runGit(g.worktreePath, "commit", "-q", "-am", "conflict")
+	require.NoError(t, err)
+	assert.Error(t, g.RestoreWIP())
+
+	// Pausing again must not overwrite the changes that were never restored.
+	writeFile(t, g.worktreePath, "new.txt", "saved on the second pause\n")
+	saved, err = g.SaveWIP()
+	require.NoError(t, err)
+	require.True(t, saved)
+	kept, err := runGit(repo, "rev-parse", "refs/claudesquad/wip/abc123.1")
+	require.NoError(t, err)
+	assert.Equal(t, first, kept)
+	second, err := runGit(repo, "rev-parse", "refs/claudesquad/wip/abc123")
+	require.NoError(t, err)
+	assert.NotEqual(t, first, second)
+
+	refs, err := g.WIPRefs()
+	require.NoError(t, err)
+	assert.Len(t, refs, 2)
+	require.NoError(t, g.DeleteWIP())
+	refs, err = g.WIPRefs()
+	require.NoError(t, err)
+	assert.Empty(t, refs)
+}
+
+func TestWIPRefOfLegacyTitle(t *testing.T) {
+	repo := setupHunkTestRepo(t).repoPath
+	// Instances created before session IDs existed use their title as ID.
+	g := &GitWorktree{
+		repoPath:     repo,
+		worktreePath: filepath.Join(t.TempDir(), "checkout"),
+		sessionName:  "fix bug",
+		sessionID:    "fix bug",
+		branchName:   "cs/fix-bug",
+	}
+	ref := g.WIPRef()
+	_, err := runGit(repo, "check-ref-format", ref)
+	require.NoError(t, err, ref)
+	assert.NotEqual(t, ref, (&GitWorktree{sessionID: "fix-bug"}).WIPRef())
+	assert.NotEqual(t, ref, (&GitWorktree{sessionID: "fix/bug"}).WIPRef())
+	assert.Equal(t, "refs/claudesquad/wip/abc123", (&GitWorktree{sessionID: "abc123"}).WIPRef())
+
+	require.NoError(t, g.Setup())
+	writeFile(t, g.worktreePath, "file.txt", "changed\n")
+	saved, err := g.SaveWIP()
+	require.NoError(t, err)
+	assert.True(t, saved)
+	require.NoError(t, g.Remove())
+	require.NoError(t, g.Setup())
+	require.NoError(t, g.RestoreWIP())
+	assert.Equal(t, "changed\n", readFile(t, g.worktreePath, "file.txt"))
+}
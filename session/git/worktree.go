@@ -4,7 +4,10 @@ import (
 	"claude-squad/config"
 	"claude-squad/log"
 	"fmt"
+	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 )
 
@@ -17,6 +20,42 @@ func getWorktreeDirectory() (string, error) {
 	return filepath.Join(configDir, "worktrees"), nil
 }
 
+// templatedWorktreePath expands a worktree path template for a session. Relative paths are resolved
+// against the repository root.
+func templatedWorktreePath(tmpl, repoPath, sessionName, sessionID, branchName string) (string, error) {
+	path, err := config.ExpandTemplate(tmpl, map[string]string{
+		"repo":   filepath.Base(repoPath),
+		"branch": branchName,
+		"name":   sanitizeBranchName(sessionName),
+		"id":     sessionID,
+	})
+	if err != nil {
+		return "", fmt.Errorf("invalid worktree path template: %w", err)
+	}
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get home directory: %w", err)
+		}
+		path = filepath.Join(homeDir, path[1:])
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(repoPath, path)
+	}
+	return filepath.Clean(path), nil
+}
+
+// uniqueWorktreePath returns path, or path with a numeric suffix if it is already taken.
+func uniqueWorktreePath(path string) string {
+	candidate := path
+	for n := 2; ; n++ {
+		if _, err := os.Stat(candidate); os.IsNotExist(err) {
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s-%d", path, n)
+	}
+}
+
 // GitWorktree manages git worktree operations for a session
 type GitWorktree struct {
 	// Path to the repository
@@ -25,27 +64,54 @@ type GitWorktree struct {
 	worktreePath string
 	// Name of the session
 	sessionName string
+	// ID of the session, which unlike its name never changes
+	sessionID string
+	// Name of the program running in the session, which commits are attributed to
+	agent string
 	// Branch name for the worktree
 	branchName string
 	// Base commit hash for the worktree
 	baseCommitSHA string
+	// Branch that was checked out in the repository when the worktree was created
+	baseBranch string
+	// What Diff compares the worktree against
+	baseline DiffBaseline
+	// Directories checked out in cone mode sparse-checkout, nil for a full checkout
+	sparsePaths []string
+	// How the checkout is separated from the main repository
+	isolation Isolation
+	// What happens to the main checkout's uncommitted changes when a new worktree is set up
+	carry CarryMode
+	// State of the main checkout before the branch was checked out in it, nil if it isn't
+	repoCheckout *RepoCheckout
 }
 
-func NewGitWorktreeFromStorage(repoPath string, worktreePath string, sessionName string, branchName string, baseCommitSHA string) *GitWorktree {
+func NewGitWorktreeFromStorage(repoPath string, worktreePath string, sessionName string, branchName string, baseCommitSHA string, baseBranch string) *GitWorktree {
 	return &GitWorktree{
 		repoPath:      repoPath,
 		worktreePath:  worktreePath,
 		sessionName:   sessionName,
 		branchName:    branchName,
 		baseCommitSHA: baseCommitSHA,
+		baseBranch:    baseBranch,
 	}
 }
 
+// Copy returns a copy of the worktree's settings. Diffing the copy on another goroutine doesn't race
+// with changes made to g in the meantime, like a renamed branch or a new diff baseline.
+func (g *GitWorktree) Copy() *GitWorktree {
+	c := *g
+	c.sparsePaths = slices.Clone(g.sparsePaths)
+	if g.repoCheckout != nil {
+		checkout := *g.repoCheckout
+		c.repoCheckout = &checkout
+	}
+	return &c
+}
+
 // NewGitWorktree creates a new GitWorktree instance
-func NewGitWorktree(repoPath string, sessionName string) (tree *GitWorktree, branchname string, err error) {
+func NewGitWorktree(repoPath string, sessionName string, sessionID string) (tree *GitWorktree, branchname string, err error) {
 	cfg := config.LoadConfig()
-	sanitizedName := sanitizeBranchName(sessionName)
-	branchName := fmt.Sprintf("%s%s", cfg.BranchPrefix, sanitizedName)
 
 	// Convert repoPath to absolute path
 	absPath, err := filepath.Abs(repoPath)
@@ -60,22 +126,93 @@ func NewGitWorktree(repoPath string, sessionName string) (tree *GitWorktree, bra
 		return nil, "", err
 	}
 
-	worktreeDir, err := getWorktreeDirectory()
+	branchName, err := newBranchName(cfg, repoPath, sessionName)
+	if err != nil {
+		return nil, "", err
+	}
+	if unique, err := uniqueBranchName(repoPath, branchName); err != nil {
+		return nil, "", err
+	} else if unique != branchName {
+		log.InfoLog.Printf("branch %s already exists, using %s instead", branchName, unique)
+		branchName = unique
+	}
+
+	worktreePath, err := newWorktreePath(cfg, repoPath, sessionName, sessionID, branchName)
 	if err != nil {
 		return nil, "", err
 	}
 
-	worktreePath := filepath.Join(worktreeDir, sanitizedName)
-	worktreePath = worktreePath + "_" + fmt.Sprintf("%x", time.Now().UnixNano())
+	isolation, err := ParseIsolation(cfg.ForRepo(repoPath).Isolation)
+	if err != nil {
+		return nil, "", err
+	}
 
 	return &GitWorktree{
 		repoPath:     repoPath,
 		sessionName:  sessionName,
+		sessionID:    sessionID,
 		branchName:   branchName,
 		worktreePath: worktreePath,
+		sparsePaths:  cfg.ForRepo(repoPath).SparseCheckout,
+		isolation:    isolation,
 	}, branchName, nil
 }
 
+// newWorktreePath returns an unused path for a new worktree, following the configured template or
+// the default layout.
+func newWorktreePath(cfg *config.Config, repoPath, sessionName, sessionID, branchName string) (string, error) {
+	if tmpl := cfg.ForRepo(repoPath).WorktreePath; tmpl != "" {
+		path, err := templatedWorktreePath(tmpl, repoPath, sessionName, sessionID, branchName)
+		if err != nil {
+			return "", err
+		}
+		return uniqueWorktreePath(path), nil
+	}
+
+	worktreeDir, err := getWorktreeDirectory()
+	if err != nil {
+		return "", err
+	}
+	worktreePath := filepath.Join(worktreeDir, sanitizeBranchName(sessionName))
+	if sessionID == "" {
+		return worktreePath + "_" + fmt.Sprintf("%x", time.Now().UnixNano()), nil
+	}
+	return worktreePath + "_" + sessionID, nil
+}
+
+// MigrateWorktreePath moves the worktree to where the current configuration places it. It only
+// applies while the worktree directory doesn't exist, i.e. while the session is paused, and only if a
+// worktree path template is configured. It returns whether the path changed.
+func (g *GitWorktree) MigrateWorktreePath() (bool, error) {
+	if _, err := os.Stat(g.worktreePath); err == nil {
+		return false, nil
+	}
+	tmpl := config.LoadConfig().ForRepo(g.repoPath).WorktreePath
+	if tmpl == "" {
+		return false, nil
+	}
+	path, err := templatedWorktreePath(tmpl, g.repoPath, g.sessionName, g.sessionID, g.branchName)
+	if err != nil {
+		return false, err
+	}
+	if path == filepath.Clean(g.worktreePath) {
+		return false, nil
+	}
+	g.worktreePath = uniqueWorktreePath(path)
+	return true, nil
+}
+
+// SetSessionID sets the ID of the session the worktree belongs to.
+func (g *GitWorktree) SetSessionID(sessionID string) {
+	g.sessionID = sessionID
+}
+
+// SetSessionName updates the name of the session after it was renamed. Templated worktree paths
+// follow the new name the next time the worktree is migrated.
+func (g *GitWorktree) SetSessionName(sessionName string) {
+	g.sessionName = sessionName
+}
+
 // GetWorktreePath returns the path to the worktree
 func (g *GitWorktree) GetWorktreePath() string {
 	return g.worktreePath
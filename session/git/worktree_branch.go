@@ -8,13 +8,14 @@ import (
 	"github.com/go-git/go-git/v5/plumbing"
 )
 
-// cleanupExistingBranch performs a thorough cleanup of any existing branch or reference
-func (g *GitWorktree) cleanupExistingBranch(repo *git.Repository) error {
+// cleanupStaleBranch removes worktree references and configuration left behind by a deleted branch
+// of the same name. It refuses to touch a branch that still exists, as it may belong to someone else.
+func (g *GitWorktree) cleanupStaleBranch(repo *git.Repository) error {
 	branchRef := plumbing.NewBranchReferenceName(g.branchName)
-
-	// Try to remove the branch reference
-	if err := repo.Storer.RemoveReference(branchRef); err != nil && err != plumbing.ErrReferenceNotFound {
-		return fmt.Errorf("failed to remove branch reference %s: %w", g.branchName, err)
+	if _, err := repo.Reference(branchRef, false); err == nil {
+		return fmt.Errorf("branch %s already exists", g.branchName)
+	} else if err != plumbing.ErrReferenceNotFound {
+		return fmt.Errorf("failed to check branch %s: %w", g.branchName, err)
 	}
 
 	// Remove any worktree-specific references
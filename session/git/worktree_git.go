@@ -9,6 +9,11 @@ import (
 
 // runGitCommand executes a git command and returns any error
 func (g *GitWorktree) runGitCommand(path string, args ...string) (string, error) {
+	return runGit(path, args...)
+}
+
+// runGit executes a git command in path and returns its combined output
+func runGit(path string, args ...string) (string, error) {
 	baseArgs := []string{"-C", path}
 	cmd := exec.Command("git", append(baseArgs, args...)...)
 
@@ -40,12 +45,36 @@ func (g *GitWorktree) PushChanges(commitMessage string, open bool) error {
 		}
 
 		// Create commit
-		if _, err := g.runGitCommand(g.worktreePath, "commit", "-m", commitMessage, "--no-verify"); err != nil {
+		if err := g.commit(commitMessage); err != nil {
 			log.ErrorLog.Print(err)
-			return fmt.Errorf("failed to commit changes: %w", err)
+			return err
 		}
 	}
 
+	return g.push(open)
+}
+
+// PushAcceptedHunks commits only the accepted hunks (see CommitHunks) and pushes the branch. The
+// remaining changes are left uncommitted in the worktree. It returns the number of hunks committed,
+// even if the push fails. Nothing is pushed if none of the accepted hunks are left to commit.
+func (g *GitWorktree) PushAcceptedHunks(commitMessage string, accepted map[string]bool, open bool) (int, error) {
+	if err := checkGHCLI(); err != nil {
+		return 0, err
+	}
+
+	count, err := g.CommitHunks(commitMessage, accepted)
+	if err != nil {
+		return 0, err
+	}
+	if count == 0 {
+		return 0, fmt.Errorf("none of the accepted hunks are uncommitted anymore, accept them again in the diff tab")
+	}
+
+	return count, g.push(open)
+}
+
+// push pushes the branch to the remote and optionally opens it in the browser
+func (g *GitWorktree) push(open bool) error {
 	// First push the branch to remote to ensure it exists
 	pushCmd := exec.Command("gh", "repo", "sync", "--source", "-b", g.branchName)
 	pushCmd.Dir = g.worktreePath
@@ -94,9 +123,9 @@ func (g *GitWorktree) CommitChanges(commitMessage string) error {
 		}
 
 		// Create commit (local only)
-		if _, err := g.runGitCommand(g.worktreePath, "commit", "-m", commitMessage, "--no-verify"); err != nil {
+		if err := g.commit(commitMessage); err != nil {
 			log.ErrorLog.Print(err)
-			return fmt.Errorf("failed to commit changes: %w", err)
+			return err
 		}
 	}
 
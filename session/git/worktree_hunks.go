@@ -0,0 +1,301 @@
+package git
+
+import (
+	"claude-squad/log"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// HunkKey identifies a hunk by its file and content, ignoring line numbers so that the key survives
+// edits elsewhere in the file.
+func HunkKey(path string, hunk Hunk) string {
+	h := sha1.New()
+	h.Write([]byte(path))
+	for _, line := range hunk.Lines {
+		h.Write([]byte{'\n'})
+		h.Write([]byte(line))
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// FileDiff returns the current diff of a single file against the diff baseline, or nil if the file
+// has no changes.
+func (g *GitWorktree) FileDiff(path string) (*FileDiff, error) {
+	base, _, err := g.resolveBaseline()
+	if err != nil {
+		return nil, err
+	}
+	content, err := g.runGitCommand(g.worktreePath, "--no-pager", "diff", base, "--", path)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range ParseDiff(content) {
+		if file.Path() == path {
+			return &file, nil
+		}
+	}
+	return nil, nil
+}
+
+// HasFileChanged reports whether the file's diff differs from the given, previously rendered one.
+// Callers use it to warn before acting on hunks the agent may have rewritten in the meantime.
+func (g *GitWorktree) HasFileChanged(file *FileDiff) (bool, error) {
+	current, err := g.FileDiff(file.Path())
+	if err != nil {
+		return false, fmt.Errorf("failed to diff %s: %w", file.Path(), err)
+	}
+	if current == nil {
+		return true, nil
+	}
+	return !reflect.DeepEqual(current.Hunks, file.Hunks), nil
+}
+
+// DiscardHunk removes a single hunk from the worktree by applying it in reverse. Added and deleted
+// files consist of a single hunk, so discarding it reverts the whole file.
+func (g *GitWorktree) DiscardHunk(file *FileDiff, hunk int) error {
+	if hunk < 0 || hunk >= len(file.Hunks) {
+		return fmt.Errorf("hunk %d out of range for %s", hunk, file.Path())
+	}
+	if file.OldPath == "/dev/null" || file.NewPath == "/dev/null" {
+		return g.RevertFile(file)
+	}
+
+	// Patch the file in place on its new path so reversing a hunk of a renamed file doesn't also
+	// undo the rename.
+	path := file.Path()
+	patch := fmt.Sprintf("diff --git %s %s\n--- %s\n+++ %s\n",
+		quotePatchPath("a/"+path), quotePatchPath("b/"+path),
+		quotePatchPath("a/"+path), quotePatchPath("b/"+path))
+	patch += hunkPatch(file.Hunks[hunk])
+
+	if err := g.applyPatch(patch, "-R"); err != nil {
+		return fmt.Errorf("failed to discard hunk in %s: %w", path, err)
+	}
+	return nil
+}
+
+// RevertFile restores a file to its content at the diff baseline, deleting it if it was added.
+func (g *GitWorktree) RevertFile(file *FileDiff) error {
+	base, _, err := g.resolveBaseline()
+	if err != nil {
+		return err
+	}
+	if file.OldPath != "/dev/null" {
+		if _, err := g.runGitCommand(g.worktreePath, "checkout", base, "--", file.OldPath); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", file.OldPath, err)
+		}
+	}
+	if file.NewPath != "/dev/null" && file.NewPath != file.OldPath {
+		// The file was added or renamed; drop the new path from the index and the worktree.
+		if _, err := g.runGitCommand(g.worktreePath, "rm", "-f", "-q", "--ignore-unmatch", "--", file.NewPath); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", file.NewPath, err)
+		}
+		if err := os.Remove(filepath.Join(g.worktreePath, file.NewPath)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", file.NewPath, err)
+		}
+	}
+	return nil
+}
+
+// CommitHunks commits only the uncommitted hunks whose HunkKey is in accepted. Other changes stay in
+// the worktree, unstaged. It returns the number of hunks committed.
+func (g *GitWorktree) CommitHunks(commitMessage string, accepted map[string]bool) (int, error) {
+	files, err := g.uncommittedDiff()
+	if err != nil {
+		return 0, err
+	}
+
+	var patch strings.Builder
+	count := 0
+	for _, file := range files {
+		if file.Binary {
+			continue
+		}
+		var hunks []Hunk
+		for _, hunk := range file.Hunks {
+			if accepted[HunkKey(file.Path(), hunk)] {
+				hunks = append(hunks, hunk)
+			}
+		}
+		if len(hunks) == 0 {
+			continue
+		}
+		for _, line := range file.Header {
+			patch.WriteString(line + "\n")
+		}
+		for _, hunk := range hunks {
+			patch.WriteString(hunkPatch(hunk))
+		}
+		count += len(hunks)
+	}
+	if count == 0 {
+		return 0, nil
+	}
+
+	// Start from a clean index so only the accepted hunks end up in the commit.
+	if _, err := g.runGitCommand(g.worktreePath, "reset", "-q"); err != nil {
+		return 0, fmt.Errorf("failed to reset index: %w", err)
+	}
+	if err := g.applyPatch(patch.String(), "--cached"); err != nil {
+		return 0, fmt.Errorf("failed to stage accepted hunks: %w", err)
+	}
+	if err := g.commit(commitMessage); err != nil {
+		log.ErrorLog.Print(err)
+		return 0, err
+	}
+	return count, nil
+}
+
+// UncommittedHunkKeys returns the HunkKey of every uncommitted hunk of path that overlaps hunk, which
+// may come from a diff against another baseline. These are the keys CommitHunks matches. There are
+// none if the hunk's changes were all committed already.
+func (g *GitWorktree) UncommittedHunkKeys(path string, hunk Hunk) ([]string, error) {
+	files, err := g.uncommittedDiff()
+	if err != nil {
+		return nil, err
+	}
+	lo, hi := changedSpan(hunk)
+	var keys []string
+	for _, file := range files {
+		if file.Path() != path {
+			continue
+		}
+		for _, uncommitted := range file.Hunks {
+			// Both diffs number new lines by the worktree file, so their changes can be compared.
+			if ulo, uhi := changedSpan(uncommitted); ulo <= hi && lo <= uhi {
+				keys = append(keys, HunkKey(path, uncommitted))
+			}
+		}
+	}
+	return keys, nil
+}
+
+// AllUncommittedHunkKeys returns the HunkKey of every uncommitted hunk in the worktree.
+func (g *GitWorktree) AllUncommittedHunkKeys() (map[string]bool, error) {
+	files, err := g.uncommittedDiff()
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[string]bool)
+	for _, file := range files {
+		for _, hunk := range file.Hunks {
+			keys[HunkKey(file.Path(), hunk)] = true
+		}
+	}
+	return keys, nil
+}
+
+// uncommittedDiff returns the diff of the worktree against HEAD, which is what CommitHunks commits
+// from.
+func (g *GitWorktree) uncommittedDiff() ([]FileDiff, error) {
+	// -N stages untracked files (intent to add), including them in the diff
+	if _, err := g.runGitCommand(g.worktreePath, "add", "-N", "."); err != nil {
+		return nil, fmt.Errorf("failed to stage untracked files: %w", err)
+	}
+	content, err := g.runGitCommand(g.worktreePath, "--no-pager", "diff", "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff uncommitted changes: %w", err)
+	}
+	return ParseDiff(content), nil
+}
+
+// changedSpan returns the first and last new line number touched by the hunk's changes, leaving out
+// its context. Removed lines count as the line they were removed before.
+func changedSpan(hunk Hunk) (lo, hi int) {
+	lo, hi = -1, -1
+	line := hunk.NewStart
+	for _, l := range hunk.Lines {
+		if l == "" {
+			continue
+		}
+		switch l[0] {
+		case '+', '-':
+			if lo < 0 {
+				lo = line
+			}
+			hi = line
+			if l[0] == '+' {
+				line++
+			}
+		case ' ':
+			line++
+		}
+	}
+	return lo, hi
+}
+
+// applyPatch runs git apply in the worktree with the given patch and extra arguments.
+func (g *GitWorktree) applyPatch(patch string, args ...string) error {
+	return g.applyPatchIn(g.worktreePath, patch, args...)
+}
+
+// applyPatchIn is applyPatch for the checkout at dir.
+func (g *GitWorktree) applyPatchIn(dir string, patch string, args ...string) error {
+	f, err := os.CreateTemp("", "claudesquad-*.patch")
+	if err != nil {
+		return fmt.Errorf("failed to create patch file: %w", err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(patch); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write patch file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write patch file: %w", err)
+	}
+
+	applyArgs := append([]string{"apply"}, args...)
+	applyArgs = append(applyArgs, f.Name())
+	_, err = g.runGitCommand(dir, applyArgs...)
+	return err
+}
+
+// hunkPatch renders a hunk back into unified diff form.
+func hunkPatch(hunk Hunk) string {
+	var b strings.Builder
+	b.WriteString(hunk.Header + "\n")
+	for _, line := range hunk.Lines {
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
+
+// quotePatchPath quotes a path the way git does when it contains characters git apply would
+// otherwise misread.
+func quotePatchPath(path string) string {
+	needsQuote := false
+	for i := 0; i < len(path); i++ {
+		if c := path[i]; c < 0x20 || c >= 0x7f || c == '"' || c == '\\' {
+			needsQuote = true
+			break
+		}
+	}
+	if !needsQuote {
+		return path
+	}
+
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(path); i++ {
+		switch c := path[i]; {
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c == '\t':
+			b.WriteString(`\t`)
+		case c == '\n':
+			b.WriteString(`\n`)
+		case c < 0x20 || c >= 0x7f:
+			fmt.Fprintf(&b, `\%03o`, c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -0,0 +1,164 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupHunkTestRepo creates a repository with a single commit and returns a GitWorktree that treats
+// the repository itself as the worktree.
+func setupHunkTestRepo(t *testing.T) *GitWorktree {
+	t.Helper()
+	dir := t.TempDir()
+	run := func(args ...string) string {
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+		return strings.TrimSpace(string(out))
+	}
+	run("init", "-q")
+	run("config", "user.email", "test@example.com")
+	run("config", "user.name", "Test")
+
+	var lines []string
+	for i := 1; i <= 20; i++ {
+		lines = append(lines, "line "+string(rune('a'+i-1)))
+	}
+	writeFile(t, dir, "file.txt", strings.Join(lines, "\n")+"\n")
+	run("add", ".")
+	run("commit", "-q", "-m", "initial")
+
+	return NewGitWorktreeFromStorage(dir, dir, "test", "main", run("rev-parse", "HEAD"), "")
+}
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+}
+
+func readFile(t *testing.T, dir, name string) string {
+	t.Helper()
+	content, err := os.ReadFile(filepath.Join(dir, name))
+	require.NoError(t, err)
+	return string(content)
+}
+
+// editTwoHunks changes the first and last line of file.txt, producing two separate hunks.
+func editTwoHunks(t *testing.T, g *GitWorktree) {
+	content := readFile(t, g.worktreePath, "file.txt")
+	content = strings.Replace(content, "line a\n", "line A\n", 1)
+	content = strings.Replace(content, "line t\n", "line T\n", 1)
+	writeFile(t, g.worktreePath, "file.txt", content)
+}
+
+func TestDiscardHunk(t *testing.T) {
+	g := setupHunkTestRepo(t)
+	editTwoHunks(t, g)
+
+	file, err := g.FileDiff("file.txt")
+	require.NoError(t, err)
+	require.NotNil(t, file)
+	require.Len(t, file.Hunks, 2)
+
+	changed, err := g.HasFileChanged(file)
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	require.NoError(t, g.DiscardHunk(file, 0))
+	content := readFile(t, g.worktreePath, "file.txt")
+	assert.Contains(t, content, "line a\n")
+	assert.Contains(t, content, "line T\n")
+
+	// The rendered diff is now stale.
+	changed, err = g.HasFileChanged(file)
+	require.NoError(t, err)
+	assert.True(t, changed)
+}
+
+func TestRevertFile(t *testing.T) {
+	g := setupHunkTestRepo(t)
+	editTwoHunks(t, g)
+	writeFile(t, g.worktreePath, "new.txt", "new\n")
+
+	stats := g.Diff()
+	require.NoError(t, stats.Error)
+	files := ParseDiff(stats.Content)
+	require.Len(t, files, 2)
+
+	for i := range files {
+		require.NoError(t, g.RevertFile(&files[i]))
+	}
+	assert.NotContains(t, readFile(t, g.worktreePath, "file.txt"), "line A")
+	_, err := os.Stat(filepath.Join(g.worktreePath, "new.txt"))
+	assert.True(t, os.IsNotExist(err))
+
+	stats = g.Diff()
+	require.NoError(t, stats.Error)
+	assert.True(t, stats.IsEmpty())
+}
+
+func TestCommitHunks(t *testing.T) {
+	g := setupHunkTestRepo(t)
+	editTwoHunks(t, g)
+
+	file, err := g.FileDiff("file.txt")
+	require.NoError(t, err)
+	require.NotNil(t, file)
+	accepted := map[string]bool{HunkKey(file.Path(), file.Hunks[1]): true}
+
+	count, err := g.CommitHunks("accepted only", accepted)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	committed, err := g.runGitCommand(g.worktreePath, "show", "HEAD:file.txt")
+	require.NoError(t, err)
+	assert.Contains(t, committed, "line a\n")
+	assert.Contains(t, committed, "line T\n")
+
+	// The unaccepted hunk is still in the worktree, uncommitted.
+	uncommitted, err := g.runGitCommand(g.worktreePath, "diff", "HEAD")
+	require.NoError(t, err)
+	assert.Contains(t, uncommitted, "+line A")
+	assert.NotContains(t, uncommitted, "+line T")
+}
+
+func TestUncommittedHunkKeys(t *testing.T) {
+	g := setupHunkTestRepo(t)
+	editTwoHunks(t, g)
+
+	file, err := g.FileDiff("file.txt")
+	require.NoError(t, err)
+	require.NotNil(t, file)
+	require.Len(t, file.Hunks, 2)
+	last := HunkKey(file.Path(), file.Hunks[1])
+	count, err := g.CommitHunks("last hunk", map[string]bool{last: true})
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+
+	// The diff against the base commit still shows both hunks, only the first is uncommitted.
+	file, err = g.FileDiff("file.txt")
+	require.NoError(t, err)
+	require.Len(t, file.Hunks, 2)
+
+	keys, err := g.UncommittedHunkKeys(file.Path(), file.Hunks[1])
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+
+	keys, err = g.UncommittedHunkKeys(file.Path(), file.Hunks[0])
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+
+	// Keys from the previous commit no longer match, the uncommitted ones do.
+	count, err = g.CommitHunks("stale", map[string]bool{last: true})
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+	count, err = g.CommitHunks("first hunk", map[string]bool{keys[0]: true})
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
@@ -32,42 +32,29 @@ func (g *GitWorktree) Setup() error {
 
 // SetupFromExistingBranch creates a worktree from an existing branch
 func (g *GitWorktree) SetupFromExistingBranch() error {
-	// Ensure worktrees directory exists
-	worktreesDir := filepath.Join(g.repoPath, "worktrees")
-	if err := os.MkdirAll(worktreesDir, 0755); err != nil {
+	// Ensure the parent directory of the worktree exists
+	if err := os.MkdirAll(filepath.Dir(g.worktreePath), 0755); err != nil {
 		return fmt.Errorf("failed to create worktrees directory: %w", err)
 	}
 
-	// Clean up any existing worktree first
-	_, _ = g.runGitCommand(g.repoPath, "worktree", "remove", "-f", g.worktreePath) // Ignore error if worktree doesn't exist
-
-	// Create a new worktree from the existing branch
-	if _, err := g.runGitCommand(g.repoPath, "worktree", "add", g.worktreePath, g.branchName); err != nil {
-		return fmt.Errorf("failed to create worktree from branch %s: %w", g.branchName, err)
-	}
-
-	return nil
+	return g.isolator().restore(g)
 }
 
 // SetupNewWorktree creates a new worktree from HEAD
 func (g *GitWorktree) SetupNewWorktree() error {
-	// Ensure worktrees directory exists
-	worktreesDir := filepath.Join(g.repoPath, "worktrees")
-	if err := os.MkdirAll(worktreesDir, 0755); err != nil {
+	// Ensure the parent directory of the worktree exists
+	if err := os.MkdirAll(filepath.Dir(g.worktreePath), 0755); err != nil {
 		return fmt.Errorf("failed to create worktrees directory: %w", err)
 	}
 
-	// Clean up any existing worktree first
-	_, _ = g.runGitCommand(g.repoPath, "worktree", "remove", "-f", g.worktreePath) // Ignore error if worktree doesn't exist
-
 	// Open the repository
 	repo, err := git.PlainOpen(g.repoPath)
 	if err != nil {
 		return fmt.Errorf("failed to open repository: %w", err)
 	}
 
-	// Clean up any existing branch or reference
-	if err := g.cleanupExistingBranch(repo); err != nil {
+	// Clean up leftovers of a previous branch with the same name
+	if err := g.cleanupStaleBranch(repo); err != nil {
 		return fmt.Errorf("failed to cleanup existing branch: %w", err)
 	}
 
@@ -82,15 +69,21 @@ func (g *GitWorktree) SetupNewWorktree() error {
 	}
 	headCommit := strings.TrimSpace(string(output))
 	g.baseCommitSHA = headCommit
+	// Remember the branch we started from so the diff can be compared against its tip later. A
+	// detached HEAD leaves it empty.
+	if branch, err := g.runGitCommand(g.repoPath, "symbolic-ref", "--short", "-q", "HEAD"); err == nil {
+		g.baseBranch = strings.TrimSpace(branch)
+	}
 
-	// Create a new worktree from the HEAD commit
-	// Otherwise, we'll inherit uncommitted changes from the previous worktree.
-	// This way, we can start the worktree with a clean slate.
 	// TODO: we might want to give an option to use main/master instead of the current branch.
-	if _, err := g.runGitCommand(g.repoPath, "worktree", "add", "-b", g.branchName, g.worktreePath, headCommit); err != nil {
-		return fmt.Errorf("failed to create worktree from commit %s: %w", headCommit, err)
+	if err := g.isolator().create(g, headCommit); err != nil {
+		return err
+	}
+	if g.carry != CarryNone {
+		if err := g.carryChanges(); err != nil {
+			return fmt.Errorf("failed to carry over uncommitted changes: %w", err)
+		}
 	}
-
 	return nil
 }
 
@@ -100,8 +93,8 @@ func (g *GitWorktree) Cleanup() error {
 
 	// Check if worktree path exists before attempting removal
 	if _, err := os.Stat(g.worktreePath); err == nil {
-		// Remove the worktree using git command
-		if _, err := g.runGitCommand(g.repoPath, "worktree", "remove", "-f", g.worktreePath); err != nil {
+		// Remove the checkout without saving its branch, which is deleted below
+		if err := g.isolator().remove(g, false); err != nil {
 			errs = append(errs, err)
 		}
 	} else if !os.IsNotExist(err) {
@@ -127,6 +120,11 @@ func (g *GitWorktree) Cleanup() error {
 		errs = append(errs, fmt.Errorf("error checking branch %s existence: %w", g.branchName, err))
 	}
 
+	// Drop uncommitted changes saved on pause along with the branch
+	if err := g.DeleteWIP(); err != nil {
+		errs = append(errs, err)
+	}
+
 	// Prune the worktree to clean up any remaining references
 	if err := g.Prune(); err != nil {
 		errs = append(errs, err)
@@ -141,12 +139,7 @@ func (g *GitWorktree) Cleanup() error {
 
 // Remove removes the worktree but keeps the branch
 func (g *GitWorktree) Remove() error {
-	// Remove the worktree using git command
-	if _, err := g.runGitCommand(g.repoPath, "worktree", "remove", "-f", g.worktreePath); err != nil {
-		return fmt.Errorf("failed to remove worktree: %w", err)
-	}
-
-	return nil
+	return g.isolator().remove(g, true)
 }
 
 // Prune removes all working tree administrative files and directories
@@ -0,0 +1,23 @@
+package git
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplatedWorktreePath(t *testing.T) {
+	path, err := templatedWorktreePath("../{{repo}}-wt/{{branch}}", "/src/app", "My Session", "1a2b3c", "me/my-session")
+	require.NoError(t, err)
+	assert.Equal(t, "/src/app-wt/me/my-session", path)
+
+	path, err = templatedWorktreePath("/tmp/wt/{{name}}", "/src/app", "My Session", "1a2b3c", "me/my-session")
+	require.NoError(t, err)
+	assert.Equal(t, "/tmp/wt/my-session", path)
+
+	path, err = templatedWorktreePath("~/wt/{{repo}}-{{id}}", "/src/app", "My Session", "1a2b3c", "me/my-session")
+	require.NoError(t, err)
+	assert.Equal(t, "app-1a2b3c", filepath.Base(path))
+}
@@ -0,0 +1,74 @@
+package session
+
+import (
+	"claude-squad/session/git"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAcceptedHunks(t *testing.T) {
+	dir := t.TempDir()
+	run := func(args ...string) string {
+		out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+		require.NoError(t, err, string(out))
+		return strings.TrimSpace(string(out))
+	}
+	run("init", "-q")
+	run("config", "user.email", "test@example.com")
+	run("config", "user.name", "Test")
+	var lines []string
+	for c := 'a'; c <= 't'; c++ {
+		lines = append(lines, "line "+string(c))
+	}
+	path := filepath.Join(dir, "file.txt")
+	require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644))
+	run("add", ".")
+	run("commit", "-q", "-m", "initial")
+
+	content := strings.Replace(strings.Join(lines, "\n")+"\n", "line a\n", "line A\n", 1)
+	content = strings.Replace(content, "line t\n", "line T\n", 1)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	worktree := git.NewGitWorktreeFromStorage(dir, dir, "test", "main", run("rev-parse", "HEAD"), "")
+	instance := &Instance{Status: Running, started: true, gitWorktree: worktree}
+	file, err := worktree.FileDiff("file.txt")
+	require.NoError(t, err)
+	require.Len(t, file.Hunks, 2)
+
+	accept := func(hunk git.Hunk) string {
+		key := git.HunkKey(file.Path(), hunk)
+		uncommitted, err := worktree.UncommittedHunkKeys(file.Path(), hunk)
+		require.NoError(t, err)
+		require.NotEmpty(t, uncommitted)
+		assert.True(t, instance.ToggleHunkAccepted(key, uncommitted))
+		return key
+	}
+
+	// Un-accepting a hunk drops everything it was accepted with.
+	first := accept(file.Hunks[0])
+	assert.False(t, instance.ToggleHunkAccepted(first, nil))
+	assert.Empty(t, instance.AcceptedHunks())
+	assert.Empty(t, instance.acceptedUncommitted())
+	assert.Empty(t, instance.ToInstanceData().AcceptedHunkKeys)
+
+	// Only the hunk still accepted is committed.
+	second := accept(file.Hunks[1])
+	require.NoError(t, instance.PruneAcceptedHunks())
+	assert.Equal(t, map[string]bool{second: true}, instance.AcceptedHunks())
+	count, err := worktree.CommitHunks("accepted", instance.acceptedUncommitted())
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	committed := run("show", "HEAD:file.txt")
+	assert.Contains(t, committed, "line a\n")
+	assert.Contains(t, committed, "line T")
+
+	// Once committed, the hunk is no longer pushable and is pruned.
+	require.NoError(t, instance.PruneAcceptedHunks())
+	assert.Empty(t, instance.AcceptedHunks())
+}
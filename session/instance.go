@@ -1,17 +1,20 @@
 package session
 
 import (
+	"claude-squad/config"
 	"claude-squad/log"
 	"claude-squad/session/git"
+	"claude-squad/session/snapshot"
 	"claude-squad/session/tmux"
+	"crypto/rand"
+	"encoding/hex"
 	"path/filepath"
+	"slices"
+	"sync"
 
 	"fmt"
 	"os"
-	"strings"
 	"time"
-
-	"github.com/atotto/clipboard"
 )
 
 type Status int
@@ -25,11 +28,16 @@ const (
 	Loading
 	// Paused is if the instance is paused (worktree removed but branch preserved).
 	Paused
+	// Frozen is if the agent's processes are stopped while its worktree and tmux session are kept.
+	Frozen
 )
 
 // Instance is a running instance of claude code.
 type Instance struct {
-	// Title is the title of the instance.
+	// ID uniquely identifies the instance. Unlike the title it never changes, so it names the tmux
+	// session, the worktree and the stored state.
+	ID string
+	// Title is the title of the instance. It is only a display label and can be changed any time.
 	Title string
 	// Path is the path to the workspace.
 	Path string
@@ -52,8 +60,18 @@ type Instance struct {
 	// Prompt is the initial prompt to pass to the instance on startup
 	Prompt string
 
-	// DiffStats stores the current git diff statistics
+	// DiffStats stores the current git diff statistics. The diff content itself lives in the diff
+	// cache, see DiffContent.
 	diffStats *git.DiffStats
+	// diffFingerprint identifies the worktree state diffStats were computed from.
+	diffFingerprint string
+	// acceptedHunks maps the git.HunkKey of every hunk marked as accepted in the diff tab to the keys
+	// of the uncommitted hunks it covers. When non-empty, pushing commits only the uncommitted hunks.
+	acceptedHunks map[string][]string
+	// reviewComments holds the line comments left in the diff tab that have not been sent yet.
+	reviewComments []ReviewComment
+	// carryChanges selects what happens to the main checkout's uncommitted changes on the first start.
+	carryChanges git.CarryMode
 
 	// The below fields are initialized upon calling Start().
 
@@ -62,11 +80,20 @@ type Instance struct {
 	tmuxSession *tmux.TmuxSession
 	// gitWorktree is the git worktree for the instance.
 	gitWorktree *git.GitWorktree
+	// snapshot isolates instances working on a directory that isn't a git repository. Exactly one of
+	// gitWorktree and snapshot is set.
+	snapshot *snapshot.Snapshot
+
+	// killedMu guards killed, which Kill sets off the UI goroutine.
+	killedMu sync.Mutex
+	// killed is set once Kill removed the instance's resources.
+	killed bool
 }
 
 // ToInstanceData converts an Instance to its serializable form
 func (i *Instance) ToInstanceData() InstanceData {
 	data := InstanceData{
+		ID:        i.ID,
 		Title:     i.Title,
 		Path:      i.Path,
 		Branch:    i.Branch,
@@ -79,6 +106,13 @@ func (i *Instance) ToInstanceData() InstanceData {
 		AutoYes:   i.AutoYes,
 	}
 
+	if i.snapshot != nil {
+		data.Snapshot = &SnapshotData{
+			SourcePath: i.snapshot.GetSourcePath(),
+			Dir:        i.snapshot.GetDir(),
+		}
+	}
+
 	// Only include worktree data if gitWorktree is initialized
 	if i.gitWorktree != nil {
 		data.Worktree = GitWorktreeData{
@@ -87,15 +121,32 @@ func (i *Instance) ToInstanceData() InstanceData {
 			SessionName:   i.Title,
 			BranchName:    i.gitWorktree.GetBranchName(),
 			BaseCommitSHA: i.gitWorktree.GetBaseCommitSHA(),
+			BaseBranch:    i.gitWorktree.GetBaseBranch(),
+			SparsePaths:   i.gitWorktree.GetSparsePaths(),
+			Isolation:     string(i.gitWorktree.GetIsolation()),
+		}
+		if checkout := i.gitWorktree.GetRepoCheckout(); checkout != nil {
+			data.Worktree.RepoCheckout = &RepoCheckoutData{
+				Previous: checkout.Previous,
+				Detached: checkout.Detached,
+				Stash:    checkout.Stash,
+			}
 		}
 	}
 
+	if len(i.acceptedHunks) > 0 {
+		data.AcceptedHunkKeys = make(map[string][]string, len(i.acceptedHunks))
+		for key, uncommitted := range i.acceptedHunks {
+			data.AcceptedHunkKeys[key] = slices.Clone(uncommitted)
+		}
+	}
+	data.ReviewComments = i.ReviewComments()
+
 	// Only include diff stats if they exist
 	if i.diffStats != nil {
 		data.DiffStats = DiffStatsData{
 			Added:   i.diffStats.Added,
 			Removed: i.diffStats.Removed,
-			Content: i.diffStats.Content,
 		}
 	}
 
@@ -105,6 +156,7 @@ func (i *Instance) ToInstanceData() InstanceData {
 // FromInstanceData creates a new Instance from serialized data
 func FromInstanceData(data InstanceData) (*Instance, error) {
 	instance := &Instance{
+		ID:        data.ID,
 		Title:     data.Title,
 		Path:      data.Path,
 		Branch:    data.Branch,
@@ -114,27 +166,56 @@ func FromInstanceData(data InstanceData) (*Instance, error) {
 		CreatedAt: data.CreatedAt,
 		UpdatedAt: data.UpdatedAt,
 		Program:   data.Program,
-		gitWorktree: git.NewGitWorktreeFromStorage(
+		diffStats: &git.DiffStats{
+			Added:   data.DiffStats.Added,
+			Removed: data.DiffStats.Removed,
+		},
+	}
+	if data.Snapshot != nil {
+		instance.snapshot = snapshot.FromStorage(data.Snapshot.SourcePath, data.Snapshot.Dir)
+	} else {
+		instance.gitWorktree = git.NewGitWorktreeFromStorage(
 			data.Worktree.RepoPath,
 			data.Worktree.WorktreePath,
 			data.Worktree.SessionName,
 			data.Worktree.BranchName,
 			data.Worktree.BaseCommitSHA,
-		),
-		diffStats: &git.DiffStats{
-			Added:   data.DiffStats.Added,
-			Removed: data.DiffStats.Removed,
-			Content: data.DiffStats.Content,
-		},
+			data.Worktree.BaseBranch,
+		)
+		instance.gitWorktree.SetSessionID(data.ID)
+		instance.gitWorktree.SetAgent(data.Program)
+		instance.gitWorktree.SetSparsePaths(data.Worktree.SparsePaths)
+		instance.gitWorktree.SetIsolation(git.Isolation(data.Worktree.Isolation))
+		if checkout := data.Worktree.RepoCheckout; checkout != nil {
+			instance.gitWorktree.SetRepoCheckout(&git.RepoCheckout{
+				Previous: checkout.Previous,
+				Detached: checkout.Detached,
+				Stash:    checkout.Stash,
+			})
+		}
+	}
+	for key, uncommitted := range data.AcceptedHunkKeys {
+		instance.ToggleHunkAccepted(key, uncommitted)
+	}
+	// Older versions stored only the keys, which were those of uncommitted hunks.
+	for _, key := range data.AcceptedHunks {
+		if !instance.IsHunkAccepted(key) {
+			instance.ToggleHunkAccepted(key, []string{key})
+		}
 	}
+	instance.reviewComments = data.ReviewComments
 
 	if instance.Paused() {
 		instance.started = true
-		instance.tmuxSession = tmux.NewTmuxSession(instance.Title, instance.Program)
+		instance.tmuxSession = tmux.NewTmuxSession(instance.ID, instance.Program)
 	} else {
 		if err := instance.Start(false); err != nil {
 			return nil, err
 		}
+		if data.Status == Frozen {
+			// The processes are still stopped in the tmux session.
+			instance.SetStatus(Frozen)
+		}
 	}
 
 	return instance, nil
@@ -162,6 +243,7 @@ func NewInstance(opts InstanceOptions) (*Instance, error) {
 	}
 
 	return &Instance{
+		ID:        newInstanceID(),
 		Title:     opts.Title,
 		Status:    Ready,
 		Path:      absPath,
@@ -174,13 +256,48 @@ func NewInstance(opts InstanceOptions) (*Instance, error) {
 	}, nil
 }
 
+// newInstanceID returns a random ID for a new instance.
+func newInstanceID() string {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand doesn't fail on supported platforms, but the time is unique enough as well.
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
 func (i *Instance) RepoName() (string, error) {
 	if !i.started {
 		return "", fmt.Errorf("cannot get repo name for instance that has not been started")
 	}
+	if i.snapshot != nil {
+		return filepath.Base(i.snapshot.GetSourcePath()), nil
+	}
 	return i.gitWorktree.GetRepoName(), nil
 }
 
+// IsSnapshot returns true if the instance works on a snapshot of a directory that isn't a git
+// repository, rather than on a git worktree.
+func (i *Instance) IsSnapshot() bool {
+	return i.snapshot != nil
+}
+
+// workspacePath returns the directory the agent works in.
+func (i *Instance) workspacePath() string {
+	if i.snapshot != nil {
+		return i.snapshot.GetWorkPath()
+	}
+	return i.gitWorktree.GetWorktreePath()
+}
+
+// cleanupWorkspace removes the instance's worktree or snapshot.
+func (i *Instance) cleanupWorkspace() error {
+	if i.snapshot != nil {
+		return i.snapshot.Cleanup()
+	}
+	return i.gitWorktree.Cleanup()
+}
+
 func (i *Instance) SetStatus(status Status) {
 	i.Status = status
 }
@@ -190,6 +307,9 @@ func (i *Instance) Start(firstTimeSetup bool) error {
 	if i.Title == "" {
 		return fmt.Errorf("instance title cannot be empty")
 	}
+	if i.ID == "" {
+		i.ID = newInstanceID()
+	}
 
 	var tmuxSession *tmux.TmuxSession
 	if i.tmuxSession != nil {
@@ -197,15 +317,23 @@ func (i *Instance) Start(firstTimeSetup bool) error {
 		tmuxSession = i.tmuxSession
 	} else {
 		// Create new tmux session
-		tmuxSession = tmux.NewTmuxSession(i.Title, i.Program)
+		tmuxSession = tmux.NewTmuxSession(i.ID, i.Program)
 	}
 	i.tmuxSession = tmuxSession
 
-	if firstTimeSetup {
-		gitWorktree, branchName, err := git.NewGitWorktree(i.Path, i.Title)
+	if firstTimeSetup && !git.IsGitRepo(i.Path) {
+		s, err := snapshot.New(i.Path, i.ID)
+		if err != nil {
+			return fmt.Errorf("failed to create snapshot: %w", err)
+		}
+		i.snapshot = s
+	} else if firstTimeSetup {
+		gitWorktree, branchName, err := git.NewGitWorktree(i.Path, i.Title, i.ID)
 		if err != nil {
 			return fmt.Errorf("failed to create git worktree: %w", err)
 		}
+		gitWorktree.SetAgent(i.Program)
+		gitWorktree.SetCarryChanges(i.carryChanges)
 		i.gitWorktree = gitWorktree
 		i.Branch = branchName
 	}
@@ -228,6 +356,16 @@ func (i *Instance) Start(firstTimeSetup bool) error {
 			setupErr = fmt.Errorf("failed to restore existing session: %w", err)
 			return setupErr
 		}
+	} else if i.snapshot != nil {
+		if err := i.snapshot.Setup(); err != nil {
+			setupErr = fmt.Errorf("failed to setup snapshot: %w", err)
+			return setupErr
+		}
+
+		if err := i.tmuxSession.Start(i.snapshot.GetWorkPath()); err != nil {
+			setupErr = fmt.Errorf("failed to start new session: %w", err)
+			return setupErr
+		}
 	} else {
 		// Setup git worktree first
 		if err := i.gitWorktree.Setup(); err != nil {
@@ -245,6 +383,7 @@ func (i *Instance) Start(firstTimeSetup bool) error {
 			return setupErr
 		}
 	}
+	i.startWindows()
 
 	i.SetStatus(Running)
 
@@ -260,6 +399,20 @@ func (i *Instance) Kill() error {
 
 	var errs []error
 
+	if i.Status == Frozen {
+		// Stopped processes don't handle the hangup of the closing session until they continue.
+		if err := i.Thaw(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if i.CheckedOut() {
+		// The branch can't be deleted while it is checked out.
+		if err := i.ReturnCheckout(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
 	// Always try to cleanup both resources, even if one fails
 	// Clean up tmux session first since it's using the git worktree
 	if i.tmuxSession != nil {
@@ -274,6 +427,19 @@ func (i *Instance) Kill() error {
 			errs = append(errs, fmt.Errorf("failed to cleanup git worktree: %w", err))
 		}
 	}
+	if i.snapshot != nil {
+		if err := i.snapshot.Cleanup(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	// Diffs computed in the meantime may still write the cache, ApplyDiff removes it again.
+	i.killedMu.Lock()
+	i.killed = true
+	i.killedMu.Unlock()
+	if err := i.removeDiffCache(); err != nil {
+		errs = append(errs, err)
+	}
 
 	return i.combineErrors(errs)
 }
@@ -298,7 +464,7 @@ func (i *Instance) Preview() (string, error) {
 	if !i.started || i.Status == Paused {
 		return "", nil
 	}
-	return i.tmuxSession.CapturePaneContent()
+	return i.tmuxSession.CaptureSelectedWindow()
 }
 
 func (i *Instance) HasUpdated() (updated bool, hasPrompt bool) {
@@ -338,6 +504,9 @@ func (i *Instance) GetGitWorktree() (*git.GitWorktree, error) {
 	if !i.started {
 		return nil, fmt.Errorf("cannot get git worktree for instance that has not been started")
 	}
+	if i.gitWorktree == nil {
+		return nil, fmt.Errorf("instance works on a snapshot of %s, not a git worktree", i.Path)
+	}
 	return i.gitWorktree, nil
 }
 
@@ -345,20 +514,130 @@ func (i *Instance) Started() bool {
 	return i.started
 }
 
-// SetTitle sets the title of the instance. Returns an error if the instance has started.
-// We cant change the title once it's been used for a tmux session etc.
+// SetTitle sets the title of the instance. The title is only a label, resources are named after the
+// ID, so it can be changed at any time. The branch keeps its name, see RenameBranch.
 func (i *Instance) SetTitle(title string) error {
-	if i.started {
-		return fmt.Errorf("cannot change title of a started instance")
-	}
 	i.Title = title
+	if i.gitWorktree != nil {
+		i.gitWorktree.SetSessionName(title)
+	}
 	return nil
 }
 
+// BranchNameFor returns the name the instance's branch would get if the instance was created with
+// the given title. It is empty for instances without a branch.
+func (i *Instance) BranchNameFor(title string) (string, error) {
+	if !i.started || i.gitWorktree == nil {
+		return "", nil
+	}
+	return i.gitWorktree.BranchNameFor(title)
+}
+
+// RenameBranch renames the instance's branch.
+func (i *Instance) RenameBranch(name string) error {
+	if !i.started || i.gitWorktree == nil {
+		return fmt.Errorf("instance has no branch to rename")
+	}
+	if err := i.gitWorktree.RenameBranch(name); err != nil {
+		return err
+	}
+	i.Branch = name
+	return nil
+}
+
+// SetCarryChanges sets whether the uncommitted changes of the main checkout are copied or moved into
+// the instance's worktree when it is started for the first time.
+func (i *Instance) SetCarryChanges(mode git.CarryMode) {
+	i.carryChanges = mode
+}
+
+// CarryChanges returns what happens to the main checkout's uncommitted changes on the first start.
+func (i *Instance) CarryChanges() git.CarryMode {
+	return i.carryChanges
+}
+
 func (i *Instance) Paused() bool {
 	return i.Status == Paused
 }
 
+// Frozen returns true if the agent's processes are stopped, see Freeze.
+func (i *Instance) Frozen() bool {
+	return i.Status == Frozen
+}
+
+// Freeze stops the agent's processes so they use no CPU and make no requests. Unlike Pause, the
+// worktree and the tmux session stay as they are, so Thaw continues the agent instantly.
+func (i *Instance) Freeze() error {
+	if !i.started {
+		return fmt.Errorf("cannot freeze instance that has not been started")
+	}
+	if i.Status == Paused || i.Status == Frozen {
+		return fmt.Errorf("can only freeze running instances")
+	}
+	if err := i.tmuxSession.Freeze(); err != nil {
+		return fmt.Errorf("failed to freeze session: %w", err)
+	}
+	i.SetStatus(Frozen)
+	return nil
+}
+
+// Thaw continues the processes of a frozen instance.
+func (i *Instance) Thaw() error {
+	if i.Status != Frozen {
+		return fmt.Errorf("can only thaw frozen instances")
+	}
+	if err := i.tmuxSession.Thaw(); err != nil {
+		return fmt.Errorf("failed to thaw session: %w", err)
+	}
+	i.SetStatus(Running)
+	return nil
+}
+
+// startWindows starts the extra tmux windows configured for the instance's repository. Failing to do
+// so doesn't affect the agent, so errors are only logged.
+func (i *Instance) startWindows() {
+	repoPath := i.Path
+	if i.gitWorktree != nil {
+		repoPath = i.gitWorktree.GetRepoPath()
+	} else if i.snapshot != nil {
+		repoPath = i.snapshot.GetSourcePath()
+	}
+	var windows []tmux.Window
+	for _, window := range config.LoadConfig().ForRepo(repoPath).Windows {
+		windows = append(windows, tmux.Window{Name: window.Name, Command: window.Command})
+	}
+	i.tmuxSession.SetWindows(i.workspacePath(), windows)
+	if err := i.tmuxSession.EnsureWindows(); err != nil {
+		log.ErrorLog.Print(err)
+	}
+}
+
+// Windows returns the names of the instance's tmux windows, starting with the agent's.
+func (i *Instance) Windows() []string {
+	if i.tmuxSession == nil {
+		return nil
+	}
+	return i.tmuxSession.WindowNames()
+}
+
+// SelectedWindow returns the name of the tmux window shown in the preview and on attach.
+func (i *Instance) SelectedWindow() string {
+	if i.tmuxSession == nil {
+		return tmux.AgentWindow
+	}
+	return i.tmuxSession.SelectedWindow()
+}
+
+// NextWindow selects the next tmux window for the preview and attach, wrapping around.
+func (i *Instance) NextWindow() error {
+	windows := i.Windows()
+	if len(windows) < 2 {
+		return fmt.Errorf("'%s' has no other windows, add them with windows in the config", i.Title)
+	}
+	next := windows[(slices.Index(windows, i.SelectedWindow())+1)%len(windows)]
+	return i.tmuxSession.SelectWindow(next)
+}
+
 // TmuxAlive returns true if the tmux session is alive. This is a sanity check before attaching.
 func (i *Instance) TmuxAlive() bool {
 	return i.tmuxSession.DoesSessionExist()
@@ -372,11 +651,35 @@ func (i *Instance) Pause() error {
 	if i.Status == Paused {
 		return fmt.Errorf("instance is already paused")
 	}
+	if i.Status == Frozen {
+		// The preserved session would otherwise come back stopped on resume.
+		if err := i.Thaw(); err != nil {
+			return err
+		}
+	}
+
+	if i.snapshot != nil {
+		// The snapshot stays on disk, there is nothing to preserve.
+		i.tmuxSession.CloseWindows()
+		if err := i.tmuxSession.DetachSafely(); err != nil {
+			log.ErrorLog.Print(err)
+			return fmt.Errorf("failed to detach tmux session: %w", err)
+		}
+		i.SetStatus(Paused)
+		return nil
+	}
 
 	var errs []error
 
-	// Check if there are any changes to commit
-	if dirty, err := i.gitWorktree.IsDirty(); err != nil {
+	if config.LoadConfig().ForRepo(i.gitWorktree.GetRepoPath()).PauseMode != "commit" {
+		// Keep the branch history free of work in progress, Resume restores it uncommitted.
+		if _, err := i.gitWorktree.SaveWIP(); err != nil {
+			log.ErrorLog.Print(err)
+			// Return early so the worktree isn't removed with the changes in it
+			return fmt.Errorf("failed to save uncommitted changes: %w", err)
+		}
+	} else if dirty, err := i.gitWorktree.IsDirty(); err != nil {
+		// Check if there are any changes to commit
 		errs = append(errs, fmt.Errorf("failed to check if worktree is dirty: %w", err))
 		log.ErrorLog.Print(err)
 	} else if dirty {
@@ -390,6 +693,8 @@ func (i *Instance) Pause() error {
 		}
 	}
 
+	// The extra windows would keep running in the removed worktree, Resume starts them again.
+	i.tmuxSession.CloseWindows()
 	// Detach from tmux session instead of closing to preserve session output
 	if err := i.tmuxSession.DetachSafely(); err != nil {
 		errs = append(errs, fmt.Errorf("failed to detach tmux session: %w", err))
@@ -420,10 +725,40 @@ func (i *Instance) Pause() error {
 	}
 
 	i.SetStatus(Paused)
-	_ = clipboard.WriteAll(i.gitWorktree.GetBranchName())
 	return nil
 }
 
+// CheckOut pauses the instance if needed and checks out its branch in the main repository, see
+// git.GitWorktree.CheckOutInRepo. Resume or ReturnCheckout switch the main checkout back.
+func (i *Instance) CheckOut() error {
+	if i.gitWorktree == nil {
+		return fmt.Errorf("only instances in git repositories can be checked out")
+	}
+	if i.Status != Paused {
+		if err := i.Pause(); err != nil {
+			return err
+		}
+	}
+	if err := i.gitWorktree.CheckOutInRepo(); err != nil {
+		return fmt.Errorf("failed to check out %s: %w", i.Branch, err)
+	}
+	return nil
+}
+
+// CheckedOut returns true if the instance's branch is checked out in the main repository by CheckOut.
+func (i *Instance) CheckedOut() bool {
+	return i.gitWorktree != nil && i.gitWorktree.GetRepoCheckout() != nil
+}
+
+// ReturnCheckout switches the main repository back to what was checked out before CheckOut. The
+// instance stays paused.
+func (i *Instance) ReturnCheckout() error {
+	if !i.CheckedOut() {
+		return fmt.Errorf("'%s' is not checked out", i.Title)
+	}
+	return i.gitWorktree.ReturnFromRepo()
+}
+
 // Resume recreates the worktree and restarts the tmux session
 func (i *Instance) Resume() error {
 	if !i.started {
@@ -433,6 +768,18 @@ func (i *Instance) Resume() error {
 		return fmt.Errorf("can only resume paused instances")
 	}
 
+	if i.snapshot != nil {
+		return i.resumeSnapshot()
+	}
+
+	if i.gitWorktree.GetRepoCheckout() != nil {
+		// Put the main checkout back the way it was before the branch was checked out there.
+		if err := i.gitWorktree.ReturnFromRepo(); err != nil {
+			log.ErrorLog.Print(err)
+			return fmt.Errorf("failed to return from checkout: %w", err)
+		}
+	}
+
 	// Check if branch is checked out
 	if checked, err := i.gitWorktree.IsBranchCheckedOut(); err != nil {
 		log.ErrorLog.Print(err)
@@ -441,11 +788,31 @@ func (i *Instance) Resume() error {
 		return fmt.Errorf("cannot resume: branch is checked out, please switch to a different branch")
 	}
 
+	// Move the worktree if the configured layout changed while the instance was paused
+	if migrated, err := i.gitWorktree.MigrateWorktreePath(); err != nil {
+		log.ErrorLog.Print(err)
+		return fmt.Errorf("failed to migrate worktree path: %w", err)
+	} else if migrated {
+		log.InfoLog.Printf("moved worktree of %s to %s", i.Title, i.gitWorktree.GetWorktreePath())
+		// The preserved tmux session still runs in the old directory, start a fresh one instead.
+		if i.tmuxSession.DoesSessionExist() {
+			if err := i.tmuxSession.Close(); err != nil {
+				log.ErrorLog.Print(err)
+			}
+		}
+	}
+
 	// Setup git worktree
 	if err := i.gitWorktree.Setup(); err != nil {
 		log.ErrorLog.Print(err)
 		return fmt.Errorf("failed to setup git worktree: %w", err)
 	}
+	// Bring back the uncommitted changes saved on pause. Failing to do so doesn't stop the resume,
+	// the changes stay in a ref.
+	wipErr := i.gitWorktree.RestoreWIP()
+	if wipErr != nil {
+		log.ErrorLog.Print(wipErr)
+	}
 
 	// Check if tmux session still exists from pause, otherwise create new one
 	if i.tmuxSession.DoesSessionExist() {
@@ -475,11 +842,55 @@ func (i *Instance) Resume() error {
 			return fmt.Errorf("failed to start new session: %w", err)
 		}
 	}
+	i.startWindows()
 
+	i.SetStatus(Running)
+	return wipErr
+}
+
+// resumeSnapshot restarts the tmux session of a snapshot instance. Unlike worktrees, the snapshot is
+// never cleaned up on failure, as it may hold changes that were not applied yet.
+func (i *Instance) resumeSnapshot() error {
+	if _, err := os.Stat(i.snapshot.GetWorkPath()); err != nil {
+		return fmt.Errorf("snapshot of %s is missing: %w", i.snapshot.GetSourcePath(), err)
+	}
+	restored := false
+	if i.tmuxSession.DoesSessionExist() {
+		if err := i.tmuxSession.Restore(); err != nil {
+			// Fall back to creating a new session
+			log.ErrorLog.Print(err)
+		} else {
+			restored = true
+		}
+	}
+	if !restored {
+		if err := i.tmuxSession.Start(i.snapshot.GetWorkPath()); err != nil {
+			log.ErrorLog.Print(err)
+			return fmt.Errorf("failed to start new session: %w", err)
+		}
+	}
+	i.startWindows()
 	i.SetStatus(Running)
 	return nil
 }
 
+// ApplyToSource copies the changes made in a snapshot instance back to the directory it was taken
+// from and returns the applied paths.
+func (i *Instance) ApplyToSource() ([]string, error) {
+	if !i.started || i.snapshot == nil {
+		return nil, fmt.Errorf("only instances working on a snapshot can apply changes to their source")
+	}
+	return i.snapshot.ApplyToSource()
+}
+
+// RevertSnapshotFile discards the changes to a file in a snapshot instance.
+func (i *Instance) RevertSnapshotFile(path string) error {
+	if !i.started || i.snapshot == nil {
+		return fmt.Errorf("instance does not work on a snapshot")
+	}
+	return i.snapshot.RevertFile(path)
+}
+
 // UpdateDiffStats updates the git diff statistics for this instance
 func (i *Instance) UpdateDiffStats() error {
 	if !i.started {
@@ -487,23 +898,33 @@ func (i *Instance) UpdateDiffStats() error {
 		return nil
 	}
 
-	if i.Status == Paused {
-		// Keep the previous diff stats if the instance is paused
-		return nil
-	}
+	return i.ApplyDiff(i.PrepareDiff("")())
+}
 
-	stats := i.gitWorktree.Diff()
-	if stats.Error != nil {
-		if strings.Contains(stats.Error.Error(), "base commit SHA not set") {
-			// Worktree is not fully set up yet, not an error
-			i.diffStats = nil
-			return nil
-		}
-		return fmt.Errorf("failed to get diff stats: %w", stats.Error)
+// CycleDiffBaseline switches the diff to the next baseline and returns it. The new diff is picked up
+// by the next diff computation.
+func (i *Instance) CycleDiffBaseline() (git.DiffBaseline, error) {
+	if i.snapshot != nil {
+		return git.BaselineBaseCommit, fmt.Errorf("snapshot instances are always diffed against the snapshot")
 	}
+	if !i.started || i.gitWorktree == nil {
+		return git.BaselineBaseCommit, fmt.Errorf("cannot change the diff baseline of an instance that has not been started")
+	}
+	baseline := i.gitWorktree.GetDiffBaseline().Next()
+	i.gitWorktree.SetDiffBaseline(baseline)
+	return baseline, nil
+}
 
-	i.diffStats = stats
-	return nil
+// WidenSparseCheckout adds directories to the instance's sparse checkout. For paused instances they
+// are checked out on resume.
+func (i *Instance) WidenSparseCheckout(paths []string) error {
+	if i.snapshot != nil {
+		return fmt.Errorf("sparse checkouts are only available for git repositories")
+	}
+	if !i.started || i.gitWorktree == nil {
+		return fmt.Errorf("cannot widen the checkout of an instance that has not been started")
+	}
+	return i.gitWorktree.WidenSparseCheckout(paths)
 }
 
 // GetDiffStats returns the current git diff statistics
@@ -511,6 +932,86 @@ func (i *Instance) GetDiffStats() *git.DiffStats {
 	return i.diffStats
 }
 
+// ToggleHunkAccepted marks or unmarks a hunk of the diff tab as accepted. key is its git.HunkKey and
+// uncommitted holds the keys of the uncommitted hunks it covers (see
+// git.GitWorktree.UncommittedHunkKeys), which are what pushing commits. Unmarking a hunk drops the
+// uncommitted keys it was accepted with. It returns whether the hunk is accepted afterwards.
+func (i *Instance) ToggleHunkAccepted(key string, uncommitted []string) bool {
+	if _, ok := i.acceptedHunks[key]; ok {
+		delete(i.acceptedHunks, key)
+		return false
+	}
+	if i.acceptedHunks == nil {
+		i.acceptedHunks = make(map[string][]string)
+	}
+	i.acceptedHunks[key] = slices.Clone(uncommitted)
+	return true
+}
+
+// PruneAcceptedHunks drops the uncommitted keys of accepted hunks that are no longer uncommitted,
+// e.g. because they were committed or changed since, and unmarks the hunks left without any. Paused
+// instances have no worktree to compare against, so their hunks are kept.
+func (i *Instance) PruneAcceptedHunks() error {
+	if len(i.acceptedHunks) == 0 || i.Paused() {
+		return nil
+	}
+	if !i.started || i.gitWorktree == nil {
+		return fmt.Errorf("cannot check the accepted hunks of an instance that has not been started")
+	}
+	current, err := i.gitWorktree.AllUncommittedHunkKeys()
+	if err != nil {
+		return err
+	}
+	for key, uncommitted := range i.acceptedHunks {
+		uncommitted = slices.DeleteFunc(uncommitted, func(k string) bool { return !current[k] })
+		if len(uncommitted) == 0 {
+			delete(i.acceptedHunks, key)
+		} else {
+			i.acceptedHunks[key] = uncommitted
+		}
+	}
+	return nil
+}
+
+// PushAcceptedHunks commits only the hunks accepted in the diff tab and pushes the branch. Once
+// committed, the hunks are no longer marked as accepted.
+func (i *Instance) PushAcceptedHunks(commitMessage string, open bool) error {
+	if !i.started || i.gitWorktree == nil {
+		return fmt.Errorf("cannot push an instance that has not been started")
+	}
+	count, err := i.gitWorktree.PushAcceptedHunks(commitMessage, i.acceptedUncommitted(), open)
+	if count > 0 {
+		i.acceptedHunks = nil
+	}
+	return err
+}
+
+// acceptedUncommitted returns the keys of the uncommitted hunks covered by the accepted hunks.
+func (i *Instance) acceptedUncommitted() map[string]bool {
+	keys := make(map[string]bool)
+	for _, uncommitted := range i.acceptedHunks {
+		for _, key := range uncommitted {
+			keys[key] = true
+		}
+	}
+	return keys
+}
+
+// IsHunkAccepted returns true if the hunk with the given git.HunkKey is marked as accepted.
+func (i *Instance) IsHunkAccepted(key string) bool {
+	_, ok := i.acceptedHunks[key]
+	return ok
+}
+
+// AcceptedHunks returns the keys of the hunks marked as accepted.
+func (i *Instance) AcceptedHunks() map[string]bool {
+	accepted := make(map[string]bool, len(i.acceptedHunks))
+	for key := range i.acceptedHunks {
+		accepted[key] = true
+	}
+	return accepted
+}
+
 // SendPrompt sends a prompt to the tmux session
 func (i *Instance) SendPrompt(prompt string) error {
 	if !i.started {
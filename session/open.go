@@ -0,0 +1,107 @@
+package session
+
+import (
+	"claude-squad/config"
+	"claude-squad/session/snapshot"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// OpenTarget is the worktree an open action opens, along with what its command template can refer to.
+type OpenTarget struct {
+	Dir    string
+	Branch string
+	Title  string
+	ID     string
+}
+
+// OpenTarget returns the instance's worktree for open actions. Paused instances have none.
+func (i *Instance) OpenTarget() (OpenTarget, error) {
+	if !i.started || i.Status == Paused {
+		return OpenTarget{}, fmt.Errorf("resume session '%s' to open its worktree", i.Title)
+	}
+	return OpenTarget{Dir: i.workspacePath(), Branch: i.Branch, Title: i.Title, ID: i.ID}, nil
+}
+
+// OpenTarget is Instance.OpenTarget for stored instances, which don't need to be loaded.
+func (d InstanceData) OpenTarget() (OpenTarget, error) {
+	if d.Status == Paused {
+		return OpenTarget{}, fmt.Errorf("resume session '%s' to open its worktree", d.Title)
+	}
+	dir := d.Worktree.WorktreePath
+	if d.Snapshot != nil {
+		// The snapshot directory also holds the pristine copy the diff is computed against.
+		dir = snapshot.FromStorage(d.Snapshot.SourcePath, d.Snapshot.Dir).GetWorkPath()
+	}
+	return OpenTarget{Dir: dir, Branch: d.Branch, Title: d.Title, ID: d.ID}, nil
+}
+
+// OpenCmd returns the command running action on target. Foreground commands, like editors, need the
+// terminal and must be run with it attached. The others only start something and return right away.
+func OpenCmd(action config.OpenAction, target OpenTarget) (cmd *exec.Cmd, foreground bool, err error) {
+	if _, err := os.Stat(target.Dir); err != nil {
+		return nil, false, fmt.Errorf("worktree of '%s' is missing: %w", target.Title, err)
+	}
+	command, err := config.ExpandTemplate(action.Command, map[string]string{
+		"worktree": shellQuote(target.Dir),
+		"branch":   shellQuote(target.Branch),
+		"title":    shellQuote(target.Title),
+		"id":       shellQuote(target.ID),
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	switch action.Type {
+	case config.OpenEditor:
+		editor := os.Getenv("VISUAL")
+		if editor == "" {
+			editor = os.Getenv("EDITOR")
+		}
+		if editor == "" {
+			editor = "vi"
+		}
+		// The editor may come with arguments, e.g. "code --wait".
+		cmd = exec.Command("sh", "-c", editor+" .")
+		foreground = true
+	case config.OpenCommand:
+		if command == "" {
+			return nil, false, fmt.Errorf("open action %q has no command", action.Name)
+		}
+		cmd = exec.Command("sh", "-c", command)
+	case config.OpenTmuxSplit, config.OpenTmuxWindow:
+		if os.Getenv("TMUX") == "" {
+			return nil, false, fmt.Errorf("open action %q only works when claude-squad runs inside tmux", action.Name)
+		}
+		args := []string{"split-window", "-h"}
+		if action.Type == config.OpenTmuxWindow {
+			args = []string{"new-window", "-n", target.Title}
+		}
+		args = append(args, "-c", target.Dir)
+		if command != "" {
+			args = append(args, command)
+		}
+		cmd = exec.Command("tmux", args...)
+	default:
+		return nil, false, fmt.Errorf("open action %q has unknown type %q", action.Name, action.Type)
+	}
+	cmd.Dir = target.Dir
+	return cmd, foreground, nil
+}
+
+// StartOpenCmd starts a background command returned by OpenCmd without waiting for it to exit.
+func StartOpenCmd(cmd *exec.Cmd) error {
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to run %s: %w", cmd.String(), err)
+	}
+	// Reap the process whenever it exits, e.g. when the IDE window is closed.
+	go func() { _ = cmd.Wait() }()
+	return nil
+}
+
+// shellQuote quotes s for use as a single word in a shell command.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
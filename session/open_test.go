@@ -0,0 +1,60 @@
+package session
+
+import (
+	"claude-squad/config"
+	"claude-squad/session/snapshot"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenCmd(t *testing.T) {
+	target := OpenTarget{Dir: t.TempDir(), Branch: "me/fix", Title: "it's a fix", ID: "abc"}
+
+	cmd, foreground, err := OpenCmd(config.OpenAction{Name: "code", Type: config.OpenCommand,
+		Command: "code {{worktree}} --title {{title}}"}, target)
+	require.NoError(t, err)
+	assert.False(t, foreground)
+	assert.Equal(t, []string{"sh", "-c", "code '" + target.Dir + `' --title 'it'\''s a fix'`}, cmd.Args)
+	assert.Equal(t, target.Dir, cmd.Dir)
+
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "nvim -p")
+	cmd, foreground, err = OpenCmd(config.OpenAction{Name: "editor", Type: config.OpenEditor}, target)
+	require.NoError(t, err)
+	assert.True(t, foreground)
+	assert.Equal(t, []string{"sh", "-c", "nvim -p ."}, cmd.Args)
+
+	t.Setenv("TMUX", "")
+	_, _, err = OpenCmd(config.OpenAction{Name: "shell", Type: config.OpenTmuxSplit}, target)
+	assert.Error(t, err, "tmux actions need claude-squad to run inside tmux")
+	t.Setenv("TMUX", "/tmp/tmux-1000/default,1,0")
+	cmd, _, err = OpenCmd(config.OpenAction{Name: "shell", Type: config.OpenTmuxSplit}, target)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tmux", "split-window", "-h", "-c", target.Dir}, cmd.Args)
+
+	_, _, err = OpenCmd(config.OpenAction{Name: "typo", Type: config.OpenCommand, Command: "code {{worktre}}"}, target)
+	assert.Error(t, err)
+	_, _, err = OpenCmd(config.OpenAction{Name: "code", Type: config.OpenEditor}, OpenTarget{Dir: "/does/not/exist"})
+	assert.Error(t, err)
+}
+
+func TestStoredOpenTarget(t *testing.T) {
+	data := InstanceData{ID: "abc", Title: "fix", Branch: "me/fix", Status: Running,
+		Worktree: GitWorktreeData{WorktreePath: "/worktrees/fix"}}
+	target, err := data.OpenTarget()
+	require.NoError(t, err)
+	assert.Equal(t, OpenTarget{Dir: "/worktrees/fix", Branch: "me/fix", Title: "fix", ID: "abc"}, target)
+
+	// Snapshot instances work on the work copy, not the directory that also holds the base copy.
+	data.Snapshot = &SnapshotData{SourcePath: "/src/notes", Dir: "/snapshots/fix"}
+	target, err = data.OpenTarget()
+	require.NoError(t, err)
+	assert.Equal(t, snapshot.FromStorage("/src/notes", "/snapshots/fix").GetWorkPath(), target.Dir)
+	assert.NotEqual(t, "/snapshots/fix", target.Dir)
+
+	data.Status = Paused
+	_, err = data.OpenTarget()
+	assert.Error(t, err)
+}
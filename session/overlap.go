@@ -0,0 +1,110 @@
+package session
+
+import (
+	"claude-squad/session/git"
+	"sort"
+)
+
+// FileOverlap is a file changed by more than one instance of the same repository.
+type FileOverlap struct {
+	// RepoPath is the repository the instances belong to.
+	RepoPath string
+	// Path is the path of the file in the repository.
+	Path string
+	// Instances are the instances changing the file, in the order they were passed to FindOverlaps.
+	Instances []*Instance
+	// SameLines is true if at least two of the instances change the same lines. Line ranges are only
+	// compared between instances diffed against the same base commit and baseline, so it may be false
+	// for instances whose changes do collide.
+	SameLines bool
+}
+
+// overlapSource is the changed files of one instance.
+type overlapSource struct {
+	instance *Instance
+	repoPath string
+	// base identifies what the line ranges are relative to, or "" if they can't be compared.
+	base   string
+	ranges map[string][]git.LineRange
+}
+
+// overlapSourceFor returns the changed files of the instance, or false if they aren't known.
+func overlapSourceFor(instance *Instance) (overlapSource, bool) {
+	stats := instance.diffStats
+	if !instance.started || stats == nil || stats.Error != nil || len(stats.Ranges) == 0 {
+		return overlapSource{}, false
+	}
+	source := overlapSource{instance: instance, ranges: stats.Ranges}
+	switch {
+	case instance.snapshot != nil:
+		// Each snapshot is taken at its own time, so its lines don't match up with other snapshots.
+		source.repoPath = instance.snapshot.GetSourcePath()
+	case instance.gitWorktree != nil:
+		source.repoPath = instance.gitWorktree.GetRepoPath()
+		source.base = instance.gitWorktree.GetBaseCommitSHA() + " " + stats.Baseline
+	default:
+		return overlapSource{}, false
+	}
+	return source, true
+}
+
+// FindOverlaps returns the files changed by more than one of the instances, comparing instances of
+// the same repository only. It uses the diff stats last computed for each instance, so it does no
+// I/O. The result is sorted by repository and path.
+func FindOverlaps(instances []*Instance) []FileOverlap {
+	byRepo := make(map[string][]overlapSource)
+	for _, instance := range instances {
+		if source, ok := overlapSourceFor(instance); ok {
+			byRepo[source.repoPath] = append(byRepo[source.repoPath], source)
+		}
+	}
+
+	var overlaps []FileOverlap
+	for repoPath, sources := range byRepo {
+		if len(sources) < 2 {
+			continue
+		}
+		byPath := make(map[string][]overlapSource)
+		for _, source := range sources {
+			for path := range source.ranges {
+				byPath[path] = append(byPath[path], source)
+			}
+		}
+		for path, changers := range byPath {
+			if len(changers) < 2 {
+				continue
+			}
+			overlap := FileOverlap{RepoPath: repoPath, Path: path}
+			for idx, source := range changers {
+				overlap.Instances = append(overlap.Instances, source.instance)
+				for _, other := range changers[:idx] {
+					if source.base != "" && source.base == other.base &&
+						rangesOverlap(source.ranges[path], other.ranges[path]) {
+						overlap.SameLines = true
+					}
+				}
+			}
+			overlaps = append(overlaps, overlap)
+		}
+	}
+
+	sort.Slice(overlaps, func(a, b int) bool {
+		if overlaps[a].RepoPath != overlaps[b].RepoPath {
+			return overlaps[a].RepoPath < overlaps[b].RepoPath
+		}
+		return overlaps[a].Path < overlaps[b].Path
+	})
+	return overlaps
+}
+
+// rangesOverlap reports whether any range of a overlaps any range of b.
+func rangesOverlap(a, b []git.LineRange) bool {
+	for _, ra := range a {
+		for _, rb := range b {
+			if ra.Overlaps(rb) {
+				return true
+			}
+		}
+	}
+	return false
+}
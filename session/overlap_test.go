@@ -0,0 +1,38 @@
+package session
+
+import (
+	"claude-squad/session/git"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindOverlaps(t *testing.T) {
+	instance := func(title, repo, base string, ranges map[string][]git.LineRange) *Instance {
+		return &Instance{
+			Title:       title,
+			started:     true,
+			gitWorktree: git.NewGitWorktreeFromStorage(repo, "/wt/"+title, title, title, base, "main"),
+			diffStats:   &git.DiffStats{Added: 1, Baseline: "base commit", Ranges: ranges},
+		}
+	}
+	a := instance("a", "/repo", "abc", map[string][]git.LineRange{
+		"main.go": {{Start: 10, End: 20}},
+		"util.go": {{Start: 1, End: 5}},
+	})
+	b := instance("b", "/repo", "abc", map[string][]git.LineRange{
+		"main.go": {{Start: 15, End: 16}},
+		"util.go": {{Start: 5, End: 9}},
+	})
+	c := instance("c", "/repo", "def", map[string][]git.LineRange{"util.go": {{Start: 1, End: 9}}})
+	other := instance("other", "/other", "abc", map[string][]git.LineRange{"main.go": {{Start: 10, End: 20}}})
+
+	overlaps := FindOverlaps([]*Instance{a, b, c, other})
+	require.Len(t, overlaps, 2)
+	assert.Equal(t, FileOverlap{RepoPath: "/repo", Path: "main.go", Instances: []*Instance{a, b}, SameLines: true}, overlaps[0])
+	// Adjacent ranges don't overlap and c's lines are relative to another base commit.
+	assert.Equal(t, FileOverlap{RepoPath: "/repo", Path: "util.go", Instances: []*Instance{a, b, c}}, overlaps[1])
+
+	assert.Empty(t, FindOverlaps([]*Instance{a, other}))
+}
@@ -0,0 +1,330 @@
+package session
+
+import (
+	"claude-squad/cmd"
+	"claude-squad/session/git"
+	"claude-squad/session/snapshot"
+	"claude-squad/session/tmux"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ResetOptions scopes what a reset removes. The zero value resets everything.
+type ResetOptions struct {
+	// RepoPath limits the reset to instances of this repository.
+	RepoPath string
+	// Title limits the reset to the instances with this title or ID.
+	Title string
+	// KeepBranches keeps the branches of removed instances.
+	KeepBranches bool
+}
+
+// Scoped returns true if the reset is limited to some instances.
+func (o ResetOptions) Scoped() bool {
+	return o.RepoPath != "" || o.Title != ""
+}
+
+// ResetTarget is one set of resources removed by a reset. Stored instances have a title; leftovers
+// of unknown instances, like stray tmux sessions or worktree directories, don't.
+type ResetTarget struct {
+	Title        string
+	ID           string
+	RepoPath     string
+	WorktreePath string
+	Branch       string
+	Session      string
+	// Isolation is how the checkout at WorktreePath is separated from the repository.
+	Isolation git.Isolation
+	// RepoCheckout is set if Branch is checked out in the repository, see git.RepoCheckout. The
+	// repository is switched back before anything is removed.
+	RepoCheckout *git.RepoCheckout
+	// Unpushed lists the commits of Branch that no remote has, which are lost if the branch is deleted.
+	Unpushed []string
+	// WIPRefs lists the refs holding uncommitted changes saved on pause, which are deleted along with
+	// the branch.
+	WIPRefs []string
+	// Dirty is true if the worktree has uncommitted changes, which are lost in any case, or WIPRefs
+	// isn't empty, or RepoChanges is set.
+	Dirty bool
+	// RepoChanges is true if Branch is checked out in the repository with uncommitted changes, which
+	// are lost along with the branch.
+	RepoChanges bool
+	// Snapshot is true if WorktreePath holds the snapshot of a directory without git, see
+	// snapshot.Snapshot. Its changes count as uncommitted.
+	Snapshot bool
+}
+
+// ResetPlan describes everything a reset removes. Build it with PlanReset, show it to the user and
+// then carry it out with Execute.
+type ResetPlan struct {
+	Options ResetOptions
+	Targets []ResetTarget
+	// stored holds the stored instance of each target with a title, by ID.
+	stored map[string]InstanceData
+
+	storage   *Storage
+	cmdExec   cmd.Executor
+	remaining []InstanceData
+}
+
+// PlanReset determines what a reset with the given options removes, without changing anything.
+func PlanReset(storage *Storage, cmdExec cmd.Executor, opts ResetOptions) (*ResetPlan, error) {
+	if opts.RepoPath != "" {
+		absPath, err := filepath.Abs(opts.RepoPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get absolute path of %s: %w", opts.RepoPath, err)
+		}
+		root, err := git.RepoRoot(absPath)
+		if err != nil {
+			return nil, err
+		}
+		opts.RepoPath = root
+	}
+
+	instances, err := storage.LoadInstanceData()
+	if err != nil {
+		return nil, err
+	}
+	plan := &ResetPlan{Options: opts, storage: storage, cmdExec: cmdExec, stored: make(map[string]InstanceData)}
+
+	covered := make(map[string]bool)
+	for _, data := range instances {
+		if !opts.matches(data) {
+			plan.remaining = append(plan.remaining, data)
+			continue
+		}
+		target := ResetTarget{
+			Title:        data.Title,
+			ID:           data.ID,
+			RepoPath:     data.Worktree.RepoPath,
+			WorktreePath: data.Worktree.WorktreePath,
+			Branch:       data.Worktree.BranchName,
+			Session:      tmux.SessionName(data.ID),
+			Isolation:    git.Isolation(data.Worktree.Isolation),
+		}
+		if checkout := data.Worktree.RepoCheckout; checkout != nil {
+			target.RepoCheckout = &git.RepoCheckout{
+				Previous: checkout.Previous,
+				Detached: checkout.Detached,
+				Stash:    checkout.Stash,
+			}
+		}
+		plan.stored[data.ID] = data
+		if data.Snapshot != nil {
+			target.RepoPath = data.Snapshot.SourcePath
+			target.WorktreePath = data.Snapshot.Dir
+			target.Snapshot = true
+		}
+		covered[filepath.Clean(target.WorktreePath)] = true
+		covered[target.Session] = true
+		plan.Targets = append(plan.Targets, plan.inspect(target))
+	}
+	if opts.Title != "" && len(plan.Targets) == 0 {
+		return nil, fmt.Errorf("no instance with title '%s'", opts.Title)
+	}
+	if opts.Scoped() {
+		return plan, nil
+	}
+
+	// An unscoped reset also removes leftovers that no stored instance accounts for.
+	sessions, err := tmux.ListSessions(cmdExec)
+	if err != nil {
+		return nil, err
+	}
+	for _, session := range sessions {
+		if !covered[session] {
+			plan.Targets = append(plan.Targets, ResetTarget{Session: session})
+		}
+	}
+	if dir, err := git.WorktreeDirectory(); err == nil {
+		entries, _ := os.ReadDir(dir)
+		for _, entry := range entries {
+			path := filepath.Join(dir, entry.Name())
+			if !entry.IsDir() || covered[path] {
+				continue
+			}
+			target := ResetTarget{WorktreePath: path, RepoPath: git.WorktreeRepo(path)}
+			if target.RepoPath != "" {
+				target.Branch = git.CurrentBranch(path)
+			}
+			plan.Targets = append(plan.Targets, plan.inspect(target))
+		}
+	}
+	return plan, nil
+}
+
+func (o ResetOptions) matches(data InstanceData) bool {
+	if o.Title != "" && data.Title != o.Title && data.ID != o.Title {
+		return false
+	}
+	if o.RepoPath != "" && (data.Snapshot != nil || filepath.Clean(data.Worktree.RepoPath) != o.RepoPath) {
+		return false
+	}
+	return true
+}
+
+// inspect records what would be lost by removing the target.
+func (p *ResetPlan) inspect(target ResetTarget) ResetTarget {
+	if target.Snapshot {
+		target.Dirty, _ = snapshot.FromStorage(target.RepoPath, target.WorktreePath).HasChanges()
+		return target
+	}
+	if target.RepoPath == "" || !git.IsGitRepo(target.RepoPath) {
+		return target
+	}
+	worktree := target.worktree()
+	if target.Branch != "" && !p.Options.KeepBranches {
+		target.Unpushed, _ = worktree.UnpushedCommits()
+	}
+	if _, err := os.Stat(target.WorktreePath); err == nil {
+		target.Dirty, _ = worktree.IsDirty()
+	}
+	// Switching back saves the changes made in the repository like the ones saved on pause.
+	if !p.Options.KeepBranches {
+		target.RepoChanges, _ = worktree.RepoCheckoutDirty()
+		target.Dirty = target.Dirty || target.RepoChanges
+	}
+	// Only stored instances have saved changes, leftovers can't be matched to theirs.
+	if target.Title != "" && !p.Options.KeepBranches {
+		target.WIPRefs, _ = worktree.WIPRefs()
+		target.Dirty = target.Dirty || len(target.WIPRefs) > 0
+	}
+	return target
+}
+
+// worktree returns the git worktree of the target, without its base commit.
+func (t ResetTarget) worktree() *git.GitWorktree {
+	worktree := git.NewGitWorktreeFromStorage(t.RepoPath, t.WorktreePath, t.Title, t.Branch, "", "")
+	worktree.SetSessionID(t.ID)
+	worktree.SetIsolation(t.Isolation)
+	worktree.SetRepoCheckout(t.RepoCheckout)
+	return worktree
+}
+
+// Empty returns true if the reset removes nothing.
+func (p *ResetPlan) Empty() bool {
+	return len(p.Targets) == 0
+}
+
+// AtRisk returns the targets whose removal loses work: unpushed commits or uncommitted changes.
+func (p *ResetPlan) AtRisk() []ResetTarget {
+	var targets []ResetTarget
+	for _, target := range p.Targets {
+		if len(target.Unpushed) > 0 || target.Dirty {
+			targets = append(targets, target)
+		}
+	}
+	return targets
+}
+
+// String lists everything the reset removes, one resource per line.
+func (p *ResetPlan) String() string {
+	var b strings.Builder
+	for _, target := range p.Targets {
+		if target.Title != "" {
+			fmt.Fprintf(&b, "instance '%s'\n", target.Title)
+		}
+		if target.RepoCheckout != nil {
+			fmt.Fprintf(&b, "  switch %s back to %s\n", target.RepoPath, target.RepoCheckout.Previous)
+		}
+		if target.Session != "" {
+			fmt.Fprintf(&b, "  tmux session %s\n", target.Session)
+		}
+		if target.WorktreePath != "" {
+			if _, err := os.Stat(target.WorktreePath); err == nil && target.Snapshot {
+				fmt.Fprintf(&b, "  snapshot %s\n", target.WorktreePath)
+			} else if err == nil {
+				fmt.Fprintf(&b, "  worktree %s\n", target.WorktreePath)
+			}
+		}
+		if target.Branch != "" && !p.Options.KeepBranches && target.RepoPath != "" && target.worktree().HasBranch() {
+			fmt.Fprintf(&b, "  branch %s in %s\n", target.Branch, target.RepoPath)
+		}
+		for _, ref := range target.WIPRefs {
+			fmt.Fprintf(&b, "  saved changes %s in %s\n", ref, target.RepoPath)
+		}
+	}
+	return b.String()
+}
+
+// Execute removes everything in the plan. It continues past failures and returns them combined.
+func (p *ResetPlan) Execute() error {
+	var errs []string
+	fail := func(err error) {
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	// Switch the repositories back first. Instances that are still checked out afterwards are kept,
+	// they are the only record of what to switch back to.
+	remaining := p.remaining
+	var targets []ResetTarget
+	for _, target := range p.Targets {
+		if target.RepoCheckout != nil && git.IsGitRepo(target.RepoPath) {
+			worktree := target.worktree()
+			if err := worktree.ReturnFromRepo(); err != nil {
+				fail(fmt.Errorf("failed to switch %s back from '%s': %w", target.RepoPath, target.Title, err))
+				if worktree.GetRepoCheckout() != nil {
+					remaining = append(remaining, p.stored[target.ID])
+					continue
+				}
+			}
+		}
+		targets = append(targets, target)
+	}
+
+	// Update the state first so that no instance is left pointing at removed resources.
+	fail(p.storage.SaveInstanceData(remaining))
+
+	live := make(map[string]bool)
+	if sessions, err := tmux.ListSessions(p.cmdExec); err == nil {
+		for _, session := range sessions {
+			live[session] = true
+		}
+	}
+	for _, target := range targets {
+		if target.Session != "" && live[target.Session] {
+			fail(tmux.KillSession(p.cmdExec, target.Session))
+		}
+		if target.Title != "" {
+			workspacePath := target.WorktreePath
+			if target.Snapshot {
+				workspacePath = snapshot.FromStorage(target.RepoPath, target.WorktreePath).GetWorkPath()
+			}
+			if path, err := diffCachePath(workspacePath); err == nil {
+				_ = os.Remove(path)
+			}
+		}
+		if target.RepoPath != "" && !target.Snapshot && git.IsGitRepo(target.RepoPath) {
+			if worktree := target.worktree(); worktree.GetIsolation() != git.IsolationWorktree && worktree.HasCheckout() {
+				// The branch of a clone or copy may have commits only in the checkout, save them first.
+				fail(worktree.Remove())
+			} else if target.WorktreePath != "" {
+				fail(git.RemoveWorktree(target.RepoPath, target.WorktreePath))
+			}
+			if target.Branch != "" && !p.Options.KeepBranches && git.BranchExists(target.RepoPath, target.Branch) {
+				fail(git.DeleteBranch(target.RepoPath, target.Branch))
+			}
+			for _, ref := range target.WIPRefs {
+				fail(git.DeleteWIPRef(target.RepoPath, ref))
+			}
+			if target.RepoCheckout != nil && target.Title != "" && !p.Options.KeepBranches {
+				// Switching back may have saved more changes.
+				fail(target.worktree().DeleteWIP())
+			}
+		} else if target.WorktreePath != "" && (target.Title == "" || target.Snapshot) {
+			// A snapshot, or a stray directory that isn't a worktree of any repository.
+			if err := os.RemoveAll(target.WorktreePath); err != nil {
+				fail(fmt.Errorf("failed to remove %s: %w", target.WorktreePath, err))
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("reset incomplete: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,282 @@
+package session
+
+import (
+	"claude-squad/cmd/cmd_test"
+	"claude-squad/session/git"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryInstanceStorage struct {
+	data json.RawMessage
+}
+
+func (m *memoryInstanceStorage) SaveInstances(data json.RawMessage) error {
+	m.data = data
+	return nil
+}
+
+func (m *memoryInstanceStorage) GetInstances() json.RawMessage {
+	if m.data == nil {
+		return json.RawMessage("[]")
+	}
+	return m.data
+}
+
+func (m *memoryInstanceStorage) DeleteAllInstances() error {
+	m.data = nil
+	return nil
+}
+
+func TestScopedReset(t *testing.T) {
+	repo := t.TempDir()
+	run := func(dir string, args ...string) {
+		out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	run(repo, "init", "-q")
+	run(repo, "config", "user.email", "test@example.com")
+	run(repo, "config", "user.name", "Test")
+	run(repo, "commit", "-q", "--allow-empty", "-m", "initial")
+
+	var instances []InstanceData
+	for _, title := range []string{"one", "two"} {
+		worktree := filepath.Join(t.TempDir(), title)
+		run(repo, "worktree", "add", "-q", "-b", "cs/"+title, worktree)
+		run(worktree, "commit", "-q", "--allow-empty", "-m", "work on "+title)
+		instances = append(instances, InstanceData{
+			Title:  title,
+			Status: Paused,
+			Worktree: GitWorktreeData{
+				RepoPath:     repo,
+				WorktreePath: worktree,
+				SessionName:  title,
+				BranchName:   "cs/" + title,
+			},
+		})
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(instances[0].Worktree.WorktreePath, "dirty.txt"), []byte("x"), 0644))
+
+	storage, err := NewStorage(&memoryInstanceStorage{})
+	require.NoError(t, err)
+	require.NoError(t, storage.SaveInstanceData(instances))
+	cmdExec := cmd_test.MockCmdExec{
+		RunFunc:    func(cmd *exec.Cmd) error { return nil },
+		OutputFunc: func(cmd *exec.Cmd) ([]byte, error) { return nil, fmt.Errorf("no tmux") },
+	}
+
+	_, err = PlanReset(storage, cmdExec, ResetOptions{Title: "missing"})
+	assert.Error(t, err)
+
+	plan, err := PlanReset(storage, cmdExec, ResetOptions{Title: "one"})
+	require.NoError(t, err)
+	require.Len(t, plan.Targets, 1)
+	target := plan.Targets[0]
+	assert.True(t, target.Dirty)
+	require.Len(t, target.Unpushed, 1)
+	assert.True(t, strings.HasSuffix(target.Unpushed[0], "work on one"))
+	assert.Contains(t, plan.String(), "branch cs/one")
+
+	// Keeping branches means no commits are lost, so they are not listed.
+	plan, err = PlanReset(storage, cmdExec, ResetOptions{Title: "one", KeepBranches: true})
+	require.NoError(t, err)
+	assert.Empty(t, plan.Targets[0].Unpushed)
+	assert.NotContains(t, plan.String(), "branch cs/one")
+
+	require.NoError(t, plan.Execute())
+	remaining, err := storage.LoadInstanceData()
+	require.NoError(t, err)
+	require.Len(t, remaining, 1)
+	assert.Equal(t, "two", remaining[0].Title)
+	_, err = os.Stat(instances[0].Worktree.WorktreePath)
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(instances[1].Worktree.WorktreePath)
+	assert.NoError(t, err)
+
+	out, err := exec.Command("git", "-C", repo, "branch", "--list", "cs/one").Output()
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "cs/one")
+}
+
+func TestResetSavedChanges(t *testing.T) {
+	repo := t.TempDir()
+	run := func(args ...string) string {
+		out, err := exec.Command("git", append([]string{"-C", repo}, args...)...).CombinedOutput()
+		require.NoError(t, err, string(out))
+		return strings.TrimSpace(string(out))
+	}
+	run("init", "-q")
+	run("config", "user.email", "test@example.com")
+	run("config", "user.name", "Test")
+	run("commit", "-q", "--allow-empty", "-m", "initial")
+	run("branch", "cs/paused")
+	// A paused instance has no worktree, its uncommitted changes live in refs.
+	head := run("rev-parse", "HEAD")
+	run("update-ref", "refs/claudesquad/wip/paused-id", head)
+	run("update-ref", "refs/claudesquad/wip/paused-id.1", head)
+
+	storage, err := NewStorage(&memoryInstanceStorage{})
+	require.NoError(t, err)
+	require.NoError(t, storage.SaveInstanceData([]InstanceData{{
+		ID:     "paused-id",
+		Title:  "paused",
+		Status: Paused,
+		Worktree: GitWorktreeData{
+			RepoPath:     repo,
+			WorktreePath: filepath.Join(t.TempDir(), "removed"),
+			SessionName:  "paused",
+			BranchName:   "cs/paused",
+		},
+	}}))
+	cmdExec := cmd_test.MockCmdExec{
+		RunFunc:    func(cmd *exec.Cmd) error { return nil },
+		OutputFunc: func(cmd *exec.Cmd) ([]byte, error) { return nil, fmt.Errorf("no tmux") },
+	}
+
+	// Kept branches keep their saved changes as well.
+	plan, err := PlanReset(storage, cmdExec, ResetOptions{Title: "paused", KeepBranches: true})
+	require.NoError(t, err)
+	assert.Empty(t, plan.AtRisk())
+
+	plan, err = PlanReset(storage, cmdExec, ResetOptions{Title: "paused"})
+	require.NoError(t, err)
+	require.Len(t, plan.AtRisk(), 1)
+	target := plan.AtRisk()[0]
+	assert.True(t, target.Dirty)
+	assert.Equal(t, []string{"refs/claudesquad/wip/paused-id", "refs/claudesquad/wip/paused-id.1"}, target.WIPRefs)
+	assert.Contains(t, plan.String(), "saved changes refs/claudesquad/wip/paused-id")
+
+	require.NoError(t, plan.Execute())
+	assert.Empty(t, run("for-each-ref", "refs/claudesquad/"))
+	assert.Empty(t, run("branch", "--list", "cs/paused"))
+}
+
+func TestResetClone(t *testing.T) {
+	repo := t.TempDir()
+	run := func(dir string, args ...string) string {
+		out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+		require.NoError(t, err, string(out))
+		return strings.TrimSpace(string(out))
+	}
+	run(repo, "init", "-q")
+	run(repo, "config", "user.email", "test@example.com")
+	run(repo, "config", "user.name", "Test")
+	run(repo, "commit", "-q", "--allow-empty", "-m", "initial")
+	// A running instance with clone isolation has its branch only in the clone.
+	clone := filepath.Join(t.TempDir(), "clone")
+	run(repo, "clone", "-q", "--no-checkout", "--reference", repo, "--origin", "source", repo, clone)
+	run(clone, "config", "include.path", filepath.Join(repo, ".git", "config"))
+	run(clone, "checkout", "-q", "--no-track", "-b", "cs/cloned", "HEAD")
+	run(clone, "commit", "-q", "--allow-empty", "-m", "work in clone")
+
+	storage, err := NewStorage(&memoryInstanceStorage{})
+	require.NoError(t, err)
+	require.NoError(t, storage.SaveInstanceData([]InstanceData{{
+		ID:     "cloned-id",
+		Title:  "cloned",
+		Status: Running,
+		Worktree: GitWorktreeData{
+			RepoPath:     repo,
+			WorktreePath: clone,
+			SessionName:  "cloned",
+			BranchName:   "cs/cloned",
+			Isolation:    "clone",
+		},
+	}}))
+	cmdExec := cmd_test.MockCmdExec{
+		RunFunc:    func(cmd *exec.Cmd) error { return nil },
+		OutputFunc: func(cmd *exec.Cmd) ([]byte, error) { return nil, fmt.Errorf("no tmux") },
+	}
+
+	plan, err := PlanReset(storage, cmdExec, ResetOptions{Title: "cloned"})
+	require.NoError(t, err)
+	require.Len(t, plan.AtRisk(), 1)
+	target := plan.AtRisk()[0]
+	require.Len(t, target.Unpushed, 1)
+	assert.True(t, strings.HasSuffix(target.Unpushed[0], "work in clone"))
+	assert.Contains(t, plan.String(), "branch cs/cloned")
+
+	// Keeping branches saves the branch of the clone to the repository before removing the clone.
+	plan, err = PlanReset(storage, cmdExec, ResetOptions{Title: "cloned", KeepBranches: true})
+	require.NoError(t, err)
+	assert.Empty(t, plan.AtRisk())
+	require.NoError(t, plan.Execute())
+	_, err = os.Stat(clone)
+	assert.True(t, os.IsNotExist(err))
+	assert.Equal(t, "work in clone", run(repo, "log", "-1", "--format=%s", "cs/cloned"))
+}
+
+func TestResetCheckedOut(t *testing.T) {
+	repo := t.TempDir()
+	run := func(args ...string) string {
+		out, err := exec.Command("git", append([]string{"-C", repo}, args...)...).CombinedOutput()
+		require.NoError(t, err, string(out))
+		return strings.TrimSpace(string(out))
+	}
+	run("init", "-q")
+	run("config", "user.email", "test@example.com")
+	run("config", "user.name", "Test")
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "file.txt"), []byte("base\n"), 0644))
+	run("add", ".")
+	run("commit", "-q", "-m", "initial")
+	previous := run("branch", "--show-current")
+	run("branch", "cs/out")
+
+	// The paused instance's branch is checked out in the repository, with the changes there stashed.
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "file.txt"), []byte("mine\n"), 0644))
+	worktree := git.NewGitWorktreeFromStorage(repo, filepath.Join(t.TempDir(), "removed"), "out", "cs/out", "", "")
+	worktree.SetSessionID("out-id")
+	require.NoError(t, worktree.CheckOutInRepo())
+	checkout := worktree.GetRepoCheckout()
+	require.NotNil(t, checkout)
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "file.txt"), []byte("on the branch\n"), 0644))
+
+	storage, err := NewStorage(&memoryInstanceStorage{})
+	require.NoError(t, err)
+	require.NoError(t, storage.SaveInstanceData([]InstanceData{{
+		ID:     "out-id",
+		Title:  "out",
+		Status: Paused,
+		Worktree: GitWorktreeData{
+			RepoPath:     repo,
+			WorktreePath: worktree.GetWorktreePath(),
+			SessionName:  "out",
+			BranchName:   "cs/out",
+			RepoCheckout: &RepoCheckoutData{
+				Previous: checkout.Previous,
+				Detached: checkout.Detached,
+				Stash:    checkout.Stash,
+			},
+		},
+	}}))
+	cmdExec := cmd_test.MockCmdExec{
+		RunFunc:    func(cmd *exec.Cmd) error { return nil },
+		OutputFunc: func(cmd *exec.Cmd) ([]byte, error) { return nil, fmt.Errorf("no tmux") },
+	}
+
+	plan, err := PlanReset(storage, cmdExec, ResetOptions{Title: "out"})
+	require.NoError(t, err)
+	require.Len(t, plan.AtRisk(), 1)
+	assert.True(t, plan.AtRisk()[0].RepoChanges)
+	assert.Contains(t, plan.String(), "switch "+repo+" back to "+previous)
+
+	require.NoError(t, plan.Execute())
+	assert.Equal(t, previous, run("branch", "--show-current"))
+	content, err := os.ReadFile(filepath.Join(repo, "file.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "mine\n", string(content))
+	assert.Empty(t, run("stash", "list"))
+	assert.Empty(t, run("branch", "--list", "cs/out"))
+	assert.Empty(t, run("for-each-ref", "refs/claudesquad/"))
+	remaining, err := storage.LoadInstanceData()
+	require.NoError(t, err)
+	assert.Empty(t, remaining)
+}
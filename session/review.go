@@ -0,0 +1,131 @@
+package session
+
+import (
+	"claude-squad/session/git"
+	"fmt"
+	"strings"
+)
+
+// reviewContextLines is the number of hunk lines included above and below a commented line.
+const reviewContextLines = 3
+
+// ReviewComment is a comment left on a line in the diff tab.
+type ReviewComment struct {
+	// Path is the path of the commented file.
+	Path string `json:"path"`
+	// Line is the line number in the new file, or in the old file if Removed is set.
+	Line int `json:"line"`
+	// Removed is true if the comment is on a removed line.
+	Removed bool `json:"removed,omitempty"`
+	// Body is the comment text.
+	Body string `json:"body"`
+	// Context is the part of the hunk surrounding the commented line, in unified diff form.
+	Context string `json:"context"`
+}
+
+// NewReviewComment creates a comment for line lineIdx of the given hunk. The body is left empty.
+func NewReviewComment(file *git.FileDiff, hunkIdx int, lineIdx int) (ReviewComment, error) {
+	if hunkIdx < 0 || hunkIdx >= len(file.Hunks) {
+		return ReviewComment{}, fmt.Errorf("hunk %d out of range for %s", hunkIdx, file.Path())
+	}
+	hunk := file.Hunks[hunkIdx]
+	if lineIdx < 0 || lineIdx >= len(hunk.Lines) {
+		return ReviewComment{}, fmt.Errorf("line %d out of range for hunk %s", lineIdx, hunk.Header)
+	}
+
+	comment := ReviewComment{Path: file.Path()}
+	oldNum, newNum := hunk.OldStart, hunk.NewStart
+	for i, line := range hunk.Lines {
+		if i == lineIdx {
+			comment.Removed = line[0] == '-'
+			comment.Line = newNum
+			if comment.Removed {
+				comment.Line = oldNum
+			}
+			break
+		}
+		switch line[0] {
+		case '+':
+			newNum++
+		case '-':
+			oldNum++
+		case ' ':
+			oldNum++
+			newNum++
+		}
+	}
+
+	start := max(lineIdx-reviewContextLines, 0)
+	end := min(lineIdx+reviewContextLines+1, len(hunk.Lines))
+	comment.Context = hunk.Header + "\n" + strings.Join(hunk.Lines[start:end], "\n")
+	return comment, nil
+}
+
+// Location returns the file:line the comment refers to.
+func (c ReviewComment) Location() string {
+	if c.Removed {
+		return fmt.Sprintf("%s:%d (removed line)", c.Path, c.Line)
+	}
+	return fmt.Sprintf("%s:%d", c.Path, c.Line)
+}
+
+// sameLine returns true if both comments are on the same line.
+func (c ReviewComment) sameLine(other ReviewComment) bool {
+	return c.Path == other.Path && c.Line == other.Line && c.Removed == other.Removed
+}
+
+// FormatReviewPrompt formats review comments into a prompt for the agent.
+func FormatReviewPrompt(comments []ReviewComment) string {
+	var b strings.Builder
+	b.WriteString("Please address the following review comments on your changes:\n")
+	for i, comment := range comments {
+		fmt.Fprintf(&b, "\n%d. %s\n", i+1, comment.Location())
+		fmt.Fprintf(&b, "Comment: %s\n", comment.Body)
+		if comment.Context != "" {
+			fmt.Fprintf(&b, "```diff\n%s\n```\n", comment.Context)
+		}
+	}
+	return b.String()
+}
+
+// SetReviewComment adds the comment to the pending review, replacing any comment on the same line.
+// A comment with an empty body removes the existing one.
+func (i *Instance) SetReviewComment(comment ReviewComment) {
+	comments := i.reviewComments[:0]
+	for _, existing := range i.reviewComments {
+		if !existing.sameLine(comment) {
+			comments = append(comments, existing)
+		}
+	}
+	if strings.TrimSpace(comment.Body) != "" {
+		comments = append(comments, comment)
+	}
+	i.reviewComments = comments
+}
+
+// ReviewComment returns the pending comment on the same line as the given one, if any.
+func (i *Instance) ReviewComment(at ReviewComment) (ReviewComment, bool) {
+	for _, existing := range i.reviewComments {
+		if existing.sameLine(at) {
+			return existing, true
+		}
+	}
+	return ReviewComment{}, false
+}
+
+// ReviewComments returns a copy of the pending review comments.
+func (i *Instance) ReviewComments() []ReviewComment {
+	return append([]ReviewComment(nil), i.reviewComments...)
+}
+
+// SubmitReview sends the pending review comments to the agent as a single prompt and clears them.
+func (i *Instance) SubmitReview() error {
+	if len(i.reviewComments) == 0 {
+		return fmt.Errorf("no review comments to submit")
+	}
+	if err := i.SendPrompt(FormatReviewPrompt(i.reviewComments)); err != nil {
+		return err
+	}
+	i.reviewComments = nil
+	return nil
+}
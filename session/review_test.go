@@ -0,0 +1,57 @@
+package session
+
+import (
+	"claude-squad/session/git"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewReviewComment(t *testing.T) {
+	file := &git.FileDiff{
+		OldPath: "main.go",
+		NewPath: "main.go",
+		Hunks: []git.Hunk{{
+			Header:   "@@ -10,4 +10,4 @@",
+			OldStart: 10,
+			NewStart: 10,
+			Lines:    []string{" a", "-b", "+B", " c", " d"},
+		}},
+	}
+
+	removed, err := NewReviewComment(file, 0, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "main.go:11 (removed line)", removed.Location())
+
+	added, err := NewReviewComment(file, 0, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "main.go:11", added.Location())
+
+	context, err := NewReviewComment(file, 0, 4)
+	require.NoError(t, err)
+	assert.Equal(t, "main.go:13", context.Location())
+	assert.Equal(t, "@@ -10,4 +10,4 @@\n-b\n+B\n c\n d", context.Context)
+
+	_, err = NewReviewComment(file, 0, 5)
+	assert.Error(t, err)
+
+	added.Body = "rename this"
+	prompt := FormatReviewPrompt([]ReviewComment{added})
+	assert.Contains(t, prompt, "1. main.go:11\nComment: rename this\n```diff\n")
+}
+
+func TestSetReviewComment(t *testing.T) {
+	instance := &Instance{}
+	instance.SetReviewComment(ReviewComment{Path: "a.go", Line: 1, Body: "first"})
+	instance.SetReviewComment(ReviewComment{Path: "a.go", Line: 2, Body: "second"})
+	instance.SetReviewComment(ReviewComment{Path: "a.go", Line: 1, Body: "edited"})
+	require.Len(t, instance.ReviewComments(), 2)
+	existing, ok := instance.ReviewComment(ReviewComment{Path: "a.go", Line: 1})
+	assert.True(t, ok)
+	assert.Equal(t, "edited", existing.Body)
+
+	// An empty body removes the comment.
+	instance.SetReviewComment(ReviewComment{Path: "a.go", Line: 2})
+	assert.Len(t, instance.ReviewComments(), 1)
+}
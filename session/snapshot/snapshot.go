@@ -0,0 +1,438 @@
+package snapshot
+
+import (
+	"bytes"
+	"claude-squad/config"
+	"claude-squad/session/git"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Snapshot isolates an instance working on a directory that isn't a git repository. The agent works
+// on a copy of the directory, and a second, pristine copy is kept to diff against. Changes are
+// applied back to the source directory on request instead of being pushed.
+type Snapshot struct {
+	// sourcePath is the directory the snapshot was taken from.
+	sourcePath string
+	// dir holds the work and base copies.
+	dir string
+}
+
+// New prepares a snapshot of sourcePath for the session. Call Setup to take it.
+func New(sourcePath string, sessionName string) (*Snapshot, error) {
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config directory: %w", err)
+	}
+	name := unsafeNameChars.ReplaceAllString(strings.ToLower(sessionName), "-")
+	dir := filepath.Join(configDir, "snapshots", fmt.Sprintf("%s_%x", name, time.Now().UnixNano()))
+	return &Snapshot{sourcePath: sourcePath, dir: dir}, nil
+}
+
+var unsafeNameChars = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+// FromStorage restores a snapshot that was already taken.
+func FromStorage(sourcePath string, dir string) *Snapshot {
+	return &Snapshot{sourcePath: sourcePath, dir: dir}
+}
+
+// GetSourcePath returns the directory the snapshot was taken from.
+func (s *Snapshot) GetSourcePath() string {
+	return s.sourcePath
+}
+
+// GetDir returns the directory holding the snapshot.
+func (s *Snapshot) GetDir() string {
+	return s.dir
+}
+
+// GetWorkPath returns the copy the agent works on.
+func (s *Snapshot) GetWorkPath() string {
+	return filepath.Join(s.dir, "work")
+}
+
+func (s *Snapshot) basePath() string {
+	return filepath.Join(s.dir, "base")
+}
+
+// Setup copies the source directory into the snapshot, leaving out the directories excluded in the
+// config. Sources larger than the configured limits are refused before anything is copied.
+func (s *Snapshot) Setup() error {
+	cfg := config.LoadConfig().Snapshot
+	exclude := make(map[string]bool)
+	for _, name := range cfg.GetExclude() {
+		exclude[name] = true
+	}
+	if err := checkSize(s.sourcePath, exclude, cfg.GetMaxFiles(), cfg.GetMaxSize()); err != nil {
+		return err
+	}
+
+	if err := os.RemoveAll(s.dir); err != nil {
+		return fmt.Errorf("failed to clean up snapshot directory: %w", err)
+	}
+	for _, dst := range []string{s.basePath(), s.GetWorkPath()} {
+		if err := copyTree(s.sourcePath, dst, exclude); err != nil {
+			return fmt.Errorf("failed to snapshot %s: %w", s.sourcePath, err)
+		}
+	}
+	return nil
+}
+
+// Cleanup deletes the snapshot. Changes that were not applied to the source are lost.
+func (s *Snapshot) Cleanup() error {
+	if err := os.RemoveAll(s.dir); err != nil {
+		return fmt.Errorf("failed to remove snapshot: %w", err)
+	}
+	return nil
+}
+
+// file is a regular file or symlink in one of the copies.
+type file struct {
+	mode    fs.FileMode
+	size    int64
+	modTime time.Time
+}
+
+// listFiles returns the regular files and symlinks under root by slash-separated relative path.
+func listFiles(root string) (map[string]file, error) {
+	files := make(map[string]file)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() && info.Mode()&fs.ModeSymlink == 0 {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = file{mode: info.Mode(), size: info.Size(), modTime: info.ModTime()}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files in %s: %w", root, err)
+	}
+	return files, nil
+}
+
+// readFile returns the content of a file, or the target of a symlink. A missing file reads as nil.
+func readFile(path string) ([]byte, bool, error) {
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if info.Mode()&fs.ModeSymlink != 0 {
+		target, err := os.Readlink(path)
+		return []byte(target), true, err
+	}
+	content, err := os.ReadFile(path)
+	return content, true, err
+}
+
+// Fingerprint returns a digest of the snapshot that changes whenever the diff can change. It only
+// looks at file metadata, so it is much cheaper than Diff.
+func (s *Snapshot) Fingerprint() (string, error) {
+	h := sha1.New()
+	for _, root := range []string{s.basePath(), s.GetWorkPath()} {
+		files, err := listFiles(root)
+		if err != nil {
+			return "", err
+		}
+		for _, path := range sortedPaths(files) {
+			f := files[path]
+			fmt.Fprintf(h, "%s\x00%d\x00%o\x00%d\x00", path, f.size, f.mode, f.modTime.UnixNano())
+		}
+		h.Write([]byte{1})
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func sortedPaths(files map[string]file) []string {
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// change is a file that differs between the base and the work copy.
+type change struct {
+	path string
+	// old and new hold the content on each side; oldExists and newExists are false for added and
+	// deleted files.
+	old, new             []byte
+	oldExists, newExists bool
+	oldMode, newMode     fs.FileMode
+}
+
+// changes compares the work copy against the base copy.
+func (s *Snapshot) changes() ([]change, error) {
+	baseFiles, err := listFiles(s.basePath())
+	if err != nil {
+		return nil, err
+	}
+	workFiles, err := listFiles(s.GetWorkPath())
+	if err != nil {
+		return nil, err
+	}
+
+	all := make(map[string]file)
+	for path, f := range baseFiles {
+		all[path] = f
+	}
+	for path, f := range workFiles {
+		all[path] = f
+	}
+
+	var changes []change
+	for _, path := range sortedPaths(all) {
+		c := change{path: path, oldMode: baseFiles[path].mode, newMode: workFiles[path].mode}
+		c.old, c.oldExists, err = readFile(filepath.Join(s.basePath(), path))
+		if err != nil {
+			return nil, err
+		}
+		c.new, c.newExists, err = readFile(filepath.Join(s.GetWorkPath(), path))
+		if err != nil {
+			return nil, err
+		}
+		if c.oldExists == c.newExists && gitMode(c.oldMode) == gitMode(c.newMode) && bytes.Equal(c.old, c.new) {
+			continue
+		}
+		changes = append(changes, c)
+	}
+	return changes, nil
+}
+
+// HasChanges returns true if the work copy differs from the base copy.
+func (s *Snapshot) HasChanges() (bool, error) {
+	changes, err := s.changes()
+	return len(changes) > 0, err
+}
+
+// Diff returns a git style unified diff of the work copy against the base copy.
+func (s *Snapshot) Diff() *git.DiffStats {
+	stats := &git.DiffStats{Baseline: "snapshot of " + s.sourcePath}
+	changes, err := s.changes()
+	if err != nil {
+		stats.Error = err
+		return stats
+	}
+
+	var b strings.Builder
+	for _, c := range changes {
+		fileStat := git.FileStat{Path: c.path}
+		fmt.Fprintf(&b, "diff --git a/%s b/%s\n", c.path, c.path)
+		switch {
+		case !c.oldExists:
+			fmt.Fprintf(&b, "new file mode %s\n", gitMode(c.newMode))
+		case !c.newExists:
+			fmt.Fprintf(&b, "deleted file mode %s\n", gitMode(c.oldMode))
+		case gitMode(c.oldMode) != gitMode(c.newMode):
+			fmt.Fprintf(&b, "old mode %s\nnew mode %s\n", gitMode(c.oldMode), gitMode(c.newMode))
+		}
+
+		oldName, newName := "a/"+c.path, "b/"+c.path
+		if !c.oldExists {
+			oldName = "/dev/null"
+		}
+		if !c.newExists {
+			newName = "/dev/null"
+		}
+		if isBinary(c.old) || isBinary(c.new) {
+			fmt.Fprintf(&b, "Binary files %s and %s differ\n", oldName, newName)
+			fileStat.Binary = true
+		} else if !bytes.Equal(c.old, c.new) {
+			hunks, added, removed := unifiedHunks(diffLines(splitLines(c.old), splitLines(c.new)))
+			fmt.Fprintf(&b, "--- %s\n+++ %s\n%s", oldName, newName, hunks)
+			fileStat.Added, fileStat.Removed = added, removed
+			stats.Added += added
+			stats.Removed += removed
+		}
+		stats.Files = append(stats.Files, fileStat)
+	}
+	stats.Content = b.String()
+	return stats
+}
+
+func gitMode(mode fs.FileMode) string {
+	switch {
+	case mode&fs.ModeSymlink != 0:
+		return "120000"
+	case mode&0111 != 0:
+		return "100755"
+	}
+	return "100644"
+}
+
+// RevertFile restores a file in the work copy to its state in the base copy.
+func (s *Snapshot) RevertFile(path string) error {
+	if err := syncFile(s.basePath(), s.GetWorkPath(), path); err != nil {
+		return fmt.Errorf("failed to revert %s: %w", path, err)
+	}
+	return nil
+}
+
+// ApplyToSource copies the changes in the work copy to the source directory and makes them part of
+// the base copy, so the diff only shows later changes. If a changed file was also modified in the
+// source directory since the snapshot was taken, nothing is applied. It returns the applied paths.
+func (s *Snapshot) ApplyToSource() ([]string, error) {
+	changes, err := s.changes()
+	if err != nil {
+		return nil, err
+	}
+
+	var conflicts []string
+	for _, c := range changes {
+		current, exists, err := readFile(filepath.Join(s.sourcePath, c.path))
+		if err != nil {
+			return nil, err
+		}
+		if exists != c.oldExists || !bytes.Equal(current, c.old) {
+			conflicts = append(conflicts, c.path)
+		}
+	}
+	if len(conflicts) > 0 {
+		return nil, fmt.Errorf("files changed in %s since the snapshot was taken: %s",
+			s.sourcePath, strings.Join(conflicts, ", "))
+	}
+
+	var applied []string
+	for _, c := range changes {
+		if err := syncFile(s.GetWorkPath(), s.sourcePath, c.path); err != nil {
+			return applied, fmt.Errorf("failed to apply %s: %w", c.path, err)
+		}
+		if err := syncFile(s.GetWorkPath(), s.basePath(), c.path); err != nil {
+			return applied, fmt.Errorf("failed to update snapshot of %s: %w", c.path, err)
+		}
+		applied = append(applied, c.path)
+	}
+	return applied, nil
+}
+
+// syncFile makes the file at path under dstRoot match the one under srcRoot, deleting it if it doesn't
+// exist under srcRoot.
+func syncFile(srcRoot, dstRoot, path string) error {
+	src := filepath.Join(srcRoot, filepath.FromSlash(path))
+	dst := filepath.Join(dstRoot, filepath.FromSlash(path))
+
+	info, err := os.Lstat(src)
+	if os.IsNotExist(err) {
+		if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return copyFile(src, dst, info)
+}
+
+// copyTree copies the directory src to dst, preserving file modes and symlinks.
+// checkSize returns an error if the files under root, outside of excluded directories, exceed
+// maxFiles or maxSize bytes. It stops walking as soon as a limit is hit, so it is cheap even for huge
+// directories.
+func checkSize(root string, exclude map[string]bool, maxFiles int, maxSize int64) error {
+	files, size := 0, int64(0)
+	var limitErr error
+	err := walkTree(root, exclude, func(path string, d fs.DirEntry) error {
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files++
+		size += info.Size()
+		switch {
+		case files > maxFiles:
+			limitErr = fmt.Errorf("%s has more than %d files, too many to snapshot: start claude-squad in a smaller directory or a git repository, or raise snapshot.max_files in the config", root, maxFiles)
+		case size > maxSize:
+			limitErr = fmt.Errorf("%s holds more than %d MB, too much to snapshot: start claude-squad in a smaller directory or a git repository, or raise snapshot.max_size_mb in the config", root, maxSize>>20)
+		default:
+			return nil
+		}
+		return filepath.SkipAll
+	})
+	if err != nil {
+		return fmt.Errorf("failed to snapshot %s: %w", root, err)
+	}
+	return limitErr
+}
+
+// walkTree is filepath.WalkDir without the directories whose name is in exclude.
+func walkTree(root string, exclude map[string]bool, fn func(path string, d fs.DirEntry) error) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && path != root && exclude[d.Name()] {
+			return filepath.SkipDir
+		}
+		return fn(path, d)
+	})
+}
+
+func copyTree(src, dst string, exclude map[string]bool) error {
+	return walkTree(src, exclude, func(path string, d fs.DirEntry) error {
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return os.MkdirAll(target, info.Mode().Perm()|0700)
+		}
+		return copyFile(path, target, info)
+	})
+}
+
+func copyFile(src, dst string, info fs.FileInfo) error {
+	switch {
+	case info.Mode()&fs.ModeSymlink != 0:
+		target, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(target, dst)
+	case info.Mode().IsRegular():
+		content, err := os.ReadFile(src)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(dst, content, info.Mode().Perm())
+	}
+	// Sockets, devices and the like are not part of the snapshot.
+	return nil
+}
@@ -0,0 +1,195 @@
+package snapshot
+
+import (
+	"claude-squad/config"
+	"claude-squad/session/git"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+}
+
+func numberedLines(n int) string {
+	var b strings.Builder
+	for i := 1; i <= n; i++ {
+		b.WriteString(strings.Repeat("x", i%7) + "\n")
+	}
+	return b.String()
+}
+
+func TestSnapshotDiff(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	source := t.TempDir()
+	long := numberedLines(40)
+	writeFile(t, source, "long.txt", long)
+	writeFile(t, source, "gone.txt", "bye\n")
+	writeFile(t, source, "eol.txt", "no newline")
+	writeFile(t, source, "sub/same.txt", "same\n")
+
+	s, err := New(source, "My Task")
+	require.NoError(t, err)
+	require.NoError(t, s.Setup())
+	stats := s.Diff()
+	require.NoError(t, stats.Error)
+	assert.True(t, stats.IsEmpty())
+	before, err := s.Fingerprint()
+	require.NoError(t, err)
+
+	work := s.GetWorkPath()
+	lines := strings.SplitAfter(long, "\n")
+	lines[2] = "changed near the top\n"
+	lines[30] = "changed near the bottom\n"
+	lines = append(lines[:20], append([]string{"inserted\n"}, lines[20:]...)...)
+	writeFile(t, work, "long.txt", strings.Join(lines, ""))
+	require.NoError(t, os.Remove(filepath.Join(work, "gone.txt")))
+	writeFile(t, work, "eol.txt", "no newline\n")
+	writeFile(t, work, "sub/new.txt", "hello\n")
+
+	after, err := s.Fingerprint()
+	require.NoError(t, err)
+	assert.NotEqual(t, before, after)
+
+	stats = s.Diff()
+	require.NoError(t, stats.Error)
+	files := git.ParseDiff(stats.Content)
+	require.Len(t, files, 4)
+	byPath := map[string]git.FileDiff{}
+	for _, f := range files {
+		byPath[f.Path()] = f
+	}
+	assert.Len(t, byPath["long.txt"].Hunks, 3)
+	assert.Equal(t, "/dev/null", byPath["gone.txt"].NewPath)
+	assert.Equal(t, "/dev/null", byPath["sub/new.txt"].OldPath)
+	assert.Equal(t, 5, stats.Added)
+	assert.Equal(t, 4, stats.Removed)
+
+	// git must be able to apply the diff to the original files and end up with the work copy.
+	check := t.TempDir()
+	require.NoError(t, copyTree(source, check, nil))
+	patch := filepath.Join(t.TempDir(), "snapshot.patch")
+	require.NoError(t, os.WriteFile(patch, []byte(stats.Content), 0644))
+	cmd := exec.Command("git", "apply", patch)
+	cmd.Dir = check
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+	for _, name := range []string{"long.txt", "eol.txt", "sub/new.txt"} {
+		want, _ := os.ReadFile(filepath.Join(work, name))
+		got, _ := os.ReadFile(filepath.Join(check, name))
+		assert.Equal(t, string(want), string(got), name)
+	}
+
+	require.NoError(t, s.RevertFile("eol.txt"))
+	assert.Len(t, git.ParseDiff(s.Diff().Content), 3)
+}
+
+func TestSnapshotApplyToSource(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	source := t.TempDir()
+	writeFile(t, source, "a.txt", "a\n")
+	writeFile(t, source, "b.txt", "b\n")
+
+	s, err := New(source, "apply")
+	require.NoError(t, err)
+	require.NoError(t, s.Setup())
+	writeFile(t, s.GetWorkPath(), "a.txt", "a2\n")
+	writeFile(t, s.GetWorkPath(), "c.txt", "c\n")
+
+	// A file changed on both sides is a conflict, and nothing is applied.
+	writeFile(t, source, "a.txt", "edited in source\n")
+	_, err = s.ApplyToSource()
+	assert.ErrorContains(t, err, "a.txt")
+	_, err = os.Stat(filepath.Join(source, "c.txt"))
+	assert.True(t, os.IsNotExist(err))
+
+	writeFile(t, source, "a.txt", "a\n")
+	applied, err := s.ApplyToSource()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.txt", "c.txt"}, applied)
+	content, err := os.ReadFile(filepath.Join(source, "c.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "c\n", string(content))
+	assert.True(t, s.Diff().IsEmpty())
+
+	require.NoError(t, s.Cleanup())
+	_, err = os.Stat(s.GetDir())
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestSnapshotLimits(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	configDir, err := config.GetConfigDir()
+	require.NoError(t, err)
+	writeFile(t, configDir, config.ConfigFileName, `{"snapshot": {"max_files": 3}}`)
+
+	source := t.TempDir()
+	writeFile(t, source, "a.txt", "a\n")
+	writeFile(t, source, "b.txt", "b\n")
+	// Excluded directories neither count nor get copied.
+	for _, name := range []string{"one", "two", "three", "four"} {
+		writeFile(t, source, "node_modules/"+name+".js", name)
+	}
+
+	s, err := New(source, "limits")
+	require.NoError(t, err)
+	require.NoError(t, s.Setup())
+	_, err = os.Stat(filepath.Join(s.GetWorkPath(), "node_modules"))
+	assert.True(t, os.IsNotExist(err))
+	assert.True(t, s.Diff().IsEmpty())
+
+	writeFile(t, source, "c.txt", "c\n")
+	writeFile(t, source, "sub/d.txt", "d\n")
+	s, err = New(source, "too many")
+	require.NoError(t, err)
+	assert.ErrorContains(t, s.Setup(), "snapshot.max_files")
+	_, err = os.Stat(s.GetDir())
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestSnapshotLargeDiff(t *testing.T) {
+	// More changed lines than maxEditDistance are shown as replacing the whole section.
+	var a, b []string
+	for i := 0; i < 2*maxEditDistance; i++ {
+		a = append(a, fmt.Sprintf("old %d\n", i))
+		b = append(b, fmt.Sprintf("new %d\n", i))
+	}
+	edits := diffLines(a, b)
+	require.Len(t, edits, len(a)+len(b))
+	assert.Equal(t, edit{'-', "old 0\n"}, edits[0])
+	assert.Equal(t, edit{'+', "new 0\n"}, edits[len(a)])
+
+	// Fewer changes still get the shortest edit script.
+	b = append([]string(nil), a...)
+	for i := 0; i < len(b); i += 100 {
+		b[i] = "changed\n"
+	}
+	_, added, removed := unifiedHunks(diffLines(a, b))
+	assert.Equal(t, len(a)/100, added)
+	assert.Equal(t, len(a)/100, removed)
+
+	// Files above maxDiffFileSize are not diffed line by line.
+	t.Setenv("HOME", t.TempDir())
+	source := t.TempDir()
+	big := strings.Repeat(numberedLines(100), maxDiffFileSize/300)
+	writeFile(t, source, "big.txt", big)
+	s, err := New(source, "large")
+	require.NoError(t, err)
+	require.NoError(t, s.Setup())
+	writeFile(t, s.GetWorkPath(), "big.txt", big+"more\n")
+	stats := s.Diff()
+	require.NoError(t, stats.Error)
+	require.Len(t, stats.Files, 1)
+	assert.True(t, stats.Files[0].Binary)
+	assert.Contains(t, stats.Content, "Binary files a/big.txt and b/big.txt differ")
+}
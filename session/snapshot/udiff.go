@@ -0,0 +1,226 @@
+package snapshot
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// contextLines is the number of unchanged lines shown around each change, like git diff.
+const contextLines = 3
+
+// edit is one line of an edit script: ' ' keeps a line, '-' removes it and '+' adds it.
+type edit struct {
+	op   byte
+	line string
+}
+
+// maxDiffFileSize is the size above which files are not diffed line by line but reported as binary,
+// like git does for files above core.bigFileThreshold.
+const maxDiffFileSize = 1 << 20
+
+// isBinary reports whether content looks binary, using the same heuristic as git: a NUL byte in the
+// first 8000 bytes. Files above maxDiffFileSize count as binary as well.
+func isBinary(content []byte) bool {
+	if len(content) > maxDiffFileSize {
+		return true
+	}
+	if len(content) > 8000 {
+		content = content[:8000]
+	}
+	return bytes.IndexByte(content, 0) >= 0
+}
+
+// splitLines splits content into lines that keep their trailing newline, so that a missing newline at
+// the end of the file counts as a change.
+func splitLines(content []byte) []string {
+	if len(content) == 0 {
+		return nil
+	}
+	lines := strings.SplitAfter(string(content), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines returns the shortest edit script turning a into b (Myers' algorithm).
+func diffLines(a, b []string) []edit {
+	// Common prefixes and suffixes are cheap to strip and usually make up most of the file.
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var edits []edit
+	for _, line := range a[:prefix] {
+		edits = append(edits, edit{' ', line})
+	}
+	edits = append(edits, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		edits = append(edits, edit{' ', line})
+	}
+	return edits
+}
+
+// maxEditDistance bounds the number of changed lines myers looks for. The rounds it saves for
+// backtracking grow with the square of that number, so files with more changes are shown as replaced
+// as a whole instead.
+const maxEditDistance = 1000
+
+func myers(a, b []string) []edit {
+	n, m := len(a), len(b)
+	total := n + m
+	if total == 0 {
+		return nil
+	}
+	maxD := min(total, maxEditDistance)
+	offset := maxD + 1
+	v := make([]int, 2*maxD+3)
+	// trace[d] holds the diagonals -d-1 to d+1 of v before round d, which is all backtrack reads.
+	var trace [][]int
+
+	for d := 0; d <= maxD; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d-1:offset+d+2]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, a, b)
+			}
+		}
+	}
+	return replaceAll(a, b)
+}
+
+// replaceAll returns the edit script removing all of a and adding all of b.
+func replaceAll(a, b []string) []edit {
+	edits := make([]edit, 0, len(a)+len(b))
+	for _, line := range a {
+		edits = append(edits, edit{'-', line})
+	}
+	for _, line := range b {
+		edits = append(edits, edit{'+', line})
+	}
+	return edits
+}
+
+// backtrack walks the saved Myers rounds back from the end to recover the edit script.
+func backtrack(trace [][]int, a, b []string) []edit {
+	x, y := len(a), len(b)
+	var edits []edit
+	for d := len(trace) - 1; d >= 0; d-- {
+		// trace[d] starts at diagonal -d-1.
+		v, offset := trace[d], d+1
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			edits = append(edits, edit{' ', a[x-1]})
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				edits = append(edits, edit{'+', b[y-1]})
+			} else {
+				edits = append(edits, edit{'-', a[x-1]})
+			}
+		}
+		x, y = prevX, prevY
+	}
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits
+}
+
+// unifiedHunks formats an edit script as unified diff hunks. It returns the hunks along with the
+// number of added and removed lines.
+func unifiedHunks(edits []edit) (string, int, int) {
+	var b strings.Builder
+	added, removed := 0, 0
+
+	// oldLine and newLine are the 1-based line numbers of edits[i] on each side.
+	oldLine := make([]int, len(edits)+1)
+	newLine := make([]int, len(edits)+1)
+	oldLine[0], newLine[0] = 1, 1
+	for i, e := range edits {
+		oldLine[i+1], newLine[i+1] = oldLine[i], newLine[i]
+		if e.op != '+' {
+			oldLine[i+1]++
+		}
+		if e.op != '-' {
+			newLine[i+1]++
+		}
+	}
+
+	for i := 0; i < len(edits); {
+		if edits[i].op == ' ' {
+			i++
+			continue
+		}
+		start := max(i-contextLines, 0)
+		// Extend the hunk while the next change is close enough that the context would overlap.
+		end := i
+		for j := i; j < len(edits); j++ {
+			if edits[j].op != ' ' {
+				end = j + 1
+			} else if j-end >= 2*contextLines {
+				break
+			}
+		}
+		end = min(end+contextLines, len(edits))
+
+		oldCount := oldLine[end] - oldLine[start]
+		newCount := newLine[end] - newLine[start]
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n", hunkRange(oldLine[start], oldCount), hunkRange(newLine[start], newCount))
+		for _, e := range edits[start:end] {
+			switch e.op {
+			case '+':
+				added++
+			case '-':
+				removed++
+			}
+			b.WriteByte(e.op)
+			b.WriteString(strings.TrimSuffix(e.line, "\n"))
+			b.WriteByte('\n')
+			if !strings.HasSuffix(e.line, "\n") {
+				b.WriteString("\\ No newline at end of file\n")
+			}
+		}
+		i = end
+	}
+	return b.String(), added, removed
+}
+
+// hunkRange formats one side of a hunk header. Empty ranges point at the line before them.
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start-1)
+	case 1:
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
@@ -9,6 +9,7 @@ import (
 
 // InstanceData represents the serializable data of an Instance
 type InstanceData struct {
+	ID        string    `json:"id,omitempty"`
 	Title     string    `json:"title"`
 	Path      string    `json:"path"`
 	Branch    string    `json:"branch"`
@@ -22,6 +23,15 @@ type InstanceData struct {
 	Program   string          `json:"program"`
 	Worktree  GitWorktreeData `json:"worktree"`
 	DiffStats DiffStatsData   `json:"diff_stats"`
+	// Snapshot is set instead of Worktree for instances working on a directory without git
+	Snapshot *SnapshotData `json:"snapshot,omitempty"`
+	// AcceptedHunkKeys maps the keys of the hunks marked as accepted in the diff tab to the keys of
+	// the uncommitted hunks they cover
+	AcceptedHunkKeys map[string][]string `json:"accepted_hunk_keys,omitempty"`
+	// AcceptedHunks holds the accepted hunk keys written by older versions. It is only read.
+	AcceptedHunks []string `json:"accepted_hunks,omitempty"`
+	// ReviewComments holds the review comments that have not been sent to the agent yet
+	ReviewComments []ReviewComment `json:"review_comments,omitempty"`
 }
 
 // GitWorktreeData represents the serializable data of a GitWorktree
@@ -31,13 +41,34 @@ type GitWorktreeData struct {
 	SessionName   string `json:"session_name"`
 	BranchName    string `json:"branch_name"`
 	BaseCommitSHA string `json:"base_commit_sha"`
+	BaseBranch    string `json:"base_branch,omitempty"`
+	// SparsePaths holds the directories checked out in the worktree, empty for a full checkout
+	SparsePaths []string `json:"sparse_paths,omitempty"`
+	// Isolation is how the checkout is separated from the main repository, see git.Isolation
+	Isolation string `json:"isolation,omitempty"`
+	// RepoCheckout is set while the branch is checked out in the main repository, see
+	// git.RepoCheckout
+	RepoCheckout *RepoCheckoutData `json:"repo_checkout,omitempty"`
 }
 
-// DiffStatsData represents the serializable data of a DiffStats
+// RepoCheckoutData represents the serializable data of a git.RepoCheckout
+type RepoCheckoutData struct {
+	Previous string `json:"previous"`
+	Detached bool   `json:"detached,omitempty"`
+	Stash    string `json:"stash,omitempty"`
+}
+
+// SnapshotData represents the serializable data of a snapshot.Snapshot
+type SnapshotData struct {
+	SourcePath string `json:"source_path"`
+	Dir        string `json:"dir"`
+}
+
+// DiffStatsData represents the serializable data of a DiffStats. The diff content is kept in the
+// diff cache rather than in the state file.
 type DiffStatsData struct {
-	Added   int    `json:"added"`
-	Removed int    `json:"removed"`
-	Content string `json:"content"`
+	Added   int `json:"added"`
+	Removed int `json:"removed"`
 }
 
 // Storage handles saving and loading instances using the state interface
@@ -73,11 +104,9 @@ func (s *Storage) SaveInstances(instances []*Instance) error {
 
 // LoadInstances loads the list of instances from disk
 func (s *Storage) LoadInstances() ([]*Instance, error) {
-	jsonData := s.state.GetInstances()
-
-	var instancesData []InstanceData
-	if err := json.Unmarshal(jsonData, &instancesData); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal instances: %w", err)
+	instancesData, err := s.LoadInstanceData()
+	if err != nil {
+		return nil, err
 	}
 
 	instances := make([]*Instance, len(instancesData))
@@ -92,8 +121,33 @@ func (s *Storage) LoadInstances() ([]*Instance, error) {
 	return instances, nil
 }
 
-// DeleteInstance removes an instance from storage
-func (s *Storage) DeleteInstance(title string) error {
+// LoadInstanceData loads the stored instances in their serialized form, without starting them
+func (s *Storage) LoadInstanceData() ([]InstanceData, error) {
+	var instancesData []InstanceData
+	if err := json.Unmarshal(s.state.GetInstances(), &instancesData); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal instances: %w", err)
+	}
+	for idx := range instancesData {
+		// Instances stored before IDs existed were identified by their title, which also named their
+		// tmux session. Using it as the ID keeps the session.
+		if instancesData[idx].ID == "" {
+			instancesData[idx].ID = instancesData[idx].Title
+		}
+	}
+	return instancesData, nil
+}
+
+// SaveInstanceData replaces the stored instances with the given serialized ones
+func (s *Storage) SaveInstanceData(instancesData []InstanceData) error {
+	jsonData, err := json.Marshal(instancesData)
+	if err != nil {
+		return fmt.Errorf("failed to marshal instances: %w", err)
+	}
+	return s.state.SaveInstances(jsonData)
+}
+
+// DeleteInstance removes the instance with the given ID from storage
+func (s *Storage) DeleteInstance(id string) error {
 	instances, err := s.LoadInstances()
 	if err != nil {
 		return fmt.Errorf("failed to load instances: %w", err)
@@ -102,8 +156,7 @@ func (s *Storage) DeleteInstance(title string) error {
 	found := false
 	newInstances := make([]*Instance, 0)
 	for _, instance := range instances {
-		data := instance.ToInstanceData()
-		if data.Title != title {
+		if instance.ID != id {
 			newInstances = append(newInstances, instance)
 		} else {
 			found = true
@@ -111,7 +164,7 @@ func (s *Storage) DeleteInstance(title string) error {
 	}
 
 	if !found {
-		return fmt.Errorf("instance not found: %s", title)
+		return fmt.Errorf("instance not found: %s", id)
 	}
 
 	return s.SaveInstances(newInstances)
@@ -124,11 +177,9 @@ func (s *Storage) UpdateInstance(instance *Instance) error {
 		return fmt.Errorf("failed to load instances: %w", err)
 	}
 
-	data := instance.ToInstanceData()
 	found := false
 	for i, existing := range instances {
-		existingData := existing.ToInstanceData()
-		if existingData.Title == data.Title {
+		if existing.ID == instance.ID {
 			instances[i] = instance
 			found = true
 			break
@@ -136,7 +187,7 @@ func (s *Storage) UpdateInstance(instance *Instance) error {
 	}
 
 	if !found {
-		return fmt.Errorf("instance not found: %s", data.Title)
+		return fmt.Errorf("instance not found: %s", instance.Title)
 	}
 
 	return s.SaveInstances(instances)
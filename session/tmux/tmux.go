@@ -12,6 +12,7 @@ import (
 	"os"
 	"os/exec"
 	"regexp"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -36,6 +37,12 @@ type TmuxSession struct {
 	// cmdExec is used to execute commands in the tmux session.
 	cmdExec cmd.Executor
 
+	// windows are the extra windows next to the program, see SetWindows. They are started in workDir.
+	windows []Window
+	workDir string
+	// window is the name of the window shown in the preview and on attach, "" for the program's.
+	window string
+
 	// Initialized by Start or Restore
 	//
 	// ptmx is a PTY is running the tmux attach command. This can be resized to change the
@@ -244,6 +251,7 @@ func (t *TmuxSession) HasUpdated() (updated bool, hasPrompt bool) {
 }
 
 func (t *TmuxSession) Attach() (chan struct{}, error) {
+	t.showWindow(t.selectedTarget())
 	t.attachCh = make(chan struct{})
 
 	t.wg = &sync.WaitGroup{}
@@ -354,6 +362,8 @@ func (t *TmuxSession) DetachSafely() error {
 	}
 
 	t.ctx = nil
+	// Keystrokes sent while detached go to whatever window is shown, make sure it's the program's.
+	t.showWindow(t.agentTarget())
 
 	if len(errs) > 0 {
 		return fmt.Errorf("errors during detach: %v", errs)
@@ -395,6 +405,8 @@ func (t *TmuxSession) Detach() {
 	// Cancel goroutines created by Attach.
 	t.cancel()
 	t.wg.Wait()
+	// Keystrokes sent while detached go to whatever window is shown, make sure it's the program's.
+	t.showWindow(t.agentTarget())
 }
 
 // Close terminates the tmux session and cleans up resources
@@ -449,10 +461,41 @@ func (t *TmuxSession) DoesSessionExist() bool {
 	return t.cmdExec.Run(existsCmd) == nil
 }
 
+// panePIDs returns the process IDs of the programs started in the panes of the program's window.
+func (t *TmuxSession) panePIDs() ([]int, error) {
+	cmd := exec.Command("tmux", "list-panes", "-t", t.agentTarget(), "-F", "#{pane_pid}")
+	output, err := t.cmdExec.Output(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list panes of %s: %w", t.sanitizedName, err)
+	}
+	var pids []int
+	for _, line := range strings.Fields(string(output)) {
+		pid, err := strconv.Atoi(line)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected pane pid %q: %w", line, err)
+		}
+		pids = append(pids, pid)
+	}
+	if len(pids) == 0 {
+		return nil, fmt.Errorf("session %s has no panes", t.sanitizedName)
+	}
+	return pids, nil
+}
+
 // CapturePaneContent captures the content of the tmux pane
 func (t *TmuxSession) CapturePaneContent() (string, error) {
 	// Add -e flag to preserve escape sequences (ANSI color codes)
-	cmd := exec.Command("tmux", "capture-pane", "-p", "-e", "-J", "-t", t.sanitizedName)
+	cmd := exec.Command("tmux", "capture-pane", "-p", "-e", "-J", "-t", t.agentTarget())
+	output, err := t.cmdExec.Output(cmd)
+	if err != nil {
+		return "", fmt.Errorf("error capturing pane content: %v", err)
+	}
+	return string(output), nil
+}
+
+// CaptureSelectedWindow is CapturePaneContent for the selected window, see SelectWindow.
+func (t *TmuxSession) CaptureSelectedWindow() (string, error) {
+	cmd := exec.Command("tmux", "capture-pane", "-p", "-e", "-J", "-t", t.selectedTarget())
 	output, err := t.cmdExec.Output(cmd)
 	if err != nil {
 		return "", fmt.Errorf("error capturing pane content: %v", err)
@@ -460,11 +503,11 @@ func (t *TmuxSession) CapturePaneContent() (string, error) {
 	return string(output), nil
 }
 
-// CapturePaneContentWithOptions captures the pane content with additional options
+// CapturePaneContentWithOptions captures the content of the selected window's pane with additional options
 // start and end specify the starting and ending line numbers (use "-" for the start/end of history)
 func (t *TmuxSession) CapturePaneContentWithOptions(start, end string) (string, error) {
 	// Add -e flag to preserve escape sequences (ANSI color codes)
-	cmd := exec.Command("tmux", "capture-pane", "-p", "-e", "-J", "-S", start, "-E", end, "-t", t.sanitizedName)
+	cmd := exec.Command("tmux", "capture-pane", "-p", "-e", "-J", "-S", start, "-E", end, "-t", t.selectedTarget())
 	output, err := t.cmdExec.Output(cmd)
 	if err != nil {
 		return "", fmt.Errorf("failed to capture tmux pane content with options: %v", err)
@@ -501,3 +544,36 @@ func CleanupSessions(cmdExec cmd.Executor) error {
 	}
 	return nil
 }
+
+// SessionName returns the name of the tmux session used for an instance with the given title.
+func SessionName(title string) string {
+	return toClaudeSquadTmuxName(title)
+}
+
+// ListSessions returns the names of all claude-squad tmux sessions.
+func ListSessions(cmdExec cmd.Executor) ([]string, error) {
+	output, err := cmdExec.Output(exec.Command("tmux", "ls", "-F", "#{session_name}"))
+	if err != nil {
+		// Exit code 1 typically means no server is running, so there are no sessions
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list tmux sessions: %v", err)
+	}
+
+	var sessions []string
+	for _, name := range strings.Split(string(output), "\n") {
+		if strings.HasPrefix(name, TmuxPrefix) {
+			sessions = append(sessions, name)
+		}
+	}
+	return sessions, nil
+}
+
+// KillSession kills the tmux session with the given name.
+func KillSession(cmdExec cmd.Executor, name string) error {
+	if err := cmdExec.Run(exec.Command("tmux", "kill-session", fmt.Sprintf("-t=%s", name))); err != nil {
+		return fmt.Errorf("failed to kill tmux session %s: %v", name, err)
+	}
+	return nil
+}
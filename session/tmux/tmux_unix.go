@@ -4,8 +4,12 @@ package tmux
 
 import (
 	"claude-squad/log"
+	"fmt"
 	"os"
+	"os/exec"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -76,3 +80,45 @@ func (t *TmuxSession) monitorWindowSize() {
 		}
 	}()
 }
+
+// Freeze stops the processes running in the session with SIGSTOP. The session and its processes stay
+// around and continue where they left off on Thaw.
+func (t *TmuxSession) Freeze() error {
+	return t.signalProcessGroups(syscall.SIGSTOP)
+}
+
+// Thaw continues the processes stopped by Freeze.
+func (t *TmuxSession) Thaw() error {
+	return t.signalProcessGroups(syscall.SIGCONT)
+}
+
+// signalProcessGroups sends sig to the process group of every pane's program and to the group in
+// the foreground of its terminal, which differs when the program started a job of its own.
+func (t *TmuxSession) signalProcessGroups(sig syscall.Signal) error {
+	pids, err := t.panePIDs()
+	if err != nil {
+		return err
+	}
+	groups := make(map[int]bool)
+	for _, pid := range pids {
+		pgid, err := syscall.Getpgid(pid)
+		if err != nil {
+			return fmt.Errorf("failed to get process group of %d: %w", pid, err)
+		}
+		groups[pgid] = true
+		output, err := t.cmdExec.Output(exec.Command("ps", "-o", "tpgid=", "-p", strconv.Itoa(pid)))
+		if err != nil {
+			log.WarningLog.Printf("failed to get foreground process group of %d: %v", pid, err)
+			continue
+		}
+		if tpgid, err := strconv.Atoi(strings.TrimSpace(string(output))); err == nil && tpgid > 0 {
+			groups[tpgid] = true
+		}
+	}
+	for pgid := range groups {
+		if err := syscall.Kill(-pgid, sig); err != nil && err != syscall.ESRCH {
+			return fmt.Errorf("failed to send %v to process group %d: %w", sig, pgid, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+//go:build !windows
+
+package tmux
+
+import (
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+
+	"claude-squad/cmd/cmd_test"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFreezeAndThaw(t *testing.T) {
+	// Stand in for the agent: a process leading its own group, like the program of a tmux pane.
+	agent := exec.Command("sleep", "30")
+	agent.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	require.NoError(t, agent.Start())
+	t.Cleanup(func() {
+		_ = agent.Process.Kill()
+		_ = agent.Wait()
+	})
+	pid := strconv.Itoa(agent.Process.Pid)
+
+	cmdExec := cmd_test.MockCmdExec{
+		RunFunc: func(cmd *exec.Cmd) error { return nil },
+		OutputFunc: func(cmd *exec.Cmd) ([]byte, error) {
+			if strings.Contains(cmd.String(), "list-panes") {
+				return []byte(pid + "\n"), nil
+			}
+			// No separate foreground process group.
+			return []byte("0\n"), nil
+		},
+	}
+	session := newTmuxSession("test-session", "claude", NewMockPtyFactory(t), cmdExec)
+
+	state := func() string {
+		output, err := exec.Command("ps", "-o", "stat=", "-p", pid).Output()
+		require.NoError(t, err)
+		return strings.TrimSpace(string(output))
+	}
+
+	require.NoError(t, session.Freeze())
+	require.True(t, strings.HasPrefix(state(), "T"), "expected stopped process, got %q", state())
+
+	require.NoError(t, session.Thaw())
+	require.False(t, strings.HasPrefix(state(), "T"), "expected running process, got %q", state())
+}
@@ -4,6 +4,7 @@ package tmux
 
 import (
 	"claude-squad/log"
+	"fmt"
 	"os"
 	"time"
 
@@ -55,3 +56,13 @@ func (t *TmuxSession) monitorWindowSize() {
 		}
 	}()
 }
+
+// Freeze is not supported on Windows, which has no job control signals.
+func (t *TmuxSession) Freeze() error {
+	return fmt.Errorf("freezing sessions is not supported on windows")
+}
+
+// Thaw is not supported on Windows.
+func (t *TmuxSession) Thaw() error {
+	return fmt.Errorf("freezing sessions is not supported on windows")
+}
@@ -0,0 +1,129 @@
+package tmux
+
+import (
+	"claude-squad/log"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+)
+
+// AgentWindow is the name shown for the window the program runs in.
+const AgentWindow = "agent"
+
+// Window is an extra window in the session next to the program, e.g. a shell or a dev server.
+type Window struct {
+	// Name is the name of the tmux window.
+	Name string
+	// Command runs in the window. Empty starts a shell.
+	Command string
+}
+
+// SetWindows sets the extra windows of the session, started in workDir by EnsureWindows.
+func (t *TmuxSession) SetWindows(workDir string, windows []Window) {
+	t.workDir = workDir
+	t.windows = windows
+	if !slices.Contains(t.WindowNames(), t.window) {
+		t.window = ""
+	}
+}
+
+// WindowNames returns the names of the session's windows, starting with AgentWindow.
+func (t *TmuxSession) WindowNames() []string {
+	names := []string{AgentWindow}
+	for _, window := range t.windows {
+		names = append(names, window.Name)
+	}
+	return names
+}
+
+// SelectedWindow returns the name of the window shown in the preview and on attach.
+func (t *TmuxSession) SelectedWindow() string {
+	if t.window == "" {
+		return AgentWindow
+	}
+	return t.window
+}
+
+// SelectWindow selects the window shown in the preview and on attach. The program keeps receiving
+// prompts and being monitored no matter which window is selected.
+func (t *TmuxSession) SelectWindow(name string) error {
+	if !slices.Contains(t.WindowNames(), name) {
+		return fmt.Errorf("session has no window named %q", name)
+	}
+	if name == AgentWindow {
+		name = ""
+	}
+	t.window = name
+	return nil
+}
+
+// EnsureWindows creates the extra windows that are missing from the session, e.g. because they were
+// configured after it was started.
+func (t *TmuxSession) EnsureWindows() error {
+	if len(t.windows) == 0 {
+		return nil
+	}
+	output, err := t.cmdExec.Output(exec.Command("tmux", "list-windows", "-t", t.sanitizedName, "-F", "#{window_name}"))
+	if err != nil {
+		return fmt.Errorf("failed to list windows of %s: %w", t.sanitizedName, err)
+	}
+	existing := strings.Split(strings.TrimSpace(string(output)), "\n")
+
+	for _, window := range t.windows {
+		if slices.Contains(existing, window.Name) {
+			continue
+		}
+		args := []string{"new-window", "-d", "-t", t.sanitizedName + ":", "-n", window.Name, "-c", t.workDir}
+		if window.Command != "" {
+			args = append(args, window.Command)
+		}
+		if err := t.cmdExec.Run(exec.Command("tmux", args...)); err != nil {
+			return fmt.Errorf("failed to create window %s: %w", window.Name, err)
+		}
+		if window.Command != "" {
+			// Keep the output of commands that exit, like a crashed dev server, around.
+			remain := exec.Command("tmux", "set-option", "-w", "-t", t.windowTarget(window.Name), "remain-on-exit", "on")
+			if err := t.cmdExec.Run(remain); err != nil {
+				log.WarningLog.Printf("failed to keep window %s open: %v", window.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// CloseWindows kills the extra windows, leaving the program's window. Failures are only logged, as
+// the windows may have been closed by hand.
+func (t *TmuxSession) CloseWindows() {
+	for _, window := range t.windows {
+		cmd := exec.Command("tmux", "kill-window", "-t", t.windowTarget(window.Name))
+		if err := t.cmdExec.Run(cmd); err != nil {
+			log.WarningLog.Printf("failed to close window %s: %v", window.Name, err)
+		}
+	}
+}
+
+// agentTarget is the tmux target of the program's window, which is the session's first window.
+func (t *TmuxSession) agentTarget() string {
+	return t.sanitizedName + ":^"
+}
+
+// windowTarget is the tmux target of the extra window with the given name.
+func (t *TmuxSession) windowTarget(name string) string {
+	return t.sanitizedName + ":=" + name
+}
+
+// selectedTarget is the tmux target of the selected window.
+func (t *TmuxSession) selectedTarget() string {
+	if t.window == "" {
+		return t.agentTarget()
+	}
+	return t.windowTarget(t.window)
+}
+
+// showWindow makes the attached PTY show the window with the given target.
+func (t *TmuxSession) showWindow(target string) {
+	if err := t.cmdExec.Run(exec.Command("tmux", "select-window", "-t", target)); err != nil {
+		log.WarningLog.Printf("failed to select window %s: %v", target, err)
+	}
+}
@@ -0,0 +1,63 @@
+package tmux
+
+import (
+	cmd2 "claude-squad/cmd"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"claude-squad/cmd/cmd_test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWindows(t *testing.T) {
+	var ran []string
+	cmdExec := cmd_test.MockCmdExec{
+		RunFunc: func(cmd *exec.Cmd) error {
+			ran = append(ran, cmd2.ToString(cmd))
+			return nil
+		},
+		OutputFunc: func(cmd *exec.Cmd) ([]byte, error) {
+			ran = append(ran, cmd2.ToString(cmd))
+			if strings.Contains(cmd.String(), "list-windows") {
+				// The shell was started before, the dev server is new.
+				return []byte("claude\nshell\n"), nil
+			}
+			return []byte("output"), nil
+		},
+	}
+	session := newTmuxSession("test-session", "claude", NewMockPtyFactory(t), cmdExec)
+	session.SetWindows("/work", []Window{{Name: "shell"}, {Name: "dev", Command: "npm run dev"}})
+	assert.Equal(t, []string{AgentWindow, "shell", "dev"}, session.WindowNames())
+
+	require.NoError(t, session.EnsureWindows())
+	assert.Equal(t, []string{
+		"tmux list-windows -t claudesquad_test-session -F #{window_name}",
+		"tmux new-window -d -t claudesquad_test-session: -n dev -c /work npm run dev",
+		"tmux set-option -w -t claudesquad_test-session:=dev remain-on-exit on",
+	}, ran)
+
+	// The preview follows the selected window, the agent is still monitored.
+	ran = nil
+	require.NoError(t, session.SelectWindow("dev"))
+	assert.Equal(t, "dev", session.SelectedWindow())
+	_, err := session.CaptureSelectedWindow()
+	require.NoError(t, err)
+	_, err = session.CapturePaneContent()
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"tmux capture-pane -p -e -J -t claudesquad_test-session:=dev",
+		"tmux capture-pane -p -e -J -t claudesquad_test-session:^",
+	}, ran)
+	assert.Error(t, session.SelectWindow("logs"))
+
+	// Dropping a window from the config selects the agent again.
+	session.SetWindows("/work", []Window{{Name: "shell"}})
+	assert.Equal(t, AgentWindow, session.SelectedWindow())
+
+	ran = nil
+	session.CloseWindows()
+	assert.Equal(t, []string{"tmux kill-window -t claudesquad_test-session:=shell"}, ran)
+}
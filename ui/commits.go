@@ -0,0 +1,206 @@
+package ui
+
+import (
+	"claude-squad/session/git"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var commitSHAStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#e0a526"))
+
+// CommitsPane lists the commits on an instance's branch and shows the diff of a single commit.
+type CommitsPane struct {
+	viewport viewport.Model
+	width    int
+	height   int
+
+	// instanceID identifies the instance the commits belong to, so the selection survives refreshes.
+	instanceID string
+	commits    []git.Commit
+	// message replaces the list when there is nothing to show.
+	message  string
+	selected int
+	// shown is the commit whose diff is displayed, nil while the list is displayed.
+	shown *git.Commit
+}
+
+func NewCommitsPane() *CommitsPane {
+	return &CommitsPane{viewport: viewport.New(0, 0), message: "No commits"}
+}
+
+func (c *CommitsPane) SetSize(width, height int) {
+	c.width = width
+	c.height = height
+	c.viewport.Width = width
+	c.viewport.Height = max(height-1, 0)
+}
+
+// SetCommits replaces the listed commits. The selection stays on the same commit if it still exists.
+func (c *CommitsPane) SetCommits(instanceID string, commits []git.Commit, err error) {
+	if instanceID != c.instanceID {
+		c.instanceID = instanceID
+		c.commits = nil
+		c.selected = 0
+		c.shown = nil
+	}
+	switch {
+	case err != nil:
+		c.message = fmt.Sprintf("Error: %v", err)
+	case len(commits) == 0:
+		c.message = "No commits since the base commit"
+	default:
+		c.message = ""
+	}
+
+	if selected := c.SelectedCommit(); selected != nil {
+		for idx, commit := range commits {
+			if commit.SHA == selected.SHA {
+				c.selected = idx
+				break
+			}
+		}
+	}
+	c.commits = commits
+	c.selected = min(c.selected, max(len(commits)-1, 0))
+}
+
+// Clear empties the pane, e.g. when no instance is selected.
+func (c *CommitsPane) Clear(message string) {
+	c.instanceID = ""
+	c.commits = nil
+	c.shown = nil
+	c.message = message
+}
+
+// InstanceID returns the ID of the instance whose commits are listed.
+func (c *CommitsPane) InstanceID() string {
+	return c.instanceID
+}
+
+// SelectedCommit returns the selected commit, or nil if there is none.
+func (c *CommitsPane) SelectedCommit() *git.Commit {
+	if c.selected < 0 || c.selected >= len(c.commits) {
+		return nil
+	}
+	return &c.commits[c.selected]
+}
+
+// Next selects the next (older) commit.
+func (c *CommitsPane) Next() {
+	if c.shown == nil && c.selected < len(c.commits)-1 {
+		c.selected++
+	}
+}
+
+// Prev selects the previous (newer) commit.
+func (c *CommitsPane) Prev() {
+	if c.shown == nil && c.selected > 0 {
+		c.selected--
+	}
+}
+
+// ShowDiff displays the diff of the given commit instead of the list.
+func (c *CommitsPane) ShowDiff(commit git.Commit, content string) {
+	c.shown = &commit
+	var lines []string
+	for _, file := range git.ParseDiff(content) {
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, diffFileHeaderStyle.Render(file.Path()))
+		for _, hunk := range file.Hunks {
+			lines = append(lines, HunkStyle.Render(hunk.Header))
+			lines = append(lines, renderUnified(renderHunkBody(hunk, true))...)
+		}
+	}
+	if len(lines) == 0 {
+		lines = append(lines, diffDimStyle.Render("The commit has no changes"))
+	}
+	for idx, line := range lines {
+		lines[idx] = fitLine(line, c.width)
+	}
+	c.viewport.SetContent(strings.Join(lines, "\n"))
+	c.viewport.GotoTop()
+}
+
+// HideDiff goes back to the list of commits.
+func (c *CommitsPane) HideDiff() {
+	c.shown = nil
+}
+
+// ShowingDiff returns true if the diff of a commit is displayed.
+func (c *CommitsPane) ShowingDiff() bool {
+	return c.shown != nil
+}
+
+func (c *CommitsPane) ScrollUp() {
+	c.viewport.LineUp(1)
+}
+
+func (c *CommitsPane) ScrollDown() {
+	c.viewport.LineDown(1)
+}
+
+func (c *CommitsPane) String() string {
+	if c.message != "" {
+		return lipgloss.Place(c.width, c.height, lipgloss.Center, lipgloss.Center, c.message)
+	}
+	if c.shown != nil {
+		header := commitSHAStyle.Render(c.shown.ShortSHA()) + " " + c.shown.Subject +
+			diffDimStyle.Render(" · v to go back")
+		return lipgloss.JoinVertical(lipgloss.Left, fitLine(header, c.width), c.viewport.View())
+	}
+
+	lines := []string{diffDimStyle.Render(fmt.Sprintf("%d commits since the base commit", len(c.commits)))}
+	// Scroll the list so the selected commit stays visible.
+	listHeight := max(c.height-1, 1)
+	start := 0
+	if c.selected >= listHeight {
+		start = c.selected - listHeight + 1
+	}
+	for idx := start; idx < len(c.commits) && idx-start < listHeight; idx++ {
+		lines = append(lines, c.renderCommit(&c.commits[idx], idx == c.selected))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderCommit renders a commit as a single line: hash, subject, stats and age.
+func (c *CommitsPane) renderCommit(commit *git.Commit, selected bool) string {
+	gutter := " "
+	subject := commit.Subject
+	if selected {
+		gutter = diffCursorStyle.Render("▌")
+		subject = diffSelectedFileStyle.Render(subject)
+	}
+	stats := AdditionStyle.Render(fmt.Sprintf("+%d", commit.Added)) + " " +
+		DeletionStyle.Render(fmt.Sprintf("-%d", commit.Removed)) +
+		diffDimStyle.Render(fmt.Sprintf(" %d files · %s", commit.Files, timeAgo(commit.Time)))
+
+	prefix := gutter + commitSHAStyle.Render(commit.ShortSHA()) + " "
+	// Keep the stats visible by truncating the subject first.
+	subjectWidth := c.width - lipgloss.Width(prefix) - lipgloss.Width(stats) - 2
+	if subjectWidth < 10 {
+		return fitLine(prefix+subject, c.width)
+	}
+	subject = fitLine(subject, subjectWidth)
+	padding := max(c.width-lipgloss.Width(prefix)-lipgloss.Width(subject)-lipgloss.Width(stats), 1)
+	return prefix + subject + strings.Repeat(" ", padding) + stats
+}
+
+// timeAgo formats how long ago t was, e.g. "5m ago".
+func timeAgo(t time.Time) string {
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+}
@@ -2,11 +2,16 @@ package ui
 
 import (
 	"claude-squad/session"
+	"claude-squad/session/git"
 	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/viewport"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/reflow/truncate"
 )
 
 var (
@@ -15,87 +20,487 @@ var (
 	HunkStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("#0ea5e9"))
 )
 
+var diffFileHeaderStyle = lipgloss.NewStyle().Bold(true).
+	Foreground(lipgloss.AdaptiveColor{Light: "#1a1a1a", Dark: "#dddddd"})
+
+var diffSelectedFileStyle = lipgloss.NewStyle().
+	Background(lipgloss.Color("#dde4f0")).
+	Foreground(lipgloss.Color("#1a1a1a"))
+
+var diffDimStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.AdaptiveColor{Light: "#808080", Dark: "#808080"})
+
+var diffCursorStyle = lipgloss.NewStyle().Foreground(highlightColor)
+
+var diffCommentStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.AdaptiveColor{Light: "#B45309", Dark: "#EAB308"})
+
+var diffSidebarBorderStyle = lipgloss.NewStyle().
+	Border(lipgloss.NormalBorder(), false, true, false, false).
+	BorderForeground(highlightColor)
+
+const (
+	// diffSidebarMinPaneWidth is the pane width below which the file list is hidden.
+	diffSidebarMinPaneWidth = 60
+	diffTabWidth            = 4
+)
+
 type DiffPane struct {
 	viewport viewport.Model
-	diff     string
-	stats    string
-	width    int
-	height   int
+	// header is the summary line rendered above the diff.
+	header string
+	// message replaces the diff when there is nothing to show.
+	message string
+	width   int
+	height  int
+
+	// instanceID and stats identify the rendered diff so that unchanged diffs are not reloaded
+	// and re-rendered on every tick and navigation state survives refreshes.
+	instanceID string
+	stats      *git.DiffStats
+	// accepted holds the keys of the hunks the user accepted, see session.Instance.AcceptedHunks.
+	// numAccepted counts the accepted hunks present in the current diff.
+	accepted    map[string]bool
+	numAccepted int
+	// reviewComments holds the instance's pending review comments, indexed by line in comments.
+	reviewComments []session.ReviewComment
+	comments       map[reviewLine]string
+
+	// files holds every file in the diff. visible indexes the files that pass the filter.
+	files   []git.FileDiff
+	visible []int
+	// selectedFile indexes visible. selectedHunk indexes the hunks of the selected file, or is -1
+	// when the file header itself is selected. selectedLine is the line cursor within the selected
+	// hunk, or -1 when the hunk header is selected.
+	selectedFile  int
+	selectedHunk  int
+	selectedLine  int
+	collapsed     map[string]bool
+	hideGenerated bool
+	// sideBySide renders old and new columns when the pane is wide enough. wordDiff highlights the
+	// changed words within paired removed and added lines.
+	sideBySide bool
+	wordDiff   bool
+
+	// fileOffsets and hunkOffsets hold the viewport line of each visible file and its hunks.
+	fileOffsets []int
+	hunkOffsets [][]int
+	// cursorOffset is the viewport line of the line cursor.
+	cursorOffset int
+}
+
+// reviewLine identifies a commented line, see session.ReviewComment.
+type reviewLine struct {
+	path    string
+	line    int
+	removed bool
 }
 
 func NewDiffPane() *DiffPane {
 	return &DiffPane{
-		viewport: viewport.New(0, 0),
+		viewport:     viewport.New(0, 0),
+		collapsed:    make(map[string]bool),
+		selectedHunk: -1,
+		selectedLine: -1,
+		wordDiff:     true,
 	}
 }
 
 func (d *DiffPane) SetSize(width, height int) {
 	d.width = width
 	d.height = height
-	d.viewport.Width = width
-	d.viewport.Height = height
-	// Update viewport content if diff exists
-	if d.diff != "" || d.stats != "" {
-		d.viewport.SetContent(lipgloss.JoinVertical(lipgloss.Left, d.stats, d.diff))
+	d.viewport.Width = d.contentWidth()
+	d.viewport.Height = max(height-1, 0)
+	// Re-render so lines are truncated to the new width
+	if len(d.files) > 0 {
+		d.render()
 	}
 }
 
 func (d *DiffPane) SetDiff(instance *session.Instance) {
-	centeredFallbackMessage := lipgloss.Place(
-		d.width,
-		d.height,
-		lipgloss.Center,
-		lipgloss.Center,
-		"No changes",
-	)
-
 	if instance == nil || !instance.Started() {
-		d.viewport.SetContent(centeredFallbackMessage)
+		d.setMessage("No changes")
 		return
 	}
 
 	stats := instance.GetDiffStats()
 	if stats == nil {
 		// Show loading message if worktree is not ready
-		centeredMessage := lipgloss.Place(
-			d.width,
-			d.height,
-			lipgloss.Center,
-			lipgloss.Center,
-			"Setting up worktree...",
-		)
-		d.viewport.SetContent(centeredMessage)
+		d.setMessage("Setting up worktree...")
 		return
 	}
 
 	if stats.Error != nil {
-		// Show error message
-		centeredMessage := lipgloss.Place(
-			d.width,
-			d.height,
-			lipgloss.Center,
-			lipgloss.Center,
-			fmt.Sprintf("Error: %v", stats.Error),
-		)
-		d.viewport.SetContent(centeredMessage)
+		d.setMessage(fmt.Sprintf("Error: %v", stats.Error))
 		return
 	}
 
 	if stats.IsEmpty() {
-		d.stats = ""
-		d.diff = ""
-		d.viewport.SetContent(centeredFallbackMessage)
-	} else {
-		additions := AdditionStyle.Render(fmt.Sprintf("%d additions(+)", stats.Added))
-		deletions := DeletionStyle.Render(fmt.Sprintf("%d deletions(-)", stats.Removed))
-		d.stats = lipgloss.JoinHorizontal(lipgloss.Center, additions, " ", deletions)
-		d.diff = colorizeDiff(stats.Content)
-		d.viewport.SetContent(lipgloss.JoinVertical(lipgloss.Left, d.stats, d.diff))
+		d.setMessage("No changes")
+		return
+	}
+
+	if instance.ID != d.instanceID {
+		d.instanceID = instance.ID
+		d.stats = nil
+		d.collapsed = make(map[string]bool)
+		d.selectedFile = 0
+		d.selectedHunk = -1
+		d.selectedLine = -1
+		d.viewport.GotoTop()
+	}
+	accepted := instance.AcceptedHunks()
+	reviewComments := instance.ReviewComments()
+	if d.message == "" && stats == d.stats && maps.Equal(accepted, d.accepted) &&
+		slices.Equal(reviewComments, d.reviewComments) {
+		return
+	}
+
+	if stats != d.stats {
+		// The instance only keeps stats in memory, load the diff itself from the cache.
+		content, err := instance.DiffContent()
+		if err != nil {
+			d.setMessage(fmt.Sprintf("Error: %v", err))
+			return
+		}
+		d.setFiles(git.ParseDiff(content), stats.Files)
+	}
+
+	d.message = ""
+	d.stats = stats
+	d.accepted = accepted
+	d.reviewComments = reviewComments
+	d.comments = make(map[reviewLine]string, len(reviewComments))
+	for _, comment := range reviewComments {
+		d.comments[reviewLine{comment.Path, comment.Line, comment.Removed}] = comment.Body
+	}
+	d.numAccepted = d.countAccepted()
+
+	additions := AdditionStyle.Render(fmt.Sprintf("%d additions(+)", stats.Added))
+	deletions := DeletionStyle.Render(fmt.Sprintf("%d deletions(-)", stats.Removed))
+	d.header = lipgloss.JoinHorizontal(lipgloss.Center, additions, " ", deletions)
+	if stats.Baseline != "" {
+		d.header += diffDimStyle.Render(" · vs " + stats.Baseline)
+	}
+	d.render()
+}
+
+// setMessage replaces the diff with a centered message.
+func (d *DiffPane) setMessage(message string) {
+	d.message = message
+	d.stats = nil
+	d.files = nil
+	d.visible = nil
+}
+
+// setFiles replaces the parsed files, keeping the selection on the same path if it still exists.
+func (d *DiffPane) setFiles(files []git.FileDiff, numstat []git.FileStat) {
+	selectedPath := d.selectedPath()
+
+	counts := make(map[string]git.FileStat, len(numstat))
+	for _, stat := range numstat {
+		counts[stat.Path] = stat
+	}
+	for i := range files {
+		if stat, ok := counts[files[i].Path()]; ok {
+			files[i].Added = stat.Added
+			files[i].Removed = stat.Removed
+			files[i].Binary = files[i].Binary || stat.Binary
+		}
+	}
+	d.files = files
+	d.updateVisible()
+
+	for vi, fi := range d.visible {
+		if d.files[fi].Path() == selectedPath {
+			d.selectedFile = vi
+			break
+		}
+	}
+	d.clampSelection()
+}
+
+func (d *DiffPane) selectedPath() string {
+	if d.selectedFile < 0 || d.selectedFile >= len(d.visible) {
+		return ""
+	}
+	return d.files[d.visible[d.selectedFile]].Path()
+}
+
+func (d *DiffPane) updateVisible() {
+	d.visible = d.visible[:0]
+	for i := range d.files {
+		if d.hideGenerated && git.IsGeneratedFile(d.files[i].Path()) {
+			continue
+		}
+		d.visible = append(d.visible, i)
 	}
 }
 
+func (d *DiffPane) clampSelection() {
+	if d.selectedFile >= len(d.visible) {
+		d.selectedFile = len(d.visible) - 1
+	}
+	if d.selectedFile < 0 {
+		d.selectedFile = 0
+	}
+	if len(d.visible) == 0 || d.isCollapsed(d.selectedFile) {
+		d.selectedHunk = -1
+		d.selectedLine = -1
+		return
+	}
+	hunks := d.files[d.visible[d.selectedFile]].Hunks
+	if d.selectedHunk >= len(hunks) {
+		d.selectedHunk = len(hunks) - 1
+	}
+	if d.selectedHunk < 0 {
+		d.selectedLine = -1
+	} else if lines := len(hunks[d.selectedHunk].Lines); d.selectedLine >= lines {
+		d.selectedLine = lines - 1
+	}
+}
+
+func (d *DiffPane) isCollapsed(visibleIdx int) bool {
+	return d.collapsed[d.files[d.visible[visibleIdx]].Path()]
+}
+
+// sidebarWidth returns the width of the file list, or 0 if it is hidden.
+func (d *DiffPane) sidebarWidth() int {
+	if d.width < diffSidebarMinPaneWidth {
+		return 0
+	}
+	return min(max(d.width/4, 20), 40)
+}
+
+func (d *DiffPane) contentWidth() int {
+	if w := d.sidebarWidth(); w > 0 {
+		// The sidebar border takes one column.
+		return d.width - w - 1
+	}
+	return d.width
+}
+
+// render lays out the visible files into the viewport and records where each file and hunk starts.
+func (d *DiffPane) render() {
+	width := d.contentWidth()
+	var b strings.Builder
+	line := 0
+	writeLine := func(s string) {
+		if line > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(fitLine(s, width))
+		line++
+	}
+
+	sideBySide := d.sideBySideActive()
+	d.fileOffsets = d.fileOffsets[:0]
+	d.hunkOffsets = d.hunkOffsets[:0]
+	for vi, fi := range d.visible {
+		file := &d.files[fi]
+		if vi > 0 {
+			writeLine("")
+		}
+		d.fileOffsets = append(d.fileOffsets, line)
+
+		marker := "▾ "
+		if d.isCollapsed(vi) {
+			marker = "▸ "
+		}
+		title := diffFileHeaderStyle.Render(marker+file.Path()) + " " + fileCounts(file)
+		if vi == d.selectedFile && d.selectedHunk < 0 {
+			title = diffSelectedFileStyle.Render(marker+file.Path()) + " " + fileCounts(file)
+		}
+		writeLine(title)
+
+		var offsets []int
+		if !d.isCollapsed(vi) {
+			for _, h := range file.Header {
+				if isExtendedHeaderLine(h) {
+					writeLine(diffDimStyle.Render(h))
+				}
+			}
+			for hi, hunk := range file.Hunks {
+				offsets = append(offsets, line)
+				header := HunkStyle.Render(hunk.Header)
+				if vi == d.selectedFile && hi == d.selectedHunk {
+					header = diffSelectedFileStyle.Render(hunk.Header)
+				}
+				if d.accepted[git.HunkKey(file.Path(), hunk)] {
+					header = AdditionStyle.Render("✓ ") + header
+				}
+				writeLine(header)
+
+				body := renderHunkBody(hunk, d.wordDiff)
+				var rows []string
+				var lineRows []int
+				if sideBySide {
+					// One column is taken by the cursor gutter.
+					rows, lineRows = renderSideBySide(body, width-1)
+				} else {
+					rows = renderUnified(body)
+					lineRows = make([]int, len(rows))
+					for i := range lineRows {
+						lineRows[i] = i
+					}
+				}
+
+				rowComments := make([][]string, len(rows))
+				for li, l := range body {
+					if comment, ok := d.comments[reviewLineOf(file.Path(), l)]; ok && l.kind != '\\' {
+						rowComments[lineRows[li]] = append(rowComments[lineRows[li]], comment)
+					}
+				}
+				cursorRow := -1
+				if vi == d.selectedFile && hi == d.selectedHunk && d.selectedLine >= 0 {
+					cursorRow = lineRows[d.selectedLine]
+				}
+				for r, row := range rows {
+					gutter := " "
+					if r == cursorRow {
+						gutter = diffCursorStyle.Render("▌")
+						d.cursorOffset = line
+					} else if len(rowComments[r]) > 0 {
+						gutter = diffCommentStyle.Render("●")
+					}
+					writeLine(gutter + row)
+					for _, comment := range rowComments[r] {
+						writeLine(diffCommentStyle.Render("  ↳ " + strings.ReplaceAll(comment, "\n", " ")))
+					}
+				}
+			}
+		}
+		d.hunkOffsets = append(d.hunkOffsets, offsets)
+	}
+
+	if len(d.visible) == 0 {
+		writeLine(diffDimStyle.Render("All changed files are hidden by the filter"))
+	}
+	d.viewport.SetContent(b.String())
+}
+
+// reviewLineOf returns the key under which a comment on the given line is stored.
+func reviewLineOf(path string, l diffLine) reviewLine {
+	if l.kind == '-' {
+		return reviewLine{path, l.oldNum, true}
+	}
+	return reviewLine{path, l.newNum, false}
+}
+
+// isExtendedHeaderLine returns true for header lines worth showing in the file view. The diff --git,
+// index and ---/+++ lines are redundant with the file title.
+func isExtendedHeaderLine(line string) bool {
+	for _, prefix := range []string{"diff --git ", "index ", "--- ", "+++ "} {
+		if strings.HasPrefix(line, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+func fileCounts(file *git.FileDiff) string {
+	if file.Binary {
+		return diffDimStyle.Render("binary")
+	}
+	return AdditionStyle.Render(fmt.Sprintf("+%d", file.Added)) + " " +
+		DeletionStyle.Render(fmt.Sprintf("-%d", file.Removed))
+}
+
+// fitLine expands tabs and truncates a (possibly styled) line so the viewport never wraps it.
+// Wrapping would shift the file and hunk offsets used for navigation.
+func fitLine(s string, width int) string {
+	s = strings.ReplaceAll(s, "\t", strings.Repeat(" ", diffTabWidth))
+	if width <= 0 {
+		return s
+	}
+	return truncate.String(s, uint(width))
+}
+
+// renderSidebar renders the file list with the selected file highlighted. The result is two columns
+// wider than width to make room for the padding and border.
+func (d *DiffPane) renderSidebar(width, height int) string {
+	lines := make([]string, 0, len(d.visible)+2)
+	title := fmt.Sprintf("%d files", len(d.visible))
+	if hidden := len(d.files) - len(d.visible); hidden > 0 {
+		title += fmt.Sprintf(" (%d hidden)", hidden)
+	}
+	lines = append(lines, diffDimStyle.Render(truncate.String(title, uint(width))), "")
+
+	// Scroll the list so the selected file stays visible.
+	listHeight := height - len(lines)
+	start := 0
+	if listHeight > 0 && d.selectedFile >= listHeight {
+		start = d.selectedFile - listHeight + 1
+	}
+	for vi := start; vi < len(d.visible) && vi-start < max(listHeight, 0); vi++ {
+		file := &d.files[d.visible[vi]]
+		counts := fileCounts(file)
+		countsWidth := lipgloss.Width(counts)
+		nameWidth := width - countsWidth - 1
+		name := shortenPath(file.Path(), nameWidth)
+		padding := max(width-lipgloss.Width(name)-countsWidth, 1)
+		if vi == d.selectedFile {
+			name = diffSelectedFileStyle.Render(name)
+		}
+		lines = append(lines, name+strings.Repeat(" ", padding)+counts)
+	}
+
+	return diffSidebarBorderStyle.Render(
+		lipgloss.NewStyle().Width(width + 1).PaddingRight(1).Height(height).MaxHeight(height).
+			Render(strings.Join(lines, "\n")))
+}
+
+// shortenPath trims a path from the left so it fits in width, keeping the file name visible.
+func shortenPath(path string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	if lipgloss.Width(path) <= width {
+		return path
+	}
+	base := filepath.Base(path)
+	if lipgloss.Width(base)+2 > width {
+		return truncate.StringWithTail(base, uint(width), "…")
+	}
+	runes := []rune(path)
+	for len(runes) > 0 && lipgloss.Width(string(runes))+1 > width {
+		runes = runes[1:]
+	}
+	return "…" + string(runes)
+}
+
 func (d *DiffPane) String() string {
-	return d.viewport.View()
+	if d.message != "" || len(d.files) == 0 {
+		message := d.message
+		if message == "" {
+			message = "No changes"
+		}
+		return lipgloss.Place(d.width, d.height, lipgloss.Center, lipgloss.Center, message)
+	}
+
+	header := d.header
+	if d.sideBySide && !d.sideBySideActive() {
+		header += diffDimStyle.Render(" · too narrow for side-by-side")
+	} else if d.sideBySide {
+		header += diffDimStyle.Render(" · side-by-side")
+	}
+	if d.wordDiff {
+		header += diffDimStyle.Render(" · word diff")
+	}
+	if d.hideGenerated {
+		header += diffDimStyle.Render(" · generated files hidden")
+	}
+	if d.numAccepted > 0 {
+		header += AdditionStyle.Render(fmt.Sprintf(" · %d hunks accepted", d.numAccepted))
+	}
+	if n := len(d.reviewComments); n > 0 {
+		header += diffCommentStyle.Render(fmt.Sprintf(" · %d review comments", n))
+	}
+	body := d.viewport.View()
+	if w := d.sidebarWidth(); w > 0 {
+		body = lipgloss.JoinHorizontal(lipgloss.Top, d.renderSidebar(w-2, d.viewport.Height), " ", body)
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, fitLine(header, d.width), body)
 }
 
 // ScrollUp scrolls the viewport up
@@ -108,30 +513,228 @@ func (d *DiffPane) ScrollDown() {
 	d.viewport.LineDown(1)
 }
 
-func colorizeDiff(diff string) string {
-	var coloredOutput strings.Builder
-
-	lines := strings.Split(diff, "\n")
-	for _, line := range lines {
-		if len(line) > 0 {
-			if strings.HasPrefix(line, "@@") {
-				// Color hunk headers cyan
-				coloredOutput.WriteString(HunkStyle.Render(line) + "\n")
-			} else if line[0] == '+' && (len(line) == 1 || line[1] != '+') {
-				// Color added lines green, excluding metadata like '+++'
-				coloredOutput.WriteString(AdditionStyle.Render(line) + "\n")
-			} else if line[0] == '-' && (len(line) == 1 || line[1] != '-') {
-				// Color removed lines red, excluding metadata like '---'
-				coloredOutput.WriteString(DeletionStyle.Render(line) + "\n")
-			} else {
-				// Print metadata and unchanged lines without color
-				coloredOutput.WriteString(line + "\n")
+// NextFile selects the next file and scrolls to it.
+func (d *DiffPane) NextFile() {
+	d.selectFile(d.selectedFile + 1)
+}
+
+// PrevFile selects the previous file and scrolls to it.
+func (d *DiffPane) PrevFile() {
+	d.selectFile(d.selectedFile - 1)
+}
+
+func (d *DiffPane) selectFile(idx int) {
+	if len(d.visible) == 0 {
+		return
+	}
+	d.selectedFile = min(max(idx, 0), len(d.visible)-1)
+	d.selectedHunk = -1
+	d.selectedLine = -1
+	d.render()
+	d.viewport.SetYOffset(d.fileOffsets[d.selectedFile])
+}
+
+// NextHunk selects the next hunk, moving on to the next file at the end of the current one.
+func (d *DiffPane) NextHunk() {
+	if file, hunk, ok := d.nextHunk(); ok {
+		d.selectHunk(file, hunk, -1)
+	}
+}
+
+// PrevHunk selects the previous hunk, moving back to the previous file at the start of the current one.
+func (d *DiffPane) PrevHunk() {
+	if file, hunk, ok := d.prevHunk(); ok {
+		d.selectHunk(file, hunk, -1)
+	}
+}
+
+func (d *DiffPane) nextHunk() (int, int, bool) {
+	if len(d.visible) == 0 {
+		return 0, 0, false
+	}
+	file, hunk := d.selectedFile, d.selectedHunk+1
+	for file < len(d.visible) {
+		if hunk < len(d.hunkOffsets[file]) {
+			return file, hunk, true
+		}
+		file, hunk = file+1, 0
+	}
+	return 0, 0, false
+}
+
+func (d *DiffPane) prevHunk() (int, int, bool) {
+	if len(d.visible) == 0 {
+		return 0, 0, false
+	}
+	file, hunk := d.selectedFile, d.selectedHunk-1
+	for file >= 0 {
+		if hunk >= 0 && hunk < len(d.hunkOffsets[file]) {
+			return file, hunk, true
+		}
+		file--
+		if file >= 0 {
+			hunk = len(d.hunkOffsets[file]) - 1
+		}
+	}
+	return 0, 0, false
+}
+
+// selectHunk selects a hunk and scrolls to it, or to the line cursor if line is not -1.
+func (d *DiffPane) selectHunk(file, hunk, line int) {
+	d.selectedFile = file
+	d.selectedHunk = hunk
+	d.selectedLine = line
+	d.render()
+	if line < 0 {
+		d.viewport.SetYOffset(d.hunkOffsets[file][hunk])
+		return
+	}
+	// Scroll just enough to keep the cursor in view.
+	if d.cursorOffset < d.viewport.YOffset {
+		d.viewport.SetYOffset(d.cursorOffset)
+	} else if d.cursorOffset >= d.viewport.YOffset+d.viewport.Height {
+		d.viewport.SetYOffset(d.cursorOffset - d.viewport.Height + 1)
+	}
+}
+
+// NextLine moves the line cursor down, continuing into the next hunk at the end of the current one.
+func (d *DiffPane) NextLine() {
+	if len(d.visible) == 0 {
+		return
+	}
+	if lines := d.selectedHunkLines(); d.selectedHunk >= 0 {
+		for line := d.selectedLine + 1; line < len(lines); line++ {
+			if lines[line][0] != '\\' {
+				d.selectHunk(d.selectedFile, d.selectedHunk, line)
+				return
+			}
+		}
+	}
+	if file, hunk, ok := d.nextHunk(); ok {
+		d.selectHunk(file, hunk, 0)
+	}
+}
+
+// PrevLine moves the line cursor up to the hunk header, then into the previous hunk.
+func (d *DiffPane) PrevLine() {
+	if len(d.visible) == 0 {
+		return
+	}
+	if lines := d.selectedHunkLines(); d.selectedHunk >= 0 && d.selectedLine >= 0 {
+		line := d.selectedLine - 1
+		for line >= 0 && lines[line][0] == '\\' {
+			line--
+		}
+		d.selectHunk(d.selectedFile, d.selectedHunk, line)
+		return
+	}
+	if file, hunk, ok := d.prevHunk(); ok {
+		lines := d.files[d.visible[file]].Hunks[hunk].Lines
+		line := len(lines) - 1
+		for line > 0 && lines[line][0] == '\\' {
+			line--
+		}
+		d.selectHunk(file, hunk, line)
+	}
+}
+
+func (d *DiffPane) selectedHunkLines() []string {
+	if d.selectedHunk < 0 || d.selectedFile >= len(d.visible) {
+		return nil
+	}
+	return d.files[d.visible[d.selectedFile]].Hunks[d.selectedHunk].Lines
+}
+
+// ToggleCollapse collapses or expands the selected file.
+func (d *DiffPane) ToggleCollapse() {
+	if len(d.visible) == 0 {
+		return
+	}
+	path := d.selectedPath()
+	d.collapsed[path] = !d.collapsed[path]
+	d.selectFile(d.selectedFile)
+}
+
+// ToggleGeneratedFilter hides or shows lockfiles and generated files.
+func (d *DiffPane) ToggleGeneratedFilter() {
+	selectedPath := d.selectedPath()
+	d.hideGenerated = !d.hideGenerated
+	d.updateVisible()
+
+	d.selectedFile = 0
+	for vi, fi := range d.visible {
+		if d.files[fi].Path() == selectedPath {
+			d.selectedFile = vi
+			break
+		}
+	}
+	d.selectedHunk = -1
+	d.selectedLine = -1
+	if len(d.visible) == 0 {
+		d.render()
+		return
+	}
+	d.selectFile(d.selectedFile)
+}
+
+// countAccepted returns the number of hunks in the current diff that are marked as accepted.
+func (d *DiffPane) countAccepted() int {
+	count := 0
+	for i := range d.files {
+		for _, hunk := range d.files[i].Hunks {
+			if d.accepted[git.HunkKey(d.files[i].Path(), hunk)] {
+				count++
 			}
-		} else {
-			// Preserve empty lines
-			coloredOutput.WriteString("\n")
 		}
 	}
+	return count
+}
+
+// SelectedHunk returns the selected file and hunk index. The hunk index is -1 if the file header is
+// selected, and the file is nil if nothing is selected.
+func (d *DiffPane) SelectedHunk() (*git.FileDiff, int) {
+	if d.message != "" || d.selectedFile < 0 || d.selectedFile >= len(d.visible) {
+		return nil, -1
+	}
+	return &d.files[d.visible[d.selectedFile]], d.selectedHunk
+}
+
+// SelectedLine returns the selected file, hunk index and line index within the hunk. The line
+// index is -1 if no line is under the cursor.
+func (d *DiffPane) SelectedLine() (*git.FileDiff, int, int) {
+	file, hunk := d.SelectedHunk()
+	if file == nil || hunk < 0 {
+		return file, hunk, -1
+	}
+	return file, hunk, d.selectedLine
+}
+
+// sideBySideActive returns true if side-by-side mode is on and the pane is wide enough for it.
+func (d *DiffPane) sideBySideActive() bool {
+	return d.sideBySide && d.contentWidth() >= diffSideBySideMinWidth
+}
+
+// ToggleSideBySide switches between the unified and side-by-side layouts.
+func (d *DiffPane) ToggleSideBySide() {
+	d.sideBySide = !d.sideBySide
+	d.rerenderKeepingSelection()
+}
 
-	return coloredOutput.String()
+// ToggleWordDiff turns word-level highlighting on or off.
+func (d *DiffPane) ToggleWordDiff() {
+	d.wordDiff = !d.wordDiff
+	d.rerenderKeepingSelection()
+}
+
+// rerenderKeepingSelection re-renders the diff and scrolls back to the selected file or hunk, whose
+// offsets change when the layout does.
+func (d *DiffPane) rerenderKeepingSelection() {
+	if len(d.visible) == 0 {
+		return
+	}
+	if d.selectedHunk >= 0 {
+		d.selectHunk(d.selectedFile, d.selectedHunk, d.selectedLine)
+	} else {
+		d.selectFile(d.selectedFile)
+	}
 }
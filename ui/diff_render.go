@@ -0,0 +1,290 @@
+package ui
+
+import (
+	"claude-squad/session/git"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/reflow/truncate"
+)
+
+const (
+	// diffSideBySideMinWidth is the narrowest content width that side-by-side mode renders at.
+	diffSideBySideMinWidth = 80
+	// diffWordDiffMaxCells caps the size of the word LCS table so huge lines don't stall the UI.
+	diffWordDiffMaxCells = 40000
+	diffGutterWidth      = 5
+)
+
+var diffGutterStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.AdaptiveColor{Light: "#A49FA5", Dark: "#555555"})
+
+// wordSpan is a piece of a line that is either unchanged or part of a word-level change.
+type wordSpan struct {
+	text    string
+	changed bool
+}
+
+// tokenizeWords splits a line into runs of word characters, runs of whitespace and single
+// punctuation characters. Joining the tokens gives back the original line.
+func tokenizeWords(s string) []string {
+	var tokens []string
+	runes := []rune(s)
+	for i := 0; i < len(runes); {
+		j := i + 1
+		switch {
+		case isWordRune(runes[i]):
+			for j < len(runes) && isWordRune(runes[j]) {
+				j++
+			}
+		case unicode.IsSpace(runes[i]):
+			for j < len(runes) && unicode.IsSpace(runes[j]) {
+				j++
+			}
+		}
+		tokens = append(tokens, string(runes[i:j]))
+		i = j
+	}
+	return tokens
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// diffWords compares two lines token by token and returns the spans of each side, marking the
+// tokens that are not part of their longest common subsequence. ok is false if the lines are too
+// long to compare.
+func diffWords(oldLine, newLine string) (oldSpans, newSpans []wordSpan, ok bool) {
+	a, b := tokenizeWords(oldLine), tokenizeWords(newLine)
+	if (len(a)+1)*(len(b)+1) > diffWordDiffMaxCells {
+		return nil, nil, false
+	}
+
+	// lcs[i][j] is the LCS length of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	appendSpan := func(spans []wordSpan, text string, changed bool) []wordSpan {
+		if n := len(spans); n > 0 && spans[n-1].changed == changed {
+			spans[n-1].text += text
+			return spans
+		}
+		return append(spans, wordSpan{text: text, changed: changed})
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			oldSpans = appendSpan(oldSpans, a[i], false)
+			newSpans = appendSpan(newSpans, b[j], false)
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] >= lcs[i+1][j]):
+			newSpans = appendSpan(newSpans, b[j], true)
+			j++
+		default:
+			oldSpans = appendSpan(oldSpans, a[i], true)
+			i++
+		}
+	}
+	return oldSpans, newSpans, true
+}
+
+// renderSpans styles a line body, highlighting the changed spans with the reverse of style.
+func renderSpans(spans []wordSpan, style lipgloss.Style) string {
+	var b strings.Builder
+	for _, span := range spans {
+		if span.changed && strings.TrimSpace(span.text) != "" {
+			b.WriteString(style.Reverse(true).Render(span.text))
+		} else {
+			b.WriteString(style.Render(span.text))
+		}
+	}
+	return b.String()
+}
+
+// diffLine is a rendered hunk body line along with its position in the old and new file.
+type diffLine struct {
+	kind     byte
+	text     string
+	rendered string
+	oldNum   int
+	newNum   int
+}
+
+// renderHunkBody styles each line of a hunk. Consecutive removed and added lines are paired up and,
+// if wordDiff is set, highlighted at word granularity.
+func renderHunkBody(hunk git.Hunk, wordDiff bool) []diffLine {
+	lines := make([]diffLine, 0, len(hunk.Lines))
+	oldNum, newNum := hunk.OldStart, hunk.NewStart
+	for _, l := range hunk.Lines {
+		line := diffLine{kind: l[0], text: expandTabs(l[1:])}
+		switch line.kind {
+		case '+':
+			line.newNum = newNum
+			newNum++
+			line.rendered = AdditionStyle.Render(line.text)
+		case '-':
+			line.oldNum = oldNum
+			oldNum++
+			line.rendered = DeletionStyle.Render(line.text)
+		case '\\':
+			line.rendered = diffDimStyle.Render(line.text)
+		default:
+			line.oldNum, line.newNum = oldNum, newNum
+			oldNum++
+			newNum++
+			line.rendered = line.text
+		}
+		lines = append(lines, line)
+	}
+
+	if wordDiff {
+		forEachChangeBlock(lines, func(removed, added []int) {
+			for k := 0; k < len(removed) && k < len(added); k++ {
+				oldLine, newLine := &lines[removed[k]], &lines[added[k]]
+				oldSpans, newSpans, ok := diffWords(oldLine.text, newLine.text)
+				if !ok {
+					continue
+				}
+				oldLine.rendered = renderSpans(oldSpans, DeletionStyle)
+				newLine.rendered = renderSpans(newSpans, AdditionStyle)
+			}
+		})
+	}
+	return lines
+}
+
+// forEachChangeBlock calls fn with the indexes of each run of removed lines and the run of added
+// lines directly following it.
+func forEachChangeBlock(lines []diffLine, fn func(removed, added []int)) {
+	for i := 0; i < len(lines); {
+		var removed, added []int
+		for ; i < len(lines) && lines[i].kind != ' ' && lines[i].kind != '+'; i++ {
+			if lines[i].kind == '-' {
+				removed = append(removed, i)
+			}
+		}
+		for ; i < len(lines) && lines[i].kind != ' ' && lines[i].kind != '-'; i++ {
+			if lines[i].kind == '+' {
+				added = append(added, i)
+			}
+		}
+		if len(removed) == 0 && len(added) == 0 {
+			i++
+			continue
+		}
+		fn(removed, added)
+	}
+}
+
+// renderUnified renders hunk body lines in the classic single column layout.
+func renderUnified(lines []diffLine) []string {
+	out := make([]string, 0, len(lines))
+	for _, line := range lines {
+		switch line.kind {
+		case '+':
+			out = append(out, AdditionStyle.Render("+")+line.rendered)
+		case '-':
+			out = append(out, DeletionStyle.Render("-")+line.rendered)
+		case '\\':
+			out = append(out, diffDimStyle.Render("\\")+line.rendered)
+		default:
+			out = append(out, " "+line.rendered)
+		}
+	}
+	return out
+}
+
+// renderSideBySide renders hunk body lines as old and new columns, each with a line number gutter.
+// Removed and added lines in the same change block share rows. lineRows maps each line to its row.
+func renderSideBySide(lines []diffLine, width int) (out []string, lineRows []int) {
+	colWidth := (width - 3) / 2
+	lineRows = make([]int, len(lines))
+	row := func(left, right *diffLine) {
+		out = append(out, sideBySideCell(left, false, colWidth)+diffDimStyle.Render(" │ ")+
+			sideBySideCell(right, true, colWidth))
+	}
+
+	for i := 0; i < len(lines); {
+		switch lines[i].kind {
+		case '-', '+':
+			var removed, added []*diffLine
+			start := len(out)
+			for i < len(lines) && lines[i].kind != ' ' {
+				if lines[i].kind == '-' && len(added) > 0 {
+					// A removal after additions starts a new change block.
+					break
+				}
+				switch lines[i].kind {
+				case '-':
+					lineRows[i] = start + len(removed)
+					removed = append(removed, &lines[i])
+				case '+':
+					lineRows[i] = start + len(added)
+					added = append(added, &lines[i])
+				default:
+					lineRows[i] = max(start+max(len(removed), len(added))-1, 0)
+				}
+				i++
+			}
+			for k := 0; k < max(len(removed), len(added)); k++ {
+				var left, right *diffLine
+				if k < len(removed) {
+					left = removed[k]
+				}
+				if k < len(added) {
+					right = added[k]
+				}
+				row(left, right)
+			}
+		case '\\':
+			lineRows[i] = max(len(out)-1, 0)
+			i++
+		default:
+			lineRows[i] = len(out)
+			row(&lines[i], &lines[i])
+			i++
+		}
+	}
+	return out, lineRows
+}
+
+// sideBySideCell renders one side of a side-by-side row, truncated and padded to width.
+func sideBySideCell(line *diffLine, newSide bool, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	if line == nil {
+		return strings.Repeat(" ", width)
+	}
+	num := line.oldNum
+	if newSide {
+		num = line.newNum
+	}
+	cell := diffGutterStyle.Render(fmt.Sprintf("%*d ", diffGutterWidth-1, num)) + line.rendered
+	cell = truncate.String(cell, uint(width))
+	if pad := width - lipgloss.Width(cell); pad > 0 {
+		cell += strings.Repeat(" ", pad)
+	}
+	return cell
+}
+
+func expandTabs(s string) string {
+	return strings.ReplaceAll(s, "\t", strings.Repeat(" ", diffTabWidth))
+}
@@ -0,0 +1,53 @@
+package ui
+
+import (
+	"claude-squad/session/git"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffWords(t *testing.T) {
+	oldSpans, newSpans, ok := diffWords(`println("hi there")`, `fmt.Println("hi here")`)
+	require.True(t, ok)
+	assert.Equal(t, []wordSpan{
+		{text: "println", changed: true},
+		{text: `("hi `, changed: false},
+		{text: "there", changed: true},
+		{text: `")`, changed: false},
+	}, oldSpans)
+	assert.Equal(t, []wordSpan{
+		{text: "fmt.Println", changed: true},
+		{text: `("hi `, changed: false},
+		{text: "here", changed: true},
+		{text: `")`, changed: false},
+	}, newSpans)
+}
+
+func TestRenderSideBySide(t *testing.T) {
+	hunk := git.Hunk{
+		OldStart: 10,
+		NewStart: 10,
+		Lines: []string{
+			" context",
+			"-old one",
+			"-old two",
+			"+new one",
+			" tail\tend",
+		},
+	}
+
+	rows, lineRows := renderSideBySide(renderHunkBody(hunk, true), 61)
+	// The two removals share rows with the single addition, so there is one row per side-by-side pair.
+	require.Len(t, rows, 4)
+	for _, row := range rows {
+		assert.Equal(t, 61, lipgloss.Width(row))
+	}
+	assert.Contains(t, rows[1], "old one")
+	assert.Contains(t, rows[1], "new one")
+	assert.Contains(t, rows[2], "old two")
+	assert.Contains(t, rows[3], "tail    end")
+	assert.Equal(t, []int{0, 1, 2, 1, 3}, lineRows)
+}
@@ -3,6 +3,7 @@ package ui
 import (
 	"claude-squad/log"
 	"claude-squad/session"
+	"claude-squad/session/git"
 	"errors"
 	"fmt"
 	"strings"
@@ -13,6 +14,7 @@ import (
 
 const readyIcon = "● "
 const pausedIcon = "⏸ "
+const frozenIcon = "❄ "
 
 var readyStyle = lipgloss.NewStyle().
 	Foreground(lipgloss.AdaptiveColor{Light: "#51bd73", Dark: "#51bd73"})
@@ -23,9 +25,21 @@ var addedLinesStyle = lipgloss.NewStyle().
 var removedLinesStyle = lipgloss.NewStyle().
 	Foreground(lipgloss.Color("#de613e"))
 
+// overlapIcon marks instances changing files that other instances change as well.
+const overlapIcon = "⚠ "
+
+var overlapStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.Color("#e0a526"))
+
+var overlapLinesStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.Color("#de613e"))
+
 var pausedStyle = lipgloss.NewStyle().
 	Foreground(lipgloss.AdaptiveColor{Light: "#888888", Dark: "#888888"})
 
+var frozenStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.AdaptiveColor{Light: "#2f8fd6", Dark: "#6cb6eb"})
+
 var titleStyle = lipgloss.NewStyle().
 	Padding(1, 1, 0, 1).
 	Foreground(lipgloss.AdaptiveColor{Light: "#1a1a1a", Dark: "#dddddd"})
@@ -62,6 +76,8 @@ type List struct {
 	// map of repo name to number of instances using it. Used to display the repo name only if there are
 	// multiple repos in play.
 	repos map[string]int
+	// overlaps holds the files each instance changes along with other instances, see SetOverlaps.
+	overlaps map[*session.Instance][]session.FileOverlap
 }
 
 func NewList(spinner *spinner.Model, autoYes bool) *List {
@@ -80,6 +96,22 @@ func (l *List) SetSize(width, height int) {
 	l.renderer.setWidth(width)
 }
 
+// SetOverlaps sets the files changed by more than one instance. Instances changing any of them are
+// flagged in the list.
+func (l *List) SetOverlaps(overlaps []session.FileOverlap) {
+	l.overlaps = make(map[*session.Instance][]session.FileOverlap)
+	for _, overlap := range overlaps {
+		for _, instance := range overlap.Instances {
+			l.overlaps[instance] = append(l.overlaps[instance], overlap)
+		}
+	}
+}
+
+// GetOverlaps returns the overlapping files changed by the instance.
+func (l *List) GetOverlaps(instance *session.Instance) []session.FileOverlap {
+	return l.overlaps[instance]
+}
+
 // SetSessionPreviewSize sets the height and width for the tmux sessions. This makes the stdout line have the correct
 // width and height.
 func (l *List) SetSessionPreviewSize(width, height int) (err error) {
@@ -113,7 +145,7 @@ func (r *InstanceRenderer) setWidth(width int) {
 // ɹ and ɻ are other options.
 const branchIcon = "Ꮧ"
 
-func (r *InstanceRenderer) Render(i *session.Instance, idx int, selected bool, hasMultipleRepos bool) string {
+func (r *InstanceRenderer) Render(i *session.Instance, idx int, selected bool, hasMultipleRepos bool, overlaps []session.FileOverlap) string {
 	prefix := fmt.Sprintf(" %d. ", idx)
 	if idx >= 10 {
 		prefix = prefix[:len(prefix)-1]
@@ -134,6 +166,8 @@ func (r *InstanceRenderer) Render(i *session.Instance, idx int, selected bool, h
 		join = readyStyle.Render(readyIcon)
 	case session.Paused:
 		join = pausedStyle.Render(pausedIcon)
+	case session.Frozen:
+		join = frozenStyle.Render(frozenIcon)
 	default:
 	}
 
@@ -170,8 +204,21 @@ func (r *InstanceRenderer) Render(i *session.Instance, idx int, selected bool, h
 		)
 	}
 
+	// Warn about files other instances change as well, in red if they change the same lines.
+	var badge string
+	if len(overlaps) > 0 {
+		style := overlapStyle
+		for _, overlap := range overlaps {
+			if overlap.SameLines {
+				style = overlapLinesStyle
+			}
+		}
+		badge = style.Background(descS.GetBackground()).Render(overlapIcon)
+	}
+
 	remainingWidth := r.width
 	remainingWidth -= len(prefix)
+	remainingWidth -= lipgloss.Width(badge)
 	remainingWidth -= len(branchIcon)
 
 	diffWidth := len(addedDiff) + len(removedDiff)
@@ -183,6 +230,12 @@ func (r *InstanceRenderer) Render(i *session.Instance, idx int, selected bool, h
 	remainingWidth -= diffWidth
 
 	branch := i.Branch
+	if !i.Started() && i.CarryChanges() != git.CarryNone {
+		branch = fmt.Sprintf("%s your changes", i.CarryChanges())
+	}
+	if i.CheckedOut() {
+		branch += " (checked out)"
+	}
 	if i.Started() && hasMultipleRepos {
 		repoName, err := i.RepoName()
 		if err != nil {
@@ -210,7 +263,7 @@ func (r *InstanceRenderer) Render(i *session.Instance, idx int, selected bool, h
 		spaces = strings.Repeat(" ", remainingWidth)
 	}
 
-	branchLine := fmt.Sprintf("%s %s-%s%s%s", strings.Repeat(" ", len(prefix)), branchIcon, branch, spaces, diff)
+	branchLine := fmt.Sprintf("%s %s-%s%s%s%s", strings.Repeat(" ", len(prefix)), branchIcon, branch, spaces, badge, diff)
 
 	// join title and subtitle
 	text := lipgloss.JoinVertical(
@@ -251,7 +304,7 @@ func (l *List) String() string {
 
 	// Render the list.
 	for i, item := range l.items {
-		b.WriteString(l.renderer.Render(item, i+1, i == l.selectedIdx, len(l.repos) > 1))
+		b.WriteString(l.renderer.Render(item, i+1, i == l.selectedIdx, len(l.repos) > 1, l.overlaps[item]))
 		if i != len(l.items)-1 {
 			b.WriteString("\n\n")
 		}
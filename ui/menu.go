@@ -54,7 +54,7 @@ type Menu struct {
 }
 
 var defaultMenuOptions = []keys.KeyName{keys.KeyNew, keys.KeyPrompt, keys.KeyHelp, keys.KeyQuit}
-var newInstanceMenuOptions = []keys.KeyName{keys.KeySubmitName}
+var newInstanceMenuOptions = []keys.KeyName{keys.KeySubmitName, keys.KeyCarryChanges}
 var promptMenuOptions = []keys.KeyName{keys.KeySubmitName}
 
 func NewMenu() *Menu {
@@ -126,10 +126,12 @@ func (m *Menu) addInstanceOptions() {
 
 	// Action group
 	actionGroup := []keys.KeyName{keys.KeyEnter, keys.KeySubmit}
-	if m.instance.Status == session.Paused {
-		actionGroup = append(actionGroup, keys.KeyResume)
+	if m.instance.CheckedOut() {
+		actionGroup = append(actionGroup, keys.KeyResume, keys.KeyReturnCheckout)
+	} else if m.instance.Status == session.Paused {
+		actionGroup = append(actionGroup, keys.KeyResume, keys.KeyCheckout)
 	} else {
-		actionGroup = append(actionGroup, keys.KeyCheckout)
+		actionGroup = append(actionGroup, keys.KeyCheckout, keys.KeyFreeze)
 	}
 
 	// Navigation group (when in diff tab)
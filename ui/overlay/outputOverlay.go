@@ -0,0 +1,88 @@
+package overlay
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/reflow/truncate"
+)
+
+// maxOutputLines caps how many lines of output the overlay keeps.
+const maxOutputLines = 1000
+
+var (
+	outputTitleStyle  = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("62"))
+	outputFooterStyle = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#7a7474", Dark: "#9C9494"})
+	outputFailedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#de613e"))
+)
+
+// OutputOverlay shows the live output of a running command, following its end.
+type OutputOverlay struct {
+	title  string
+	lines  []string
+	footer string
+	// failed colors the border and the footer to signal an error.
+	failed bool
+
+	width  int
+	height int
+}
+
+// NewOutputOverlay creates a new output overlay with the given title.
+func NewOutputOverlay(title string) *OutputOverlay {
+	return &OutputOverlay{title: title}
+}
+
+// AppendLines adds lines of output.
+func (o *OutputOverlay) AppendLines(lines ...string) {
+	o.lines = append(o.lines, lines...)
+	if len(o.lines) > maxOutputLines {
+		o.lines = o.lines[len(o.lines)-maxOutputLines:]
+	}
+}
+
+// SetFooter sets the line shown below the output, e.g. the available keys. failed highlights it as
+// an error.
+func (o *OutputOverlay) SetFooter(footer string, failed bool) {
+	o.footer = footer
+	o.failed = failed
+}
+
+// SetSize sets the size of the overlay, including its border.
+func (o *OutputOverlay) SetSize(width, height int) {
+	o.width = width
+	o.height = height
+}
+
+// Render renders the output overlay.
+func (o *OutputOverlay) Render(opts ...WhitespaceOption) string {
+	borderColor := lipgloss.Color("62")
+	if o.failed {
+		borderColor = lipgloss.Color("#de613e")
+	}
+	style := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(borderColor).
+		Padding(0, 1).
+		Width(o.width)
+
+	// Leave room for the border, the title, the footer and the blank lines around the output.
+	innerWidth := max(o.width-4, 1)
+	visible := max(o.height-6, 1)
+	lines := o.lines
+	if len(lines) > visible {
+		lines = lines[len(lines)-visible:]
+	}
+	output := make([]string, visible)
+	for idx, line := range lines {
+		output[idx] = truncate.StringWithTail(strings.ReplaceAll(line, "\t", "    "), uint(innerWidth), "…")
+	}
+
+	footerStyle := outputFooterStyle
+	if o.failed {
+		footerStyle = outputFailedStyle
+	}
+	content := outputTitleStyle.Render(o.title) + "\n\n" + strings.Join(output, "\n") + "\n\n" +
+		footerStyle.Render(o.footer)
+	return style.Render(content)
+}
@@ -56,6 +56,12 @@ func (p *PreviewPane) UpdateContent(instance *session.Instance) error {
 		p.setFallbackState("No agents running yet. Spin up a new instance with 'n' to get started!")
 		return nil
 	case instance.Status == session.Paused:
+		hint := fmt.Sprintf("Press 'c' to check out '%s' in your repository", instance.Branch)
+		if instance.IsSnapshot() {
+			hint = "The snapshot is kept until the session is killed"
+		} else if instance.CheckedOut() {
+			hint = fmt.Sprintf("'%s' is checked out in your repository. Press 'c' to switch back", instance.Branch)
+		}
 		p.setFallbackState(lipgloss.JoinVertical(lipgloss.Center,
 			"Session is paused. Press 'r' to resume.",
 			"",
@@ -64,10 +70,7 @@ func (p *PreviewPane) UpdateContent(instance *session.Instance) error {
 					Light: "#FFD700",
 					Dark:  "#FFD700",
 				}).
-				Render(fmt.Sprintf(
-					"The instance can be checked out at '%s' (copied to your clipboard)",
-					instance.Branch,
-				)),
+				Render(hint),
 		))
 		return nil
 	}
@@ -96,6 +99,10 @@ func (p *PreviewPane) UpdateContent(instance *session.Instance) error {
 			return err
 		}
 
+		if windows := instance.Windows(); len(windows) > 1 {
+			content = renderWindowBar(windows, instance.SelectedWindow()) + "\n" + content
+		}
+
 		// Always update the preview state with content, even if empty
 		// This ensures that newly created instances will display their content immediately
 		if len(content) == 0 && !instance.Started() {
@@ -267,3 +274,23 @@ func (p *PreviewPane) ResetToNormalMode(instance *session.Instance) error {
 
 	return nil
 }
+
+var windowBarStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.AdaptiveColor{Light: "#808080", Dark: "#808080"})
+
+var selectedWindowStyle = lipgloss.NewStyle().
+	Bold(true).
+	Foreground(lipgloss.AdaptiveColor{Light: "#1a1a1a", Dark: "#dddddd"})
+
+// renderWindowBar lists the tmux windows of an instance, highlighting the one being previewed.
+func renderWindowBar(windows []string, selected string) string {
+	parts := make([]string, len(windows))
+	for idx, window := range windows {
+		if window == selected {
+			parts[idx] = selectedWindowStyle.Render("[" + window + "]")
+		} else {
+			parts[idx] = windowBarStyle.Render(window)
+		}
+	}
+	return strings.Join(parts, " ") + windowBarStyle.Render(" · t to switch")
+}
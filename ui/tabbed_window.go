@@ -3,6 +3,7 @@ package ui
 import (
 	"claude-squad/log"
 	"claude-squad/session"
+	"claude-squad/session/git"
 	"github.com/charmbracelet/lipgloss"
 )
 
@@ -33,6 +34,7 @@ var (
 const (
 	PreviewTab int = iota
 	DiffTab
+	CommitsTab
 )
 
 type Tab struct {
@@ -51,17 +53,20 @@ type TabbedWindow struct {
 
 	preview  *PreviewPane
 	diff     *DiffPane
+	commits  *CommitsPane
 	instance *session.Instance
 }
 
-func NewTabbedWindow(preview *PreviewPane, diff *DiffPane) *TabbedWindow {
+func NewTabbedWindow(preview *PreviewPane, diff *DiffPane, commits *CommitsPane) *TabbedWindow {
 	return &TabbedWindow{
 		tabs: []string{
 			"Preview",
 			"Diff",
+			"Commits",
 		},
 		preview: preview,
 		diff:    diff,
+		commits: commits,
 	}
 }
 
@@ -88,6 +93,7 @@ func (w *TabbedWindow) SetSize(width, height int) {
 
 	w.preview.SetSize(contentWidth, contentHeight)
 	w.diff.SetSize(contentWidth, contentHeight)
+	w.commits.SetSize(contentWidth, contentHeight)
 }
 
 func (w *TabbedWindow) GetPreviewSize() (width, height int) {
@@ -123,6 +129,21 @@ func (w *TabbedWindow) UpdateDiff(instance *session.Instance) {
 	w.diff.SetDiff(instance)
 }
 
+// SetCommits updates the commits listed in the commits tab. See CommitsPane.SetCommits.
+func (w *TabbedWindow) SetCommits(instanceID string, commits []git.Commit, err error) {
+	w.commits.SetCommits(instanceID, commits, err)
+}
+
+// ClearCommits empties the commits tab.
+func (w *TabbedWindow) ClearCommits(message string) {
+	w.commits.Clear(message)
+}
+
+// CommitsInstanceID returns the ID of the instance whose commits are listed in the commits tab.
+func (w *TabbedWindow) CommitsInstanceID() string {
+	return w.commits.InstanceID()
+}
+
 // ResetPreviewToNormalMode resets the preview pane to normal mode
 func (w *TabbedWindow) ResetPreviewToNormalMode(instance *session.Instance) error {
 	return w.preview.ResetToNormalMode(instance)
@@ -130,30 +151,132 @@ func (w *TabbedWindow) ResetPreviewToNormalMode(instance *session.Instance) erro
 
 // Add these new methods for handling scroll events
 func (w *TabbedWindow) ScrollUp() {
-	if w.activeTab == PreviewTab {
+	switch w.activeTab {
+	case PreviewTab:
 		err := w.preview.ScrollUp(w.instance)
 		if err != nil {
 			log.InfoLog.Printf("tabbed window failed to scroll up: %v", err)
 		}
-	} else {
+	case DiffTab:
 		w.diff.ScrollUp()
+	case CommitsTab:
+		w.commits.ScrollUp()
 	}
 }
 
 func (w *TabbedWindow) ScrollDown() {
-	if w.activeTab == PreviewTab {
+	switch w.activeTab {
+	case PreviewTab:
 		err := w.preview.ScrollDown(w.instance)
 		if err != nil {
 			log.InfoLog.Printf("tabbed window failed to scroll down: %v", err)
 		}
-	} else {
+	case DiffTab:
 		w.diff.ScrollDown()
+	case CommitsTab:
+		w.commits.ScrollDown()
 	}
 }
 
+// DiffNextFile jumps to the next file in the diff tab
+func (w *TabbedWindow) DiffNextFile() {
+	w.diff.NextFile()
+}
+
+// DiffPrevFile jumps to the previous file in the diff tab
+func (w *TabbedWindow) DiffPrevFile() {
+	w.diff.PrevFile()
+}
+
+// DiffNextHunk jumps to the next hunk in the diff tab
+func (w *TabbedWindow) DiffNextHunk() {
+	w.diff.NextHunk()
+}
+
+// DiffPrevHunk jumps to the previous hunk in the diff tab
+func (w *TabbedWindow) DiffPrevHunk() {
+	w.diff.PrevHunk()
+}
+
+// DiffNextLine moves the line cursor down in the diff tab
+func (w *TabbedWindow) DiffNextLine() {
+	w.diff.NextLine()
+}
+
+// DiffPrevLine moves the line cursor up in the diff tab
+func (w *TabbedWindow) DiffPrevLine() {
+	w.diff.PrevLine()
+}
+
+// DiffToggleFile collapses or expands the selected file in the diff tab
+func (w *TabbedWindow) DiffToggleFile() {
+	w.diff.ToggleCollapse()
+}
+
+// DiffToggleFilter hides or shows generated files in the diff tab
+func (w *TabbedWindow) DiffToggleFilter() {
+	w.diff.ToggleGeneratedFilter()
+}
+
+// DiffToggleSideBySide switches the diff tab between unified and side-by-side layouts
+func (w *TabbedWindow) DiffToggleSideBySide() {
+	w.diff.ToggleSideBySide()
+}
+
+// DiffToggleWordDiff turns word-level highlighting in the diff tab on or off
+func (w *TabbedWindow) DiffToggleWordDiff() {
+	w.diff.ToggleWordDiff()
+}
+
+// SelectedDiffHunk returns the file and hunk selected in the diff tab. See DiffPane.SelectedHunk.
+func (w *TabbedWindow) SelectedDiffHunk() (*git.FileDiff, int) {
+	return w.diff.SelectedHunk()
+}
+
+// SelectedDiffLine returns the file, hunk and line under the cursor in the diff tab. See
+// DiffPane.SelectedLine.
+func (w *TabbedWindow) SelectedDiffLine() (*git.FileDiff, int, int) {
+	return w.diff.SelectedLine()
+}
+
+// CommitsNext selects the next commit in the commits tab
+func (w *TabbedWindow) CommitsNext() {
+	w.commits.Next()
+}
+
+// CommitsPrev selects the previous commit in the commits tab
+func (w *TabbedWindow) CommitsPrev() {
+	w.commits.Prev()
+}
+
+// SelectedCommit returns the commit selected in the commits tab, or nil if there is none.
+func (w *TabbedWindow) SelectedCommit() *git.Commit {
+	return w.commits.SelectedCommit()
+}
+
+// ShowCommitDiff displays the diff of a commit in the commits tab
+func (w *TabbedWindow) ShowCommitDiff(commit git.Commit, content string) {
+	w.commits.ShowDiff(commit, content)
+}
+
+// HideCommitDiff goes back to the list in the commits tab
+func (w *TabbedWindow) HideCommitDiff() {
+	w.commits.HideDiff()
+}
+
+// IsShowingCommitDiff returns true if the commits tab displays the diff of a commit
+func (w *TabbedWindow) IsShowingCommitDiff() bool {
+	return w.commits.ShowingDiff()
+}
+
 // IsInDiffTab returns true if the diff tab is currently active
 func (w *TabbedWindow) IsInDiffTab() bool {
-	return w.activeTab == 1
+	return w.activeTab == DiffTab
+}
+
+// IsInCommitsTab returns true if the commits tab is currently active
+func (w *TabbedWindow) IsInCommitsTab() bool {
+	return w.activeTab == CommitsTab
 }
 
 // IsPreviewInScrollMode returns true if the preview pane is in scroll mode
@@ -202,10 +325,13 @@ func (w *TabbedWindow) String() string {
 
 	row := lipgloss.JoinHorizontal(lipgloss.Top, renderedTabs...)
 	var content string
-	if w.activeTab == 0 {
+	switch w.activeTab {
+	case PreviewTab:
 		content = w.preview.String()
-	} else {
+	case DiffTab:
 		content = w.diff.String()
+	case CommitsTab:
+		content = w.commits.String()
 	}
 	window := windowStyle.Render(
 		lipgloss.Place(